@@ -2,39 +2,372 @@ package app
 
 import (
 	"context"
+	"errors"
+	"github.com/cherrycutter/todo_app/internal/auth"
 	"github.com/cherrycutter/todo_app/internal/db"
 	"github.com/cherrycutter/todo_app/internal/handlers"
+	"github.com/cherrycutter/todo_app/internal/metrics"
+	"github.com/cherrycutter/todo_app/internal/models"
 	"github.com/cherrycutter/todo_app/internal/repos"
 	"github.com/cherrycutter/todo_app/internal/services"
+	"github.com/cherrycutter/todo_app/internal/tracing"
+	"github.com/cherrycutter/todo_app/internal/workers"
 	"github.com/cherrycutter/todo_app/pkg/config"
 	"github.com/cherrycutter/todo_app/pkg/logger"
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
 )
 
+var errNotReady = errors.New("server is not started or shutting down")
+
+// worker is a background job of the app, Run returns once ctx is done and Check reports its state
+type worker interface {
+	Run(ctx context.Context)
+	Check(ctx context.Context) error
+}
+
+// App is the API server together with its background workers and its database pool
+type App struct {
+	cfg    config.Config
+	server *http.Server
+	pool   *pgxpool.Pool
+	// listener is the address the server accepts connections on once started
+	listener net.Listener
+	// admin serves the metrics and the stats on an address of its own, it is nil when they are not served
+	admin *http.Server
+	// traces exports the spans of the app, spans keeps them when the exporter is memory
+	traces *sdktrace.TracerProvider
+	spans  *tracetest.InMemoryExporter
+
+	workers     []worker
+	health      services.HealthService
+	stopWorkers context.CancelFunc
+	running     sync.WaitGroup
+	// cancelRequests cancels the context of the requests still running once the server is shut down
+	cancelRequests context.CancelFunc
+
+	// ready tells whether the app takes traffic, it turns false as soon as the app shuts down
+	ready atomic.Bool
+}
+
+// Run serves the API until SIGINT or SIGTERM, then shuts it down within ShutdownTimeout
 func Run(cfg config.Config) {
-	database, err := db.InitDB(cfg)
+	a, err := New(cfg)
 	if err != nil {
 		logger.Error.Fatal(err)
 	}
+	logger.Info.Println("starting server...")
+	if err = a.Start(); err != nil {
+		logger.Error.Fatal(err)
+	}
+
+	signals, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	<-signals.Done()
+
+	logger.Info.Println("shutting down server...")
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err = a.Shutdown(ctx); err != nil {
+		logger.Error.Printf("server shutdown failed: %s", err)
+		return
+	}
+	logger.Info.Println("server stopped")
+}
+
+// New connects to the database and wires the routes and workers of the API, nothing runs before Start
+func New(cfg config.Config) (*App, error) {
+	pool, err := db.InitDB(cfg)
+	if err != nil {
+		return nil, err
+	}
 	logger.Info.Printf("database connection established successfully")
 
-	defer database.Close(context.Background())
+	exporter, err := tracing.NewExporter(context.Background(), cfg.TraceExporter, cfg.TraceEndpoint)
+	if err != nil {
+		pool.Close()
+		return nil, err
+	}
+	traces, err := tracing.NewProvider(context.Background(), exporter, cfg.TraceSampleRatio)
+	if err != nil {
+		pool.Close()
+		return nil, err
+	}
+	tracer := traces.Tracer(tracing.TracerName)
+
+	// every statement of the repositories is a span of the request that runs it
+	database := repos.NewTracedDB(repos.NewDB(pool), tracer)
+	a := &App{cfg: cfg, pool: pool, traces: traces, health: services.NewHealthService(cfg.ReadinessTimeout)}
+	a.spans, _ = exporter.(*tracetest.InMemoryExporter)
+
+	healthRepo := repos.NewHealthRepo(database)
+	a.health.Register("server", true, func(ctx context.Context) error {
+		if !a.Ready() {
+			return errNotReady
+		}
+		return nil
+	})
+	a.health.Register("database", true, services.DatabaseCheck(healthRepo))
+	a.health.Register("schema", true, services.SchemaCheck(healthRepo, repos.SchemaVersion))
 
+	m := metrics.New()
 	r := gin.Default()
+	r.Use(handlers.Tracing(tracer, tracing.NewPropagator()), handlers.RequestMetrics(m.ObserveRequest))
 
-	repo := repos.NewTodoRepo(database)
-	service := services.NewTodoService(repo)
+	signer, err := newTokenSigner(cfg)
+	if err != nil {
+		pool.Close()
+		return nil, err
+	}
+
+	userRepo := repos.NewUserRepo(database)
+	refreshTokenRepo := repos.NewRefreshTokenRepo(database)
+	authService := services.NewAuthService(userRepo, refreshTokenRepo, signer, cfg.RefreshTokenTTL)
+	apiKeyRepo := repos.NewAPIKeyRepo(database)
+	apiKeyService := services.NewAPIKeyService(apiKeyRepo)
+	authHandler := handlers.NewAuthHandler(authService)
+
+	authHandler.RegisterRoutes(r)
+
+	idempotencyRepo := repos.NewIdempotencyRepo(database)
+	if cfg.IdempotencyStore == "memory" {
+		idempotencyRepo = repos.NewIdempotencyMemoryRepo()
+	}
+	idempotencyService := services.NewIdempotencyService(idempotencyRepo, cfg.IdempotencyKeyTTL)
+
+	// every other route belongs to the authenticated user, its changes can be retried with an Idempotency-Key
+	api := r.Group("/", handlers.BearerAuth(authService, apiKeyService), handlers.Idempotency(idempotencyService))
+
+	repo := repos.NewInstrumentedTodoRepo(repos.NewTodoRepo(database, cfg.MaxTodoDepth), m.QueryObserver("todo"))
+	service := services.NewTracedTodoService(services.NewTodoService(repo), tracer)
 	handler := handlers.NewTodoHandler(service)
 
-	handler.RegisterRoutes(r)
+	handler.RegisterRoutes(api)
+
+	a.addWorker("trash_purger", workers.NewTrashPurger(service, cfg.TrashRetention, cfg.TrashPurgeInterval))
+	a.addWorker("idempotency_purger", workers.NewIdempotencyPurger(idempotencyService, cfg.IdempotencyPurgeInterval))
+
+	tagRepo := repos.NewTagRepo(database)
+	tagService := services.NewTagService(tagRepo, repo)
+	tagHandler := handlers.NewTagHandler(tagService)
+
+	tagHandler.RegisterRoutes(api)
+
+	projectRepo := repos.NewProjectRepo(database)
+	projectService := services.NewProjectService(projectRepo, repo)
+	projectHandler := handlers.NewProjectHandler(projectService, service)
+
+	projectHandler.RegisterRoutes(api)
+
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService)
+
+	apiKeyHandler.RegisterRoutes(api)
+
+	// the event listener takes a connection out of the pool, it holds it while it waits for notifications
+	eventListener := repos.NewEventListener(func(ctx context.Context) (*pgx.Conn, error) {
+		conn, err := pool.Acquire(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return conn.Hijack(), nil
+	})
+	eventService := services.NewEventService(repos.NewEventRepo(database), eventListener, cfg.EventRetention)
+	eventHandler := handlers.NewEventHandler(eventService)
+
+	eventHandler.RegisterRoutes(api)
+
+	// browsers cannot send an Authorization header with a websocket, they pass the token as a subprotocol
+	wsGroup := r.Group("/", handlers.WebSocketProtocolAuth(), handlers.BearerAuth(authService, apiKeyService))
+	wsHandler := handlers.NewWSHandler(service, projectService, eventService)
+
+	wsHandler.RegisterRoutes(wsGroup)
+
+	a.addWorker("event_relay", workers.NewEventRelay(eventService))
+	a.addWorker("event_purger", workers.NewEventPurger(eventService, cfg.EventPurgeInterval))
+
+	webhookService := services.NewWebhookService(repos.NewWebhookRepo(database), services.NewWebhookClient(cfg.WebhookTimeout))
+	webhookHandler := handlers.NewWebhookHandler(webhookService)
+
+	webhookHandler.RegisterRoutes(api)
+
+	a.addWorker("webhook_dispatcher", workers.NewWebhookDispatcher(webhookService, cfg.WebhookPollInterval))
+
+	healthHandler := handlers.NewHealthHandler(a.health)
+
+	healthHandler.RegisterRoutes(r)
+
+	poolStats := func() models.PoolStats {
+		return db.PoolStats(pool)
+	}
+	statsRepo := repos.NewStatsRepo(database)
+	err = m.Register(metrics.NewPoolCollector(poolStats), metrics.NewTodoCollector(statsRepo.CountAllTodos, cfg.MetricsTimeout))
+	if err != nil {
+		pool.Close()
+		return nil, err
+	}
+	// the metrics and the stats need no token, they are only served on the admin address kept off the public network
+	if cfg.MetricsAddr != "" {
+		admin := gin.New()
+		admin.Use(gin.Recovery())
+		admin.GET("/metrics", gin.WrapH(m.Handler()))
+		statsHandler := handlers.NewStatsHandler(poolStats)
+
+		statsHandler.RegisterRoutes(admin)
+
+		a.admin = &http.Server{
+			Addr:         cfg.MetricsAddr,
+			Handler:      admin,
+			ReadTimeout:  cfg.ServerReadTimeout,
+			WriteTimeout: cfg.ServerWriteTimeout,
+			IdleTimeout:  cfg.ServerIdleTimeout,
+		}
+	}
 
 	// swagger endpoint
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
-	logger.Info.Println("starting server...")
-	if err = r.Run(":8080"); err != nil {
-		logger.Error.Fatal(err)
+	a.server = &http.Server{
+		Addr:         cfg.ServerAddr,
+		Handler:      r,
+		ReadTimeout:  cfg.ServerReadTimeout,
+		WriteTimeout: cfg.ServerWriteTimeout,
+		IdleTimeout:  cfg.ServerIdleTimeout,
+	}
+	// event streams and websockets would hold the shutdown until its deadline, their clients reconnect elsewhere
+	a.server.RegisterOnShutdown(eventService.Disconnect)
+	return a, nil
+}
+
+// addWorker adds a background job to the app, its state is reported by the readiness endpoint without
+// making the app unready
+func (a *App) addWorker(name string, w worker) {
+	a.workers = append(a.workers, w)
+	a.health.Register("worker:"+name, false, w.Check)
+}
+
+// Start starts the workers and serves the API in the background, it fails when an address cannot be listened on
+func (a *App) Start() error {
+	listener, err := net.Listen("tcp", a.cfg.ServerAddr)
+	if err != nil {
+		return err
+	}
+	var adminListener net.Listener
+	if a.admin != nil {
+		if adminListener, err = net.Listen("tcp", a.admin.Addr); err != nil {
+			listener.Close()
+			return err
+		}
+	}
+	a.listener = listener
+
+	requests, cancelRequests := context.WithCancel(context.Background())
+	a.cancelRequests = cancelRequests
+	a.server.BaseContext = func(net.Listener) context.Context {
+		return requests
+	}
+
+	ctx, stop := context.WithCancel(context.Background())
+	a.stopWorkers = stop
+	for _, w := range a.workers {
+		a.running.Add(1)
+		go func(w worker) {
+			defer a.running.Done()
+			w.Run(ctx)
+		}(w)
+	}
+
+	go serve(a.server, listener)
+	if a.admin != nil {
+		go serve(a.admin, adminListener)
+	}
+	a.ready.Store(true)
+	return nil
+}
+
+func serve(server *http.Server, listener net.Listener) {
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logger.Error.Printf("server failed: %s", err)
+	}
+}
+
+// Addr returns the address the server listens on, which tells tests the port picked for ":0"
+func (a *App) Addr() string {
+	return a.listener.Addr().String()
+}
+
+// Spans returns the spans ended so far when the trace exporter is memory, which lets tests follow a request
+func (a *App) Spans() tracetest.SpanStubs {
+	if a.spans == nil {
+		return nil
+	}
+	return a.spans.GetSpans()
+}
+
+// Ready tells whether the app takes traffic
+func (a *App) Ready() bool {
+	return a.ready.Load()
+}
+
+// Shutdown stops the app in order. Readiness turns false and, after ShutdownDelay, the server stops accepting
+// connections and waits for its requests until ctx is done, the requests left are canceled. The metrics, which cover
+// the shutdown, stop next, then the workers. The remaining spans are exported and the database is closed last. No
+// step waits past ctx, the ones still running are left behind
+func (a *App) Shutdown(ctx context.Context) error {
+	a.ready.Store(false)
+	// the delay lets load balancers notice the app is not ready before its connections are refused
+	select {
+	case <-time.After(a.cfg.ShutdownDelay):
+	case <-ctx.Done():
+	}
+	err := a.server.Shutdown(ctx)
+	if a.cancelRequests != nil {
+		// the requests left hold connections of the pool, closing it would wait for them
+		a.cancelRequests()
+	}
+	if a.admin != nil {
+		err = errors.Join(err, a.admin.Shutdown(ctx))
+	}
+
+	if a.stopWorkers != nil {
+		a.stopWorkers()
+		err = errors.Join(err, waitUntilDone(ctx, a.running.Wait))
+	}
+	err = errors.Join(err, a.traces.Shutdown(ctx))
+	return errors.Join(err, waitUntilDone(ctx, a.pool.Close))
+}
+
+// waitUntilDone runs fn and waits for it to return until ctx is done
+func waitUntilDone(ctx context.Context, fn func()) error {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn()
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// newTokenSigner returns the access token signer selected by the config
+func newTokenSigner(cfg config.Config) (*auth.TokenSigner, error) {
+	if cfg.JWTSigningMethod == "EdDSA" {
+		return auth.NewEdDSASigner(cfg.JWTKeyFile, cfg.AccessTokenTTL)
 	}
+	return auth.NewHS256Signer([]byte(cfg.JWTSecret), cfg.AccessTokenTTL), nil
 }
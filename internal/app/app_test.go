@@ -0,0 +1,125 @@
+package app
+
+import (
+	"context"
+	"github.com/cherrycutter/todo_app/internal/services"
+	"github.com/cherrycutter/todo_app/pkg/config"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// fakeWorker runs until its context is done and tells when it stopped
+type fakeWorker struct {
+	stopped chan struct{}
+}
+
+func (w *fakeWorker) Run(ctx context.Context) {
+	<-ctx.Done()
+	close(w.stopped)
+}
+
+func (w *fakeWorker) Check(ctx context.Context) error {
+	return nil
+}
+
+// newTestApp returns an app serving handler on a free port with one worker. Its pool is never connected
+func newTestApp(t *testing.T, handler http.Handler, shutdownDelay time.Duration) (*App, *fakeWorker) {
+	pool, err := pgxpool.New(context.Background(), "postgres://todo@127.0.0.1:1/todo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.Config{ServerAddr: "127.0.0.1:0", ShutdownDelay: shutdownDelay}
+	a := &App{
+		cfg:    cfg,
+		server: &http.Server{Handler: handler},
+		pool:   pool,
+		traces: sdktrace.NewTracerProvider(),
+		health: services.NewHealthService(time.Second),
+	}
+	w := &fakeWorker{stopped: make(chan struct{})}
+	a.addWorker("fake", w)
+	return a, w
+}
+
+func TestShutdownDrains(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	a, w := newTestApp(t, http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		rw.WriteHeader(http.StatusNoContent)
+	}), 50*time.Millisecond)
+	assert.False(t, a.Ready())
+	if !assert.NoError(t, a.Start()) {
+		return
+	}
+	assert.True(t, a.Ready())
+
+	responses := make(chan *http.Response, 1)
+	go func() {
+		resp, err := http.Get("http://" + a.Addr() + "/slow")
+		assert.NoError(t, err)
+		responses <- resp
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	shutdown := make(chan error, 1)
+	go func() {
+		shutdown <- a.Shutdown(ctx)
+	}()
+	assert.Eventually(t, func() bool { return !a.Ready() }, time.Second, 5*time.Millisecond)
+
+	// the request in flight is answered before the workers stop
+	select {
+	case <-w.stopped:
+		t.Fatal("worker stopped before the requests drained")
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(release)
+	if resp := <-responses; resp != nil {
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+		resp.Body.Close()
+	}
+	assert.NoError(t, <-shutdown)
+	select {
+	case <-w.stopped:
+	default:
+		t.Error("worker still running")
+	}
+
+	_, err := http.Get("http://" + a.Addr() + "/slow")
+	assert.Error(t, err)
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	started, canceled := make(chan struct{}), make(chan struct{})
+	a, w := newTestApp(t, http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-r.Context().Done()
+		close(canceled)
+	}), 0)
+	if !assert.NoError(t, a.Start()) {
+		return
+	}
+	go http.Get("http://" + a.Addr() + "/stuck")
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	assert.ErrorIs(t, a.Shutdown(ctx), context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+
+	// the stuck request is canceled once the server gave up waiting for it
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Error("request not canceled")
+	}
+	<-w.stopped
+}
@@ -0,0 +1,15 @@
+package app
+
+import (
+	"github.com/cherrycutter/todo_app/pkg/logger"
+	"io"
+	"log"
+	"os"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	logger.Info = log.New(io.Discard, "", 0)
+	logger.Error = log.New(io.Discard, "", 0)
+	os.Exit(m.Run())
+}
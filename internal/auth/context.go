@@ -0,0 +1,16 @@
+package auth
+
+import "context"
+
+type userIdKey struct{}
+
+// WithUserId returns a copy of ctx carrying the id of the authenticated user
+func WithUserId(ctx context.Context, userId int) context.Context {
+	return context.WithValue(ctx, userIdKey{}, userId)
+}
+
+// UserIdFromContext returns the id of the authenticated user, ok is false for anonymous requests
+func UserIdFromContext(ctx context.Context) (int, bool) {
+	userId, ok := ctx.Value(userIdKey{}).(int)
+	return userId, ok
+}
@@ -0,0 +1,32 @@
+package auth
+
+import (
+	"errors"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// dummyHash is compared against when a user does not exist, so that unknown emails take as long as wrong passwords
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy password"), bcrypt.DefaultCost)
+
+// HashPassword returns the bcrypt hash of a password
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// CheckPassword tells whether the password matches the hash, an empty hash never matches
+// but takes as long as a real comparison
+func CheckPassword(hash, password string) (bool, error) {
+	if hash == "" {
+		_ = bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		return false, nil
+	}
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+	return err == nil, err
+}
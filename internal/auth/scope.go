@@ -0,0 +1,60 @@
+package auth
+
+import "context"
+
+// Scopes granted to API keys, requests authenticated with an access token are not restricted
+const (
+	ScopeTodosRead     = "todos:read"
+	ScopeTodosWrite    = "todos:write"
+	ScopeTagsRead      = "tags:read"
+	ScopeTagsWrite     = "tags:write"
+	ScopeProjectsRead  = "projects:read"
+	ScopeProjectsAdmin = "projects:admin"
+)
+
+// Scopes lists every scope an API key can carry
+var Scopes = []string{
+	ScopeTodosRead,
+	ScopeTodosWrite,
+	ScopeTagsRead,
+	ScopeTagsWrite,
+	ScopeProjectsRead,
+	ScopeProjectsAdmin,
+}
+
+// IsScope tells whether s is a known scope
+func IsScope(s string) bool {
+	for _, scope := range Scopes {
+		if scope == s {
+			return true
+		}
+	}
+	return false
+}
+
+type scopesKey struct{}
+
+// WithScopes returns a copy of ctx restricted to the given scopes
+func WithScopes(ctx context.Context, scopes []string) context.Context {
+	return context.WithValue(ctx, scopesKey{}, scopes)
+}
+
+// ScopesFromContext returns the scopes the request is restricted to, ok is false when it is not restricted
+func ScopesFromContext(ctx context.Context) ([]string, bool) {
+	scopes, ok := ctx.Value(scopesKey{}).([]string)
+	return scopes, ok
+}
+
+// HasScope tells whether the request is allowed to act within scope
+func HasScope(ctx context.Context, scope string) bool {
+	scopes, restricted := ScopesFromContext(ctx)
+	if !restricted {
+		return true
+	}
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
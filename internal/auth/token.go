@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"os"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrTokenExpired = errors.New("token has expired")
+	ErrInvalidToken = errors.New("token is invalid")
+)
+
+// TokenSigner issues and verifies the signed access tokens
+type TokenSigner struct {
+	method    jwt.SigningMethod
+	signKey   interface{}
+	verifyKey interface{}
+	ttl       time.Duration
+}
+
+// NewHS256Signer returns a signer using HMAC-SHA256 with the given secret
+func NewHS256Signer(secret []byte, ttl time.Duration) *TokenSigner {
+	return &TokenSigner{method: jwt.SigningMethodHS256, signKey: secret, verifyKey: secret, ttl: ttl}
+}
+
+// NewEdDSASigner returns a signer using the ed25519 private key stored as PKCS #8 PEM in keyFile
+func NewEdDSASigner(keyFile string, ttl time.Duration) (*TokenSigner, error) {
+	pem, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read key file: %w", err)
+	}
+	key, err := jwt.ParseEdPrivateKeyFromPEM(pem)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse key file: %w", err)
+	}
+	privateKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, errors.New("key file does not contain an ed25519 private key")
+	}
+	return &TokenSigner{method: jwt.SigningMethodEdDSA, signKey: privateKey, verifyKey: privateKey.Public(), ttl: ttl}, nil
+}
+
+// Issue returns a new access token for the user and its expiry time
+func (s *TokenSigner) Issue(userId int) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(s.ttl)
+	claims := jwt.RegisteredClaims{
+		Subject:   strconv.Itoa(userId),
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(expiresAt),
+	}
+	token, err := jwt.NewWithClaims(s.method, claims).SignedString(s.signKey)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return token, expiresAt, nil
+}
+
+// Parse verifies an access token and returns the id of its user
+func (s *TokenSigner) Parse(token string) (int, error) {
+	var claims jwt.RegisteredClaims
+	_, err := jwt.ParseWithClaims(token, &claims, func(*jwt.Token) (interface{}, error) {
+		return s.verifyKey, nil
+	}, jwt.WithValidMethods([]string{s.method.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return 0, ErrTokenExpired
+		}
+		return 0, ErrInvalidToken
+	}
+	userId, err := strconv.Atoi(claims.Subject)
+	if err != nil {
+		return 0, ErrInvalidToken
+	}
+	return userId, nil
+}
+
+// NewRefreshToken returns a random opaque refresh token
+func NewRefreshToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashToken returns the SHA-256 hex digest under which an opaque token is stored
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// APIKeyPrefix starts every API key, it tells API keys and access tokens apart
+const APIKeyPrefix = "tda_"
+
+// NewAPIKey returns a random API key
+func NewAPIKey() (string, error) {
+	token, err := NewRefreshToken()
+	if err != nil {
+		return "", err
+	}
+	return APIKeyPrefix + token, nil
+}
@@ -3,21 +3,53 @@ package db
 import (
 	"context"
 	"fmt"
+	"github.com/cherrycutter/todo_app/internal/models"
 	"github.com/cherrycutter/todo_app/pkg/config"
-	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-func InitDB(cfg config.Config) (*pgx.Conn, error) {
+// InitDB opens a connection pool sized and recycled as configured, the pool is safe for concurrent use
+func InitDB(cfg config.Config) (*pgxpool.Pool, error) {
 	connStr := fmt.Sprintf("postgres://%s:%s@%s:%s/%s", cfg.DBUser, cfg.DBPass, cfg.DBHost, cfg.DBPort, cfg.DBName)
 
-	conn, err := pgx.Connect(context.Background(), connStr)
+	poolCfg, err := pgxpool.ParseConfig(connStr)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse database config: %w", err)
+	}
+	poolCfg.MaxConns = cfg.DBMaxConns
+	poolCfg.MinConns = cfg.DBMinConns
+	poolCfg.MaxConnIdleTime = cfg.DBMaxConnIdleTime
+	poolCfg.MaxConnLifetime = cfg.DBMaxConnLifetime
+	poolCfg.HealthCheckPeriod = cfg.DBHealthCheckPeriod
+
+	pool, err := pgxpool.NewWithConfig(context.Background(), poolCfg)
 	if err != nil {
 		return nil, fmt.Errorf("unable to connect to database: %w", err)
 	}
 
-	if err = conn.Ping(context.Background()); err != nil {
+	if err = pool.Ping(context.Background()); err != nil {
+		pool.Close()
 		return nil, fmt.Errorf("unable to ping database: %w", err)
 	}
 
-	return conn, nil
+	return pool, nil
+}
+
+// PoolStats returns a snapshot of the connections of a pool and how they were used
+func PoolStats(pool *pgxpool.Pool) models.PoolStats {
+	stat := pool.Stat()
+	return models.PoolStats{
+		MaxConns:                stat.MaxConns(),
+		TotalConns:              stat.TotalConns(),
+		IdleConns:               stat.IdleConns(),
+		AcquiredConns:           stat.AcquiredConns(),
+		ConstructingConns:       stat.ConstructingConns(),
+		AcquireCount:            stat.AcquireCount(),
+		AcquireDurationMs:       stat.AcquireDuration().Milliseconds(),
+		EmptyAcquireCount:       stat.EmptyAcquireCount(),
+		CanceledAcquireCount:    stat.CanceledAcquireCount(),
+		NewConnsCount:           stat.NewConnsCount(),
+		MaxIdleDestroyCount:     stat.MaxIdleDestroyCount(),
+		MaxLifetimeDestroyCount: stat.MaxLifetimeDestroyCount(),
+	}
 }
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/cherrycutter/todo_app/internal/services"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+type APIKeyHandler struct {
+	service services.APIKeyService
+}
+
+func NewAPIKeyHandler(service services.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{service: service}
+}
+
+func (h *APIKeyHandler) RegisterRoutes(router gin.IRouter) {
+	keys := router.Group("/api-keys", RequireUserToken())
+	keys.GET("", h.GetAPIKeys)
+	keys.POST("", h.PostAPIKey)
+	keys.POST("/:id/rotate", h.RotateAPIKey)
+	keys.DELETE("/:id", h.RevokeAPIKey)
+}
+
+// GetAPIKeys godoc
+// @Summary Get all API keys
+// @Description Returns the API keys of the user that are not revoked, without their secret
+// @Tags api-keys
+// @Accept  json
+// @Produce  json
+// @Success 200 {array} models.APIKeyModel
+// @Failure 401 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /api-keys [get]
+func (h *APIKeyHandler) GetAPIKeys(ctx *gin.Context) {
+	keys, err := h.service.GetAPIKeys(ctx.Request.Context())
+	if err != nil {
+		newServiceErrorResponse(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, keys)
+}
+
+// PostAPIKey godoc
+// @Summary Create a new API key
+// @Description Creates an API key with a name and scopes, the key is only shown in this response
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Param key body models.APIKeyModel true "Name and scopes of the key"
+// @Success 201 {object} models.CreatedAPIKey
+// @Failure 400 {object} errorResponse
+// @Failure 401 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /api-keys [post]
+func (h *APIKeyHandler) PostAPIKey(ctx *gin.Context) {
+	var key models.APIKeyModel
+	if err := ctx.BindJSON(&key); err != nil {
+		newErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	createdKey, err := h.service.CreateAPIKey(ctx.Request.Context(), key)
+	if err != nil {
+		newServiceErrorResponse(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, createdKey)
+}
+
+// RotateAPIKey godoc
+// @Summary Rotate an API key
+// @Description Replaces the secret of an API key, the old secret stops working immediately
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Param id path int true "API key ID"
+// @Success 200 {object} models.CreatedAPIKey
+// @Failure 400 {object} errorResponse
+// @Failure 401 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /api-keys/{id}/rotate [post]
+func (h *APIKeyHandler) RotateAPIKey(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		newErrorResponse(ctx, http.StatusBadRequest, "invalid id param")
+		return
+	}
+	rotatedKey, err := h.service.RotateAPIKey(ctx.Request.Context(), id)
+	if err != nil {
+		newServiceErrorResponse(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, rotatedKey)
+}
+
+// RevokeAPIKey godoc
+// @Summary Revoke an API key
+// @Description Revokes an API key by id
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Param id path int true "API key ID"
+// @Success 200 {object} errorResponse
+// @Failure 400 {object} errorResponse
+// @Failure 401 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /api-keys/{id} [delete]
+func (h *APIKeyHandler) RevokeAPIKey(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		newErrorResponse(ctx, http.StatusBadRequest, "invalid id param")
+		return
+	}
+	if err = h.service.RevokeAPIKey(ctx.Request.Context(), id); err != nil {
+		newServiceErrorResponse(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "api key revoked successfully"})
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+// GetTodoHistory godoc
+// @Summary Get the history of a todo
+// @Description Returns a page of the audit entries of a todo newest first, each with the changed fields and their old and new values. The history stays available after the todo is deleted
+// @Tags audit
+// @Accept json
+// @Produce json
+// @Param id path int true "Todo ID"
+// @Param limit query int false "Page size (1-100)" default(20)
+// @Param cursor query string false "Cursor returned as next_cursor by the previous page"
+// @Success 200 {object} models.AuditPage
+// @Failure 400 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /todo/{id}/history [get]
+func (h *TodoHandler) GetTodoHistory(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		newErrorResponse(ctx, http.StatusBadRequest, "invalid id param")
+		return
+	}
+	if err = checkQueryParams(ctx, todoHistoryQueryParams); err != nil {
+		newErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	params, err := parseListParams(ctx)
+	if err != nil {
+		newErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	page, err := h.service.GetTodoHistory(ctx.Request.Context(), id, params)
+	if err != nil {
+		newServiceErrorResponse(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, page)
+}
+
+// GetAuditLog godoc
+// @Summary Get the audit log
+// @Description Returns a filtered page of the audit entries of all todos of the user newest first
+// @Tags audit
+// @Accept json
+// @Produce json
+// @Param limit query int false "Page size (1-100)" default(20)
+// @Param cursor query string false "Cursor returned as next_cursor by the previous page"
+// @Param actor_id query int false "Only changes made by this user"
+// @Param operation query string false "Only changes of this kind" Enums(create, update, delete, restore, purge)
+// @Param from query string false "Only changes made at or after this RFC 3339 timestamp"
+// @Param to query string false "Only changes made before this RFC 3339 timestamp"
+// @Success 200 {object} models.AuditPage
+// @Failure 400 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /audit [get]
+func (h *TodoHandler) GetAuditLog(ctx *gin.Context) {
+	if err := checkQueryParams(ctx, auditQueryParams); err != nil {
+		newErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	params, err := parseListParams(ctx)
+	if err != nil {
+		newErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	filter, err := parseAuditFilter(ctx)
+	if err != nil {
+		newErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	page, err := h.service.GetAuditLog(ctx.Request.Context(), filter, params)
+	if err != nil {
+		newServiceErrorResponse(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, page)
+}
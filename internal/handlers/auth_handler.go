@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/cherrycutter/todo_app/internal/services"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+type AuthHandler struct {
+	service services.AuthService
+}
+
+func NewAuthHandler(service services.AuthService) *AuthHandler {
+	return &AuthHandler{service: service}
+}
+
+func (h *AuthHandler) RegisterRoutes(router gin.IRouter) {
+	router.POST("/auth/register", h.Register)
+	router.POST("/auth/login", h.Login)
+	router.POST("/auth/refresh", h.Refresh)
+	router.POST("/auth/logout", h.Logout)
+}
+
+// Register godoc
+// @Summary Register a new user
+// @Description Creates a user account, the password must be 8 to 72 bytes long
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param credentials body models.Credentials true "Email and password"
+// @Success 201 {object} models.UserModel
+// @Failure 400 {object} errorResponse
+// @Failure 409 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /auth/register [post]
+func (h *AuthHandler) Register(ctx *gin.Context) {
+	var credentials models.Credentials
+	if err := ctx.BindJSON(&credentials); err != nil {
+		newErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	user, err := h.service.Register(ctx.Request.Context(), credentials)
+	if err != nil {
+		newServiceErrorResponse(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, user)
+}
+
+// Login godoc
+// @Summary Log in
+// @Description Checks the credentials of a user and returns an access token with a refresh token
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param credentials body models.Credentials true "Email and password"
+// @Success 200 {object} models.TokenPair
+// @Failure 400 {object} errorResponse
+// @Failure 401 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /auth/login [post]
+func (h *AuthHandler) Login(ctx *gin.Context) {
+	var credentials models.Credentials
+	if err := ctx.BindJSON(&credentials); err != nil {
+		newErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	tokens, err := h.service.Login(ctx.Request.Context(), credentials)
+	if err != nil {
+		newServiceErrorResponse(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, tokens)
+}
+
+// Refresh godoc
+// @Summary Refresh the access token
+// @Description Exchanges a refresh token for a new token pair, the refresh token cannot be used again
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body models.RefreshRequest true "Refresh token"
+// @Success 200 {object} models.TokenPair
+// @Failure 400 {object} errorResponse
+// @Failure 401 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /auth/refresh [post]
+func (h *AuthHandler) Refresh(ctx *gin.Context) {
+	var request models.RefreshRequest
+	if err := ctx.BindJSON(&request); err != nil {
+		newErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	tokens, err := h.service.Refresh(ctx.Request.Context(), request.RefreshToken)
+	if err != nil {
+		newServiceErrorResponse(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, tokens)
+}
+
+// Logout godoc
+// @Summary Log out
+// @Description Revokes a refresh token
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body models.RefreshRequest true "Refresh token"
+// @Success 200 {object} errorResponse
+// @Failure 400 {object} errorResponse
+// @Failure 401 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /auth/logout [post]
+func (h *AuthHandler) Logout(ctx *gin.Context) {
+	var request models.RefreshRequest
+	if err := ctx.BindJSON(&request); err != nil {
+		newErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := h.service.Logout(ctx.Request.Context(), request.RefreshToken); err != nil {
+		newServiceErrorResponse(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "logged out successfully"})
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/cherrycutter/todo_app/internal/services"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+// bulkSuccessStatus is the status of a successful bulk operation, the one of the matching single todo request
+var bulkSuccessStatus = map[models.BulkOp]int{
+	models.BulkCreate: http.StatusCreated,
+	models.BulkUpdate: http.StatusOK,
+	models.BulkDelete: http.StatusOK,
+}
+
+// BulkTodos godoc
+// @Summary Create, update and delete todos in bulk
+// @Description Runs a list of create, update and delete operations in order. In atomic mode all of them are applied in one transaction or none is, the first failure is returned with its status. In best_effort mode every result carries its own status and error
+// @Tags todos
+// @Accept json
+// @Produce json
+// @Param request body models.TodoBulkRequest true "Bulk Request"
+// @Success 200 {object} models.TodoBulkResponse
+// @Failure 400 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 409 {object} errorResponse
+// @Failure 412 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /todos/bulk [post]
+func (h *TodoHandler) BulkTodos(ctx *gin.Context) {
+	var req models.TodoBulkRequest
+	if err := ctx.BindJSON(&req); err != nil {
+		newErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	resp, err := h.service.BulkTodos(ctx.Request.Context(), req)
+	if err != nil {
+		var bulkErr *services.BulkOperationError
+		if errors.As(err, &bulkErr) {
+			statusCode, message := serviceErrorStatus(bulkErr.Err)
+			newErrorResponse(ctx, statusCode, "operation "+strconv.Itoa(bulkErr.Index)+": "+message)
+			return
+		}
+		newServiceErrorResponse(ctx, err)
+		return
+	}
+	for i := range resp.Results {
+		result := &resp.Results[i]
+		if result.Err != nil {
+			result.Status, result.Error = serviceErrorStatus(result.Err)
+		} else {
+			result.Status = bulkSuccessStatus[result.Op]
+		}
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
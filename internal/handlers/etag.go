@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/cherrycutter/todo_app/internal/services"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+var (
+	errInvalidIfMatch = errors.New(`invalid If-Match header: must be a todo ETag like "3" or *`)
+	errIfMatchFailed  = errors.New("If-Match header does not match the ETag of the todo")
+)
+
+// todoETag returns the strong ETag of a todo, it changes with every write of the todo
+func todoETag(todo models.TodoModel) string {
+	return `"` + strconv.Itoa(todo.Version) + `"`
+}
+
+// writeTodo sends a todo together with its ETag
+func writeTodo(ctx *gin.Context, statusCode int, todo models.TodoModel) {
+	ctx.Header("ETag", todoETag(todo))
+	ctx.JSON(statusCode, todo)
+}
+
+// parseIfMatch reads the version required by the If-Match header, nil when the header is missing or *.
+// If-Match uses the strong comparison, so weak tags never match, and the write is conditioned on a single
+// version, so a list naming several versions fails as well. Both give errIfMatchFailed
+func parseIfMatch(ctx *gin.Context) (*int, error) {
+	header := strings.TrimSpace(ctx.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return nil, nil
+	}
+	var versions []int
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		weak := strings.HasPrefix(tag, "W/")
+		tag = strings.TrimPrefix(tag, "W/")
+		if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+			return nil, errInvalidIfMatch
+		}
+		// an opaque tag that is not a version is well formed but cannot match a todo
+		version, err := strconv.Atoi(tag[1 : len(tag)-1])
+		if !weak && err == nil {
+			versions = append(versions, version)
+		}
+	}
+	if len(versions) != 1 {
+		return nil, errIfMatchFailed
+	}
+	return &versions[0], nil
+}
+
+// ifMatchErrorStatus returns the status code answering an error of parseIfMatch
+func ifMatchErrorStatus(err error) int {
+	if errors.Is(err, errIfMatchFailed) {
+		return http.StatusPreconditionFailed
+	}
+	return http.StatusBadRequest
+}
+
+// ifMatchError answers an error of parseIfMatch for the todo id. A header that cannot match gets the current todo
+// and its ETag like a version mismatch, so that the client can retry with the right one
+func (h *TodoHandler) ifMatchError(ctx *gin.Context, id int, err error) {
+	if ifMatchErrorStatus(err) != http.StatusPreconditionFailed {
+		newErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	current, err := h.service.GetTodo(ctx.Request.Context(), id)
+	if err != nil {
+		newServiceErrorResponse(ctx, err)
+		return
+	}
+	newServiceErrorResponse(ctx, &services.PreconditionFailedError{Current: current})
+}
+
+// notModified answers with 304 Not Modified when the If-None-Match header lists the ETag of the todo,
+// weak tags compare like strong ones
+func notModified(ctx *gin.Context, todo models.TodoModel) bool {
+	header := ctx.GetHeader("If-None-Match")
+	if header == "" {
+		return false
+	}
+	etag := todoETag(todo)
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			ctx.Header("ETag", etag)
+			ctx.Status(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
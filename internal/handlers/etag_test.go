@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"encoding/json"
+	"github.com/cherrycutter/todo_app/internal/models"
+	mock_services "github.com/cherrycutter/todo_app/internal/services/mocks"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseIfMatch(t *testing.T) {
+	version := 3
+	tests := []struct {
+		name       string
+		header     string
+		want       *int
+		wantStatus int
+	}{
+		{name: "Missing", header: ""},
+		{name: "Any", header: "*"},
+		{name: "Strong", header: `"3"`, want: &version},
+		{name: "Strong Among Weak", header: `W/"2", "3"`, want: &version},
+		{name: "Weak", header: `W/"3"`, wantStatus: http.StatusPreconditionFailed},
+		{name: "Several Versions", header: `"3", "4"`, wantStatus: http.StatusPreconditionFailed},
+		{name: "Opaque", header: `"abc"`, wantStatus: http.StatusPreconditionFailed},
+		{name: "Unquoted", header: "3", wantStatus: http.StatusBadRequest},
+		{name: "Unquoted In List", header: `"3", 4`, wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+			ctx.Request = httptest.NewRequest(http.MethodPut, "/todos/1", nil)
+			ctx.Request.Header.Set("If-Match", tt.header)
+
+			got, err := parseIfMatch(ctx)
+			if tt.wantStatus != 0 {
+				assert.Error(t, err)
+				assert.Equal(t, tt.wantStatus, ifMatchErrorStatus(err))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestIfMatchFailed(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		header string
+	}{
+		{name: "Weak Put", method: http.MethodPut, header: `W/"4"`},
+		{name: "Several Versions Patch", method: http.MethodPatch, header: `"3", "4"`},
+		{name: "Opaque Delete", method: http.MethodDelete, header: `"abc"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			service := mock_services.NewMockTodoService(ctrl)
+			// nothing is written, the client gets the todo as it is now
+			service.EXPECT().GetTodo(gomock.Any(), 1).Return(models.TodoModel{Id: 1, Title: "Buy milk", Version: 4}, nil)
+			r := gin.New()
+			NewTodoHandler(service).RegisterRoutes(r)
+
+			req := httptest.NewRequest(tt.method, "/todo/1", strings.NewReader(`{"title":"Buy oat milk"}`))
+			req.Header.Set("If-Match", tt.header)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+			assert.Equal(t, `"4"`, w.Header().Get("ETag"))
+			var current models.TodoModel
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &current))
+			assert.Equal(t, "Buy milk", current.Title)
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/cherrycutter/todo_app/internal/auth"
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/cherrycutter/todo_app/internal/services"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// eventKeepAliveInterval is how often an idle stream sends a comment, so that proxies do not close it
+const eventKeepAliveInterval = 15 * time.Second
+
+type EventHandler struct {
+	service services.EventService
+}
+
+func NewEventHandler(service services.EventService) *EventHandler {
+	return &EventHandler{service: service}
+}
+
+func (h *EventHandler) RegisterRoutes(router gin.IRouter) {
+	router.GET("/events", RequireScope(auth.ScopeTodosRead), h.GetEvents)
+}
+
+// GetEvents godoc
+// @Summary Stream todo changes
+// @Description Streams the created, updated and deleted todos of the user as Server-Sent Events, the event id resumes the stream through Last-Event-ID. A reset event means changes were lost and the todos have to be fetched again
+// @Tags events
+// @Produce text/event-stream
+// @Param Last-Event-ID header int false "Id of the last event received, the events following it are replayed"
+// @Success 200 {object} models.TodoEvent
+// @Failure 400 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /events [get]
+func (h *EventHandler) GetEvents(ctx *gin.Context) {
+	var lastEventId *int64
+	if header := ctx.GetHeader("Last-Event-ID"); header != "" {
+		id, err := strconv.ParseInt(header, 10, 64)
+		if err != nil || id < 0 {
+			newErrorResponse(ctx, http.StatusBadRequest, "invalid Last-Event-ID header")
+			return
+		}
+		lastEventId = &id
+	}
+	events, err := h.service.Subscribe(ctx.Request.Context(), lastEventId)
+	if err != nil {
+		newServiceErrorResponse(ctx, err)
+		return
+	}
+
+	// a stream outlives the write timeout of the server
+	if err = http.NewResponseController(ctx.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		newServiceErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.Status(http.StatusOK)
+	ctx.Writer.Flush()
+
+	keepAlive := time.NewTicker(eventKeepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				// the client reconnects and resumes after the last event it received
+				return
+			}
+			if err = writeEvent(ctx.Writer, event); err != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err = io.WriteString(ctx.Writer, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		}
+		ctx.Writer.Flush()
+	}
+}
+
+// writeEvent writes an event in the text/event-stream format. A reset event has an empty id, which clears
+// the Last-Event-ID of the client so that it does not resume from the lost events again
+func writeEvent(w io.Writer, event models.TodoEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	id := ""
+	if event.Id != 0 {
+		id = strconv.FormatInt(event.Id, 10)
+	}
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", id, event.Type, data)
+	return err
+}
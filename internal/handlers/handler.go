@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"errors"
+	"github.com/cherrycutter/todo_app/internal/auth"
 	"github.com/cherrycutter/todo_app/internal/models"
 	"github.com/cherrycutter/todo_app/internal/repos"
 	"github.com/cherrycutter/todo_app/internal/services"
@@ -18,40 +19,205 @@ func NewTodoHandler(service services.TodoService) *TodoHandler {
 	return &TodoHandler{service: service}
 }
 
-func (h *TodoHandler) RegisterRoutes(router *gin.Engine) {
-	router.GET("/todos", h.GetTodos)
-	router.GET("/todo/:id", h.GetTodo)
-	router.POST("/todo", h.PostTodo)
-	router.PATCH("/todo/:id", h.UpdateTodo)
-	router.DELETE("/todo/:id", h.DeleteTodo)
+func (h *TodoHandler) RegisterRoutes(router gin.IRouter) {
+	read := RequireScope(auth.ScopeTodosRead)
+	write := RequireScope(auth.ScopeTodosWrite)
+
+	router.GET("/todos", read, h.GetTodos)
+	router.GET("/todos/overdue", read, h.GetOverdueTodos)
+	router.GET("/todos/due", read, h.GetDueTodos)
+	router.GET("/todos/search", read, h.SearchTodos)
+	router.GET("/todo/:id", read, h.GetTodo)
+	router.POST("/todo", write, h.PostTodo)
+	router.POST("/todos/bulk", write, h.BulkTodos)
+	router.PUT("/todo/:id", write, h.UpdateTodo)
+	router.PATCH("/todo/:id", write, h.PatchTodo)
+	router.DELETE("/todo/:id", write, h.DeleteTodo)
+	router.GET("/todo/:id/subtasks", read, h.GetSubtasks)
+	router.POST("/todo/:id/subtasks", write, h.PostSubtask)
+	router.PUT("/todo/:id/subtasks/order", write, h.ReorderSubtasks)
+	router.POST("/todo/:id/subtasks/:subtask_id/toggle", write, h.ToggleSubtask)
+	router.GET("/todo/:id/occurrences", read, h.GetOccurrences)
+	router.GET("/todo/:id/history", read, h.GetTodoHistory)
+	router.GET("/audit", read, h.GetAuditLog)
+	router.GET("/trash", read, h.GetTrash)
+	router.POST("/trash/:id/restore", write, h.RestoreTodo)
+	router.DELETE("/trash/:id", write, h.DeleteTrashedTodo)
 }
 
 // GetTodos godoc
-// @Summary Get all todos
-// @Description Returns a list of all todos
+// @Summary Get todos
+// @Description Returns a filtered page of todos, ordered by creation time unless sort is given. Use next_cursor to fetch the following page
 // @Tags todos
 // @Accept  json
 // @Produce  json
-// @Success 200 {array} models.TodoModel
+// @Param limit query int false "Page size (1-100)" default(20)
+// @Param cursor query string false "Cursor returned as next_cursor by the previous page"
+// @Param with_total query bool false "Include the total number of todos"
+// @Param completed query bool false "Only completed or only open todos"
+// @Param created_after query string false "Only todos created after this RFC 3339 timestamp"
+// @Param created_before query string false "Only todos created before this RFC 3339 timestamp"
+// @Param title_contains query string false "Case-insensitive substring of the title"
+// @Param tag query []string false "Tag names, repeat the param to pass several" collectionFormat(multi)
+// @Param tag_match query string false "Whether todos must carry any or all of the tags" Enums(any, all) default(any)
+// @Param project_id query int false "Only todos of this project"
+// @Param series_id query int false "Only the instances of this recurring series"
+// @Param sort query string false "Comma separated field:direction list, fields are id, title, completed, created_at and due_at" example(created_at:desc,title:asc)
+// @Success 200 {object} models.TodoPage
+// @Failure 400 {object} errorResponse
 // @Failure 500 {object} errorResponse
 // @Router /todos [get]
 func (h *TodoHandler) GetTodos(ctx *gin.Context) {
-	todos, err := h.service.GetTodos(ctx.Request.Context())
+	listTodos(ctx, h.service, todoListQueryParams, nil)
+}
+
+// GetOverdueTodos godoc
+// @Summary Get overdue todos
+// @Description Returns a page of open todos whose due date has passed, ordered by due date unless sort is given
+// @Tags todos
+// @Accept  json
+// @Produce  json
+// @Param limit query int false "Page size (1-100)" default(20)
+// @Param cursor query string false "Cursor returned as next_cursor by the previous page"
+// @Param with_total query bool false "Include the total number of todos"
+// @Param sort query string false "Comma separated field:direction list" example(due_at:asc)
+// @Success 200 {object} models.TodoPage
+// @Failure 400 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /todos/overdue [get]
+func (h *TodoHandler) GetOverdueTodos(ctx *gin.Context) {
+	listTodos(ctx, h.service, todoOverdueQueryParams, func(filter *models.TodoFilter) error {
+		filter.Overdue = true
+		if filter.Sort == nil {
+			filter.Sort = []models.SortField{{Field: "due_at"}}
+		}
+		return nil
+	})
+}
+
+// GetDueTodos godoc
+// @Summary Get todos due in a time range
+// @Description Returns a page of todos due within [from, to), ordered by due date unless sort is given
+// @Tags todos
+// @Accept  json
+// @Produce  json
+// @Param from query string false "Start of the range, RFC 3339 timestamp"
+// @Param to query string false "End of the range, RFC 3339 timestamp"
+// @Param limit query int false "Page size (1-100)" default(20)
+// @Param cursor query string false "Cursor returned as next_cursor by the previous page"
+// @Param with_total query bool false "Include the total number of todos"
+// @Param completed query bool false "Only completed or only open todos"
+// @Param sort query string false "Comma separated field:direction list" example(due_at:asc)
+// @Success 200 {object} models.TodoPage
+// @Failure 400 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /todos/due [get]
+func (h *TodoHandler) GetDueTodos(ctx *gin.Context) {
+	listTodos(ctx, h.service, todoDueQueryParams, func(filter *models.TodoFilter) error {
+		var err error
+		if filter.DueFrom, err = parseTimeParam(ctx, "from"); err != nil {
+			return err
+		}
+		if filter.DueTo, err = parseTimeParam(ctx, "to"); err != nil {
+			return err
+		}
+		if filter.DueFrom == nil && filter.DueTo == nil {
+			return errors.New("at least one of the from and to params is required")
+		}
+		if filter.DueFrom != nil && filter.DueTo != nil && !filter.DueFrom.Before(*filter.DueTo) {
+			return errors.New("invalid from param: must be before to")
+		}
+		if filter.Sort == nil {
+			filter.Sort = []models.SortField{{Field: "due_at"}}
+		}
+		return nil
+	})
+}
+
+// SearchTodos godoc
+// @Summary Search todos
+// @Description Full-text search over title and description, best matches first. Words are combined with AND, "quoted words" match as a phrase, word* matches as a prefix and -word excludes a word. Matches are wrapped in <mark> in the highlights, which are not HTML-escaped
+// @Tags todos
+// @Accept  json
+// @Produce  json
+// @Param q query string true "Search query" example("quarterly report" dead*)
+// @Param limit query int false "Maximum number of hits (1-100)" default(20)
+// @Param completed query bool false "Only completed or only open todos"
+// @Success 200 {object} models.TodoSearchResult
+// @Failure 400 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /todos/search [get]
+func (h *TodoHandler) SearchTodos(ctx *gin.Context) {
+	if err := checkQueryParams(ctx, todoSearchQueryParams); err != nil {
+		newErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	params, err := parseListParams(ctx)
+	if err != nil {
+		newErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	filter, err := parseTodoFilter(ctx)
+	if err != nil {
+		newErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	search := models.TodoSearch{Query: ctx.Query("q"), Completed: filter.Completed}
+	result, err := h.service.SearchTodos(ctx.Request.Context(), search, params.Limit)
+	if err != nil {
+		newServiceErrorResponse(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, result)
+}
+
+// listTodos serves a todo list endpoint, extend adds the endpoint specific conditions to the parsed filter
+func listTodos(ctx *gin.Context, service services.TodoService, allowed map[string]bool, extend func(filter *models.TodoFilter) error) {
+	if err := checkQueryParams(ctx, allowed); err != nil {
+		newErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	params, err := parseListParams(ctx)
+	if err != nil {
+		newErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	filter, err := parseTodoFilter(ctx)
 	if err != nil {
-		newErrorResponse(ctx, http.StatusInternalServerError, "failed to get todos")
+		newErrorResponse(ctx, http.StatusBadRequest, err.Error())
 		return
 	}
-	ctx.JSON(http.StatusOK, todos)
+	if extend != nil {
+		if err = extend(&filter); err != nil {
+			newErrorResponse(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	page, err := service.GetTodos(ctx.Request.Context(), filter, params)
+	if err != nil {
+		var filterErr *repos.FilterError
+		if errors.Is(err, services.ErrInvalidCursor) {
+			newErrorResponse(ctx, http.StatusBadRequest, "invalid cursor param")
+		} else if errors.As(err, &filterErr) {
+			newErrorResponse(ctx, http.StatusBadRequest, filterErr.Error())
+		} else {
+			newErrorResponse(ctx, http.StatusInternalServerError, "failed to get todos")
+		}
+		return
+	}
+	ctx.JSON(http.StatusOK, page)
 }
 
 // GetTodo godoc
 // @Summary Get todo by ID
-// @Description Returns one todo by id
+// @Description Returns one todo by id with its version as ETag, 304 when If-None-Match lists that ETag
 // @Tags todos
 // @Accept json
 // @Produce json
 // @Param id path int true "Todo ID"
+// @Param If-None-Match header string false "ETags of the todo already known"
 // @Success 200 {object} models.TodoModel
+// @Success 304
 // @Failure 400 {object} errorResponse
 // @Failure 404 {object} errorResponse
 // @Failure 500 {object} errorResponse
@@ -64,14 +230,13 @@ func (h *TodoHandler) GetTodo(ctx *gin.Context) {
 	}
 	todo, err := h.service.GetTodo(ctx.Request.Context(), id)
 	if err != nil {
-		if errors.Is(err, repos.ErrTodoNotFound) {
-			newErrorResponse(ctx, http.StatusNotFound, "todo not found")
-		} else {
-			newErrorResponse(ctx, http.StatusInternalServerError, err.Error())
-		}
+		newServiceErrorResponse(ctx, err)
 		return
 	}
-	ctx.JSON(http.StatusOK, todo)
+	if notModified(ctx, todo) {
+		return
+	}
+	writeTodo(ctx, http.StatusOK, todo)
 }
 
 // PostTodo godoc
@@ -93,58 +258,107 @@ func (h *TodoHandler) PostTodo(ctx *gin.Context) {
 	}
 	createdTodo, err := h.service.CreateTodo(ctx.Request.Context(), todo)
 	if err != nil {
-		newErrorResponse(ctx, http.StatusInternalServerError, err.Error())
+		newServiceErrorResponse(ctx, err)
 		return
 	}
-	ctx.JSON(http.StatusCreated, createdTodo)
+	writeTodo(ctx, http.StatusCreated, createdTodo)
 }
 
 // UpdateTodo godoc
-// @Summary Update an existing todo
-// @Description Updates an existing todo by id
+// @Summary Replace an existing todo
+// @Description Replaces all fields of an existing todo by id, with cascade=true completing it also completes its subtasks. Completing an instance of a recurring todo creates the next instance of its series
 // @Tags todos
 // @Accept json
 // @Produce json
 // @Param id path int true "Todo ID"
+// @Param cascade query bool false "Complete all subtasks when the todo gets completed"
+// @Param If-Match header string false "ETag the todo must still have"
 // @Param todo body models.TodoModel true "Todo Model"
 // @Success 200 {object} models.TodoModel
 // @Failure 400 {object} errorResponse
 // @Failure 404 {object} errorResponse
+// @Failure 409 {object} errorResponse
+// @Failure 412 {object} models.TodoModel
 // @Failure 500 {object} errorResponse
-// @Router /todos/{id} [patch]
+// @Router /todos/{id} [put]
 func (h *TodoHandler) UpdateTodo(ctx *gin.Context) {
 	id, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil {
 		newErrorResponse(ctx, http.StatusBadRequest, "invalid id param")
 		return
 	}
+	opts, err := parseUpdateOptions(ctx)
+	if err != nil {
+		h.ifMatchError(ctx, id, err)
+		return
+	}
 	var todo models.TodoModel
 	if err = ctx.BindJSON(&todo); err != nil {
 		newErrorResponse(ctx, http.StatusBadRequest, err.Error())
 		return
 	}
-	updatedTodo, err := h.service.UpdateTodo(ctx.Request.Context(), id, todo)
+	updatedTodo, err := h.service.UpdateTodo(ctx.Request.Context(), id, todo, opts)
 	if err != nil {
-		if errors.Is(err, repos.ErrTodoNotFound) {
-			newErrorResponse(ctx, http.StatusNotFound, "todo not found")
-		} else {
-			newErrorResponse(ctx, http.StatusInternalServerError, err.Error())
-		}
+		newServiceErrorResponse(ctx, err)
+		return
+	}
+	writeTodo(ctx, http.StatusOK, updatedTodo)
+}
+
+// PatchTodo godoc
+// @Summary Partially update an existing todo
+// @Description Applies a JSON merge patch (RFC 7396) to an existing todo, only the fields present are changed and null clears the description. With cascade=true completing the todo also completes its subtasks. Completing an instance of a recurring todo creates the next instance of its series
+// @Tags todos
+// @Accept json
+// @Accept application/merge-patch+json
+// @Produce json
+// @Param id path int true "Todo ID"
+// @Param cascade query bool false "Complete all subtasks when the todo gets completed"
+// @Param If-Match header string false "ETag the todo must still have"
+// @Param patch body models.TodoPatch true "Merge Patch"
+// @Success 200 {object} models.TodoModel
+// @Failure 400 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 409 {object} errorResponse
+// @Failure 412 {object} models.TodoModel
+// @Failure 500 {object} errorResponse
+// @Router /todos/{id} [patch]
+func (h *TodoHandler) PatchTodo(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		newErrorResponse(ctx, http.StatusBadRequest, "invalid id param")
 		return
 	}
-	ctx.JSON(http.StatusOK, updatedTodo)
+	opts, err := parseUpdateOptions(ctx)
+	if err != nil {
+		h.ifMatchError(ctx, id, err)
+		return
+	}
+	var patch models.TodoPatch
+	if err = ctx.BindJSON(&patch); err != nil {
+		newErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	updatedTodo, err := h.service.PatchTodo(ctx.Request.Context(), id, patch, opts)
+	if err != nil {
+		newServiceErrorResponse(ctx, err)
+		return
+	}
+	writeTodo(ctx, http.StatusOK, updatedTodo)
 }
 
 // DeleteTodo godoc
 // @Summary Delete todo by ID
-// @Description Deletes an existing todo by id
+// @Description Moves a todo and its subtasks to the trash, from where they can be restored until the trash is purged
 // @Tags todos
 // @Accept json
 // @Produce json
 // @Param id path int true "Todo ID"
+// @Param If-Match header string false "ETag the todo must still have"
 // @Success 200 {object} errorResponse
 // @Failure 400 {object} errorResponse
 // @Failure 404 {object} errorResponse
+// @Failure 412 {object} models.TodoModel
 // @Failure 500 {object} errorResponse
 // @Router /todos/{id} [delete]
 func (h *TodoHandler) DeleteTodo(ctx *gin.Context) {
@@ -153,14 +367,15 @@ func (h *TodoHandler) DeleteTodo(ctx *gin.Context) {
 		newErrorResponse(ctx, http.StatusBadRequest, "invalid id param")
 		return
 	}
-	err = h.service.DeleteTodo(ctx.Request.Context(), id)
+	ifMatch, err := parseIfMatch(ctx)
 	if err != nil {
-		if errors.Is(err, repos.ErrTodoNotFound) {
-			newErrorResponse(ctx, http.StatusNotFound, "todo not found")
-		} else {
-			newErrorResponse(ctx, http.StatusInternalServerError, err.Error())
-		}
+		h.ifMatchError(ctx, id, err)
+		return
+	}
+	err = h.service.DeleteTodo(ctx.Request.Context(), id, ifMatch)
+	if err != nil {
+		newServiceErrorResponse(ctx, err)
 		return
 	}
-	ctx.JSON(http.StatusOK, gin.H{"message": "todo deleted successfully"})
+	ctx.JSON(http.StatusOK, gin.H{"message": "todo moved to trash"})
 }
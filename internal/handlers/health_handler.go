@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/cherrycutter/todo_app/internal/services"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// HealthHandler serves the probes of the orchestrator, they need no authentication
+type HealthHandler struct {
+	service services.HealthService
+}
+
+func NewHealthHandler(service services.HealthService) *HealthHandler {
+	return &HealthHandler{service: service}
+}
+
+func (h *HealthHandler) RegisterRoutes(router gin.IRouter) {
+	router.GET("/healthz", h.GetLiveness)
+	router.GET("/readyz", h.GetReadiness)
+}
+
+// GetLiveness godoc
+// @Summary Liveness probe
+// @Description Answers as long as the process serves requests, it checks no dependency
+// @Tags health
+// @Produce json
+// @Success 200 {object} models.HealthReport
+// @Router /healthz [get]
+func (h *HealthHandler) GetLiveness(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, models.HealthReport{Status: models.HealthUp, Checks: []models.HealthCheckResult{}})
+}
+
+// GetReadiness godoc
+// @Summary Readiness probe
+// @Description Runs the checks of the database, its migrations and the background workers and lists their status and latency. The API takes traffic when every critical check is up
+// @Tags health
+// @Produce json
+// @Success 200 {object} models.HealthReport
+// @Failure 503 {object} models.HealthReport
+// @Router /readyz [get]
+func (h *HealthHandler) GetReadiness(ctx *gin.Context) {
+	report := h.service.Check(ctx.Request.Context())
+	status := http.StatusOK
+	if report.Status != models.HealthUp {
+		status = http.StatusServiceUnavailable
+	}
+	ctx.JSON(status, report)
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/cherrycutter/todo_app/internal/services"
+	"github.com/cherrycutter/todo_app/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"time"
+)
+
+// replayedHeaders are the response headers stored with an idempotent response
+var replayedHeaders = []string{"Content-Type", "ETag", "Location"}
+
+const (
+	// idempotentBodyLimit bounds the body of a request sent with an Idempotency-Key, it is buffered to be fingerprinted
+	idempotentBodyLimit = 1 << 20
+	// idempotencySaveTimeout bounds the time the outcome of a request takes to be stored once it is answered
+	idempotencySaveTimeout = 5 * time.Second
+)
+
+// Idempotency makes POST, PUT, PATCH and DELETE requests sent with an Idempotency-Key header safe to retry:
+// a retry gets the response of the first request replayed, a different request sent with the same key gets 422.
+// Responses with a 5xx status are not stored so that the request can be retried
+func Idempotency(service services.IdempotencyService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		key := ctx.GetHeader("Idempotency-Key")
+		if key == "" || !isMutating(ctx.Request.Method) {
+			ctx.Next()
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(ctx.Writer, ctx.Request.Body, idempotentBodyLimit))
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			newErrorResponse(ctx, http.StatusRequestEntityTooLarge, err.Error())
+			ctx.Abort()
+			return
+		}
+		if err != nil {
+			newErrorResponse(ctx, http.StatusBadRequest, err.Error())
+			ctx.Abort()
+			return
+		}
+		ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		reqCtx := ctx.Request.Context()
+		replay, err := service.BeginRequest(reqCtx, key, requestFingerprint(ctx.Request, body))
+		if err != nil {
+			newServiceErrorResponse(ctx, err)
+			ctx.Abort()
+			return
+		}
+		if replay != nil {
+			for name, value := range replay.Headers {
+				ctx.Header(name, value)
+			}
+			ctx.Header("Idempotent-Replayed", "true")
+			ctx.Data(replay.StatusCode, replay.Headers["Content-Type"], replay.Body)
+			ctx.Abort()
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: ctx.Writer}
+		ctx.Writer = recorder
+		completed := false
+		defer func() {
+			// a failed or panicking request releases its key
+			if !completed {
+				saveCtx, cancel := idempotencySaveContext(reqCtx)
+				defer cancel()
+				if err := service.AbandonRequest(saveCtx, key); err != nil {
+					logger.Error.Printf("releasing idempotency key failed: %s", err)
+				}
+			}
+		}()
+		ctx.Next()
+
+		if recorder.Status() >= http.StatusInternalServerError {
+			return
+		}
+		response := models.IdempotentResponse{StatusCode: recorder.Status(), Headers: map[string]string{}, Body: recorder.body.Bytes()}
+		for _, name := range replayedHeaders {
+			if value := recorder.Header().Get(name); value != "" {
+				response.Headers[name] = value
+			}
+		}
+		saveCtx, cancel := idempotencySaveContext(reqCtx)
+		defer cancel()
+		if err := service.CompleteRequest(saveCtx, key, response); err != nil {
+			logger.Error.Printf("storing idempotent response failed: %s", err)
+			return
+		}
+		completed = true
+	}
+}
+
+// idempotencySaveContext returns the context the outcome of a request is stored with. It keeps the values of the
+// request context but not its cancellation, a client that disconnects once its request ran must not leave the key
+// reserved until it expires
+func idempotencySaveContext(reqCtx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.WithoutCancel(reqCtx), idempotencySaveTimeout)
+}
+
+func isMutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// requestFingerprint identifies a request by its method, its URL and its body
+func requestFingerprint(req *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(req.Method + " " + req.URL.RequestURI() + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// responseRecorder keeps a copy of the response body written through it
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
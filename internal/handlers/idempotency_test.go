@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"context"
+	"github.com/cherrycutter/todo_app/internal/auth"
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/cherrycutter/todo_app/internal/repos"
+	"github.com/cherrycutter/todo_app/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newIdempotentRouter serves POST /todos behind the Idempotency middleware backed by the in-memory store,
+// the requests are made by user 7 and answered with status while counting the runs of the handler
+func newIdempotentRouter(service services.IdempotencyService, status int, runs *int) *gin.Engine {
+	r := gin.New()
+	r.Use(func(ctx *gin.Context) {
+		ctx.Request = ctx.Request.WithContext(auth.WithUserId(ctx.Request.Context(), 7))
+	}, Idempotency(service))
+	r.POST("/todos", func(ctx *gin.Context) {
+		*runs++
+		ctx.Header("Location", "/todos/1")
+		ctx.JSON(status, gin.H{"run": *runs})
+	})
+	return r
+}
+
+// cancelAwareIdempotencyService fails to store the outcome of a request with a canceled context, like the database
+type cancelAwareIdempotencyService struct {
+	services.IdempotencyService
+}
+
+func (s *cancelAwareIdempotencyService) CompleteRequest(ctx context.Context, key string, response models.IdempotentResponse) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.IdempotencyService.CompleteRequest(ctx, key, response)
+}
+
+func (s *cancelAwareIdempotencyService) AbandonRequest(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.IdempotencyService.AbandonRequest(ctx, key)
+}
+
+func sendIdempotent(r http.Handler, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/todos", strings.NewReader(body))
+	req.Header.Set("Idempotency-Key", key)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotency(t *testing.T) {
+	t.Run("Replayed", func(t *testing.T) {
+		var runs int
+		r := newIdempotentRouter(services.NewIdempotencyService(repos.NewIdempotencyMemoryRepo(), time.Hour), http.StatusCreated, &runs)
+
+		first := sendIdempotent(r, "key", `{"title":"a"}`)
+		second := sendIdempotent(r, "key", `{"title":"a"}`)
+		assert.Equal(t, 1, runs)
+		assert.Equal(t, http.StatusCreated, second.Code)
+		assert.Equal(t, first.Body.String(), second.Body.String())
+		assert.Equal(t, "/todos/1", second.Header().Get("Location"))
+		assert.Equal(t, "true", second.Header().Get("Idempotent-Replayed"))
+		assert.Empty(t, first.Header().Get("Idempotent-Replayed"))
+	})
+
+	t.Run("Reused For Another Request", func(t *testing.T) {
+		var runs int
+		r := newIdempotentRouter(services.NewIdempotencyService(repos.NewIdempotencyMemoryRepo(), time.Hour), http.StatusCreated, &runs)
+
+		sendIdempotent(r, "key", `{"title":"a"}`)
+		w := sendIdempotent(r, "key", `{"title":"b"}`)
+		assert.Equal(t, 1, runs)
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	})
+
+	t.Run("In Progress", func(t *testing.T) {
+		var runs int
+		service := services.NewIdempotencyService(repos.NewIdempotencyMemoryRepo(), time.Hour)
+		r := newIdempotentRouter(service, http.StatusCreated, &runs)
+
+		// the first request reserved the key but has not answered yet
+		body := `{"title":"a"}`
+		req := httptest.NewRequest(http.MethodPost, "/todos", strings.NewReader(body))
+		replay, err := service.BeginRequest(auth.WithUserId(req.Context(), 7), "key", requestFingerprint(req, []byte(body)))
+		assert.NoError(t, err)
+		assert.Nil(t, replay)
+
+		w := sendIdempotent(r, "key", body)
+		assert.Equal(t, 0, runs)
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Equal(t, http.StatusUnprocessableEntity, sendIdempotent(r, "key", `{"title":"b"}`).Code)
+	})
+
+	t.Run("Server Error Not Stored", func(t *testing.T) {
+		var runs int
+		r := newIdempotentRouter(services.NewIdempotencyService(repos.NewIdempotencyMemoryRepo(), time.Hour), http.StatusInternalServerError, &runs)
+
+		sendIdempotent(r, "key", `{"title":"a"}`)
+		w := sendIdempotent(r, "key", `{"title":"a"}`)
+		assert.Equal(t, 2, runs)
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Empty(t, w.Header().Get("Idempotent-Replayed"))
+	})
+
+	t.Run("Client Gone", func(t *testing.T) {
+		var runs int
+		service := &cancelAwareIdempotencyService{IdempotencyService: services.NewIdempotencyService(repos.NewIdempotencyMemoryRepo(), time.Hour)}
+		r := newIdempotentRouter(service, http.StatusCreated, &runs)
+
+		// the client disconnected by the time the response is stored, it is stored all the same
+		reqCtx, cancel := context.WithCancel(context.Background())
+		cancel()
+		req := httptest.NewRequest(http.MethodPost, "/todos", strings.NewReader(`{"title":"a"}`)).WithContext(reqCtx)
+		req.Header.Set("Idempotency-Key", "key")
+		r.ServeHTTP(httptest.NewRecorder(), req)
+
+		w := sendIdempotent(r, "key", `{"title":"a"}`)
+		assert.Equal(t, 1, runs)
+		assert.Equal(t, "true", w.Header().Get("Idempotent-Replayed"))
+	})
+
+	t.Run("Body Too Large", func(t *testing.T) {
+		var runs int
+		r := newIdempotentRouter(services.NewIdempotencyService(repos.NewIdempotencyMemoryRepo(), time.Hour), http.StatusCreated, &runs)
+
+		w := sendIdempotent(r, "key", `{"title":"`+strings.Repeat("a", idempotentBodyLimit)+`"}`)
+		assert.Equal(t, 0, runs)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	})
+
+	t.Run("Without Key", func(t *testing.T) {
+		var runs int
+		r := newIdempotentRouter(services.NewIdempotencyService(repos.NewIdempotencyMemoryRepo(), time.Hour), http.StatusCreated, &runs)
+
+		sendIdempotent(r, "", `{"title":"a"}`)
+		sendIdempotent(r, "", `{"title":"a"}`)
+		assert.Equal(t, 2, runs)
+	})
+}
@@ -0,0 +1,17 @@
+package handlers
+
+import (
+	"github.com/cherrycutter/todo_app/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"io"
+	"log"
+	"os"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	logger.Info = log.New(io.Discard, "", 0)
+	logger.Error = log.New(io.Discard, "", 0)
+	os.Exit(m.Run())
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/cherrycutter/todo_app/internal/auth"
+	"github.com/cherrycutter/todo_app/internal/services"
+	"github.com/cherrycutter/todo_app/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// codeInsufficientScope is the error code of the 403 responses to API keys lacking a scope
+const codeInsufficientScope = "insufficient_scope"
+
+// BearerAuth authenticates requests with the access token or API key of the Authorization header
+// and puts the id of the user into the request context, API keys also restrict the request to their scopes
+func BearerAuth(service services.AuthService, apiKeyService services.APIKeyService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		scheme, token, _ := strings.Cut(ctx.GetHeader("Authorization"), " ")
+		if !strings.EqualFold(scheme, "Bearer") || token == "" {
+			newAuthErrorResponse(ctx, codeMissingToken, "missing bearer token")
+			return
+		}
+
+		reqCtx := ctx.Request.Context()
+		if strings.HasPrefix(token, auth.APIKeyPrefix) {
+			userId, scopes, err := apiKeyService.Authenticate(reqCtx, token)
+			if err != nil {
+				if errors.Is(err, services.ErrInvalidAPIKey) {
+					newAuthErrorResponse(ctx, codeInvalidToken, err.Error())
+				} else {
+					newErrorResponse(ctx, http.StatusInternalServerError, err.Error())
+					ctx.Abort()
+				}
+				return
+			}
+			reqCtx = auth.WithScopes(auth.WithUserId(reqCtx, userId), scopes)
+		} else {
+			userId, err := service.Authenticate(reqCtx, token)
+			if err != nil {
+				if errors.Is(err, auth.ErrTokenExpired) {
+					newAuthErrorResponse(ctx, codeTokenExpired, err.Error())
+				} else {
+					newAuthErrorResponse(ctx, codeInvalidToken, err.Error())
+				}
+				return
+			}
+			reqCtx = auth.WithUserId(reqCtx, userId)
+		}
+		ctx.Request = ctx.Request.WithContext(reqCtx)
+		ctx.Next()
+	}
+}
+
+// RequestMetrics reports every request with the template of its route, such as /todo/:id. Requests that match
+// no route are reported as unmatched, so that unknown paths do not add labels
+func RequestMetrics(observe func(method, route string, status int, duration time.Duration)) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		ctx.Next()
+		route := ctx.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		observe(ctx.Request.Method, route, ctx.Writer.Status(), time.Since(start))
+	}
+}
+
+// Tracing makes a server span of every request, continuing the trace of its traceparent header if any. The
+// span is passed to the handlers through the request context and its traceparent is set on the response
+func Tracing(tracer trace.Tracer, propagator propagation.TextMapPropagator) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		reqCtx := propagator.Extract(ctx.Request.Context(), propagation.HeaderCarrier(ctx.Request.Header))
+		name := ctx.Request.Method
+		attrs := []attribute.KeyValue{semconv.HTTPRequestMethodKey.String(ctx.Request.Method), semconv.URLPath(ctx.Request.URL.Path)}
+		if route := ctx.FullPath(); route != "" {
+			name += " " + route
+			attrs = append(attrs, semconv.HTTPRoute(route))
+		}
+		reqCtx, span := tracer.Start(reqCtx, name, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(attrs...))
+		defer span.End()
+		propagator.Inject(reqCtx, propagation.HeaderCarrier(ctx.Writer.Header()))
+		ctx.Request = ctx.Request.WithContext(reqCtx)
+
+		ctx.Next()
+		status := ctx.Writer.Status()
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		// client errors are the client's, only server errors fail the span
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	}
+}
+
+// RequireScope rejects requests made with an API key that lacks one of the scopes
+func RequireScope(scopes ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		for _, scope := range scopes {
+			if !auth.HasScope(ctx.Request.Context(), scope) {
+				newScopeErrorResponse(ctx, "api key lacks the "+scope+" scope")
+				return
+			}
+		}
+		ctx.Next()
+	}
+}
+
+// RequireUserToken rejects requests made with an API key, so that keys cannot manage keys
+func RequireUserToken() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if _, restricted := auth.ScopesFromContext(ctx.Request.Context()); restricted {
+			newScopeErrorResponse(ctx, "api keys cannot be managed with an api key")
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// newScopeErrorResponse sends a 403 response for a request that is authenticated but not allowed
+func newScopeErrorResponse(ctx *gin.Context, message string) {
+	logger.Error.Println(message)
+	ctx.AbortWithStatusJSON(http.StatusForbidden, errorResponse{Message: message, Code: codeInsufficientScope})
+}
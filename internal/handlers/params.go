@@ -0,0 +1,264 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/gin-gonic/gin"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// todoListQueryParams are the query params understood by the todo list endpoint
+var todoListQueryParams = map[string]bool{
+	"limit":          true,
+	"cursor":         true,
+	"with_total":     true,
+	"completed":      true,
+	"created_after":  true,
+	"created_before": true,
+	"title_contains": true,
+	"tag":            true,
+	"tag_match":      true,
+	"project_id":     true,
+	"series_id":      true,
+	"sort":           true,
+}
+
+// todoOverdueQueryParams are the query params understood by the overdue todos endpoint, overdue todos are
+// open by definition so completed is not one of them
+var todoOverdueQueryParams = map[string]bool{
+	"limit":      true,
+	"cursor":     true,
+	"with_total": true,
+	"sort":       true,
+}
+
+// todoDueQueryParams are the query params understood by the due todos endpoint
+var todoDueQueryParams = map[string]bool{
+	"limit":      true,
+	"cursor":     true,
+	"with_total": true,
+	"completed":  true,
+	"sort":       true,
+	"from":       true,
+	"to":         true,
+}
+
+// todoSearchQueryParams are the query params understood by the todo search endpoint
+var todoSearchQueryParams = map[string]bool{
+	"q":         true,
+	"limit":     true,
+	"completed": true,
+}
+
+// todoTrashQueryParams are the query params understood by the trash endpoint
+var todoTrashQueryParams = map[string]bool{
+	"limit":          true,
+	"cursor":         true,
+	"with_total":     true,
+	"completed":      true,
+	"title_contains": true,
+	"sort":           true,
+}
+
+// todoOccurrencesQueryParams are the query params understood by the occurrence preview endpoint
+var todoOccurrencesQueryParams = map[string]bool{
+	"limit": true,
+}
+
+// todoHistoryQueryParams are the query params understood by the todo history endpoint
+var todoHistoryQueryParams = map[string]bool{
+	"limit":  true,
+	"cursor": true,
+}
+
+// auditQueryParams are the query params understood by the audit log endpoint
+var auditQueryParams = map[string]bool{
+	"limit":     true,
+	"cursor":    true,
+	"actor_id":  true,
+	"operation": true,
+	"from":      true,
+	"to":        true,
+}
+
+// webhookDeliveriesQueryParams are the query params understood by the webhook deliveries endpoint
+var webhookDeliveriesQueryParams = map[string]bool{
+	"limit": true,
+}
+
+// checkQueryParams rejects query params that are not in the allowed set
+func checkQueryParams(ctx *gin.Context, allowed map[string]bool) error {
+	for key := range ctx.Request.URL.Query() {
+		if !allowed[key] {
+			return fmt.Errorf("unknown query param %q", key)
+		}
+	}
+	return nil
+}
+
+// parseListParams reads the limit, cursor and with_total query params of a list request
+func parseListParams(ctx *gin.Context) (models.ListParams, error) {
+	var params models.ListParams
+	if limit := ctx.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > models.MaxPageLimit {
+			return params, fmt.Errorf("invalid limit param: must be between 1 and %d", models.MaxPageLimit)
+		}
+		params.Limit = n
+	}
+	params.Cursor = ctx.Query("cursor")
+	if withTotal := ctx.Query("with_total"); withTotal != "" {
+		b, err := strconv.ParseBool(withTotal)
+		if err != nil {
+			return params, errors.New("invalid with_total param")
+		}
+		params.WithTotal = b
+	}
+	return params, nil
+}
+
+// parseTodoFilter reads the filtering and sorting query params of the todo list endpoint
+func parseTodoFilter(ctx *gin.Context) (models.TodoFilter, error) {
+	var filter models.TodoFilter
+	if completed := ctx.Query("completed"); completed != "" {
+		b, err := strconv.ParseBool(completed)
+		if err != nil {
+			return filter, errors.New("invalid completed param")
+		}
+		filter.Completed = &b
+	}
+	var err error
+	if filter.CreatedAfter, err = parseTimeParam(ctx, "created_after"); err != nil {
+		return filter, err
+	}
+	if filter.CreatedBefore, err = parseTimeParam(ctx, "created_before"); err != nil {
+		return filter, err
+	}
+	filter.TitleContains = ctx.Query("title_contains")
+	if projectId := ctx.Query("project_id"); projectId != "" {
+		id, err := strconv.Atoi(projectId)
+		if err != nil {
+			return filter, errors.New("invalid project_id param")
+		}
+		filter.ProjectId = &id
+	}
+	if seriesId := ctx.Query("series_id"); seriesId != "" {
+		id, err := strconv.Atoi(seriesId)
+		if err != nil {
+			return filter, errors.New("invalid series_id param")
+		}
+		filter.SeriesId = &id
+	}
+	filter.Tags = ctx.QueryArray("tag")
+	switch ctx.Query("tag_match") {
+	case "", "any":
+	case "all":
+		filter.TagMatchAll = true
+	default:
+		return filter, errors.New("invalid tag_match param: must be any or all")
+	}
+	if sort := ctx.Query("sort"); sort != "" {
+		if filter.Sort, err = parseSort(sort); err != nil {
+			return filter, err
+		}
+	}
+	return filter, nil
+}
+
+// parseTimeParam reads an optional RFC 3339 timestamp query param
+func parseTimeParam(ctx *gin.Context, name string) (*time.Time, error) {
+	value := ctx.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s param: must be an RFC 3339 timestamp", name)
+	}
+	t = t.UTC()
+	return &t, nil
+}
+
+// parseAuditFilter reads the filtering query params of the audit log endpoint
+func parseAuditFilter(ctx *gin.Context) (models.AuditFilter, error) {
+	var filter models.AuditFilter
+	if actorId := ctx.Query("actor_id"); actorId != "" {
+		id, err := strconv.Atoi(actorId)
+		if err != nil {
+			return filter, errors.New("invalid actor_id param")
+		}
+		filter.ActorId = &id
+	}
+	if operation := ctx.Query("operation"); operation != "" {
+		op := models.AuditOperation(operation)
+		switch op {
+		case models.AuditCreate, models.AuditUpdate, models.AuditDelete, models.AuditRestore, models.AuditPurge:
+		default:
+			return filter, errors.New("invalid operation param: must be create, update, delete, restore or purge")
+		}
+		filter.Operation = &op
+	}
+	var err error
+	if filter.From, err = parseTimeParam(ctx, "from"); err != nil {
+		return filter, err
+	}
+	if filter.To, err = parseTimeParam(ctx, "to"); err != nil {
+		return filter, err
+	}
+	return filter, nil
+}
+
+// parseSort parses a sort param of the form field:direction,field:direction where the direction is optional
+func parseSort(value string) ([]models.SortField, error) {
+	var fields []models.SortField
+	for _, part := range strings.Split(value, ",") {
+		name, dir, _ := strings.Cut(strings.TrimSpace(part), ":")
+		if name == "" {
+			return nil, errors.New("invalid sort param: empty field")
+		}
+		field := models.SortField{Field: name}
+		switch strings.ToLower(dir) {
+		case "", "asc":
+		case "desc":
+			field.Desc = true
+		default:
+			return nil, fmt.Errorf("invalid sort param: unknown direction %q", dir)
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// parseUpdateOptions reads the cascade query param and the If-Match header of the todo update endpoints
+func parseUpdateOptions(ctx *gin.Context) (models.TodoUpdateOptions, error) {
+	var opts models.TodoUpdateOptions
+	if cascade := ctx.Query("cascade"); cascade != "" {
+		b, err := strconv.ParseBool(cascade)
+		if err != nil {
+			return opts, errors.New("invalid cascade param")
+		}
+		opts.CascadeCompletion = b
+	}
+	ifMatch, err := parseIfMatch(ctx)
+	if err != nil {
+		return opts, err
+	}
+	opts.IfMatch = ifMatch
+	return opts, nil
+}
+
+// parseTodoTagParams reads the todo id and tag id path params
+func parseTodoTagParams(ctx *gin.Context) (int, int, error) {
+	todoId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return 0, 0, errors.New("invalid id param")
+	}
+	tagId, err := strconv.Atoi(ctx.Param("tag_id"))
+	if err != nil {
+		return 0, 0, errors.New("invalid tag_id param")
+	}
+	return todoId, tagId, nil
+}
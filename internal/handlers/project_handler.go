@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/cherrycutter/todo_app/internal/auth"
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/cherrycutter/todo_app/internal/services"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+type ProjectHandler struct {
+	service     services.ProjectService
+	todoService services.TodoService
+}
+
+func NewProjectHandler(service services.ProjectService, todoService services.TodoService) *ProjectHandler {
+	return &ProjectHandler{service: service, todoService: todoService}
+}
+
+func (h *ProjectHandler) RegisterRoutes(router gin.IRouter) {
+	read := RequireScope(auth.ScopeProjectsRead)
+	admin := RequireScope(auth.ScopeProjectsAdmin)
+
+	router.GET("/projects", read, h.GetProjects)
+	router.GET("/projects/:id", read, h.GetProject)
+	router.POST("/projects", admin, h.PostProject)
+	router.PUT("/projects/:id", admin, h.UpdateProject)
+	router.DELETE("/projects/:id", admin, h.DeleteProject)
+	router.GET("/projects/:id/todos", RequireScope(auth.ScopeProjectsRead, auth.ScopeTodosRead), h.GetProjectTodos)
+	router.POST("/projects/:id/todos", RequireScope(auth.ScopeProjectsRead, auth.ScopeTodosWrite), h.PostProjectTodo)
+}
+
+// GetProjects godoc
+// @Summary Get all projects
+// @Description Returns a list of all projects, the inbox first
+// @Tags projects
+// @Accept  json
+// @Produce  json
+// @Param archived query bool false "Include archived projects"
+// @Success 200 {array} models.ProjectModel
+// @Failure 400 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /projects [get]
+func (h *ProjectHandler) GetProjects(ctx *gin.Context) {
+	includeArchived := false
+	if archived := ctx.Query("archived"); archived != "" {
+		b, err := strconv.ParseBool(archived)
+		if err != nil {
+			newErrorResponse(ctx, http.StatusBadRequest, "invalid archived param")
+			return
+		}
+		includeArchived = b
+	}
+	projects, err := h.service.GetProjects(ctx.Request.Context(), includeArchived)
+	if err != nil {
+		newErrorResponse(ctx, http.StatusInternalServerError, "failed to get projects")
+		return
+	}
+	ctx.JSON(http.StatusOK, projects)
+}
+
+// GetProject godoc
+// @Summary Get project by ID
+// @Description Returns one project by id
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Param id path int true "Project ID"
+// @Success 200 {object} models.ProjectModel
+// @Failure 400 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /projects/{id} [get]
+func (h *ProjectHandler) GetProject(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		newErrorResponse(ctx, http.StatusBadRequest, "invalid id param")
+		return
+	}
+	project, err := h.service.GetProject(ctx.Request.Context(), id)
+	if err != nil {
+		newServiceErrorResponse(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, project)
+}
+
+// PostProject godoc
+// @Summary Create a new project
+// @Description Creates one new project
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Param project body models.ProjectModel true "Project Model"
+// @Success 201 {object} models.ProjectModel
+// @Failure 400 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /projects [post]
+func (h *ProjectHandler) PostProject(ctx *gin.Context) {
+	var project models.ProjectModel
+	if err := ctx.BindJSON(&project); err != nil {
+		newErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	createdProject, err := h.service.CreateProject(ctx.Request.Context(), project)
+	if err != nil {
+		newServiceErrorResponse(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, createdProject)
+}
+
+// UpdateProject godoc
+// @Summary Replace an existing project
+// @Description Replaces name, description, color and archived flag of an existing project by id
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Param id path int true "Project ID"
+// @Param project body models.ProjectModel true "Project Model"
+// @Success 200 {object} models.ProjectModel
+// @Failure 400 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 409 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /projects/{id} [put]
+func (h *ProjectHandler) UpdateProject(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		newErrorResponse(ctx, http.StatusBadRequest, "invalid id param")
+		return
+	}
+	var project models.ProjectModel
+	if err = ctx.BindJSON(&project); err != nil {
+		newErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	updatedProject, err := h.service.UpdateProject(ctx.Request.Context(), id, project)
+	if err != nil {
+		newServiceErrorResponse(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, updatedProject)
+}
+
+// DeleteProject godoc
+// @Summary Delete project by ID
+// @Description Deletes an existing project by id. Its todos are moved to the inbox project, or moved to the trash with their subtasks with mode=cascade
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Param id path int true "Project ID"
+// @Param mode query string false "What happens to the todos of the project" Enums(move, cascade) default(move)
+// @Success 200 {object} errorResponse
+// @Failure 400 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 409 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /projects/{id} [delete]
+func (h *ProjectHandler) DeleteProject(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		newErrorResponse(ctx, http.StatusBadRequest, "invalid id param")
+		return
+	}
+	mode := models.ProjectDeleteMode(ctx.DefaultQuery("mode", string(models.ProjectDeleteMoveToInbox)))
+	if err = h.service.DeleteProject(ctx.Request.Context(), id, mode); err != nil {
+		newServiceErrorResponse(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "project deleted successfully"})
+}
+
+// GetProjectTodos godoc
+// @Summary Get the todos of a project
+// @Description Returns a filtered page of the todos of a project, accepts the same query params as GET /todos
+// @Tags projects
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Project ID"
+// @Param limit query int false "Page size (1-100)" default(20)
+// @Param cursor query string false "Cursor returned as next_cursor by the previous page"
+// @Param with_total query bool false "Include the total number of todos"
+// @Param completed query bool false "Only completed or only open todos"
+// @Param sort query string false "Comma separated field:direction list" example(created_at:desc)
+// @Success 200 {object} models.TodoPage
+// @Failure 400 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /projects/{id}/todos [get]
+func (h *ProjectHandler) GetProjectTodos(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		newErrorResponse(ctx, http.StatusBadRequest, "invalid id param")
+		return
+	}
+	if _, err = h.service.GetProject(ctx.Request.Context(), id); err != nil {
+		newServiceErrorResponse(ctx, err)
+		return
+	}
+	listTodos(ctx, h.todoService, todoListQueryParams, func(filter *models.TodoFilter) error {
+		if filter.ProjectId != nil && *filter.ProjectId != id {
+			return errors.New("invalid project_id param: does not match the project")
+		}
+		filter.ProjectId = &id
+		return nil
+	})
+}
+
+// PostProjectTodo godoc
+// @Summary Create a new todo in a project
+// @Description Creates one new todo in the project, the project_id of the body is ignored
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Param id path int true "Project ID"
+// @Param todo body models.TodoModel true "Todo Model"
+// @Success 201 {object} models.TodoModel
+// @Failure 400 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /projects/{id}/todos [post]
+func (h *ProjectHandler) PostProjectTodo(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		newErrorResponse(ctx, http.StatusBadRequest, "invalid id param")
+		return
+	}
+	var todo models.TodoModel
+	if err = ctx.BindJSON(&todo); err != nil {
+		newErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	todo.ProjectId = &id
+	createdTodo, err := h.todoService.CreateTodo(ctx.Request.Context(), todo)
+	if err != nil {
+		newServiceErrorResponse(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, createdTodo)
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+// GetOccurrences godoc
+// @Summary Preview the next occurrences of a recurring todo
+// @Description Returns the due dates and reminders of the instances following the todo in its series, a todo that does not recur has none
+// @Tags todos
+// @Accept json
+// @Produce json
+// @Param id path int true "Todo ID"
+// @Param limit query int false "Number of occurrences (1-100)" default(5)
+// @Success 200 {object} models.TodoOccurrences
+// @Failure 400 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /todo/{id}/occurrences [get]
+func (h *TodoHandler) GetOccurrences(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		newErrorResponse(ctx, http.StatusBadRequest, "invalid id param")
+		return
+	}
+	if err = checkQueryParams(ctx, todoOccurrencesQueryParams); err != nil {
+		newErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	params, err := parseListParams(ctx)
+	if err != nil {
+		newErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	occurrences, err := h.service.GetOccurrences(ctx.Request.Context(), id, params.Limit)
+	if err != nil {
+		newServiceErrorResponse(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, occurrences)
+}
@@ -1,16 +1,99 @@
 package handlers
 
 import (
+	"errors"
+	"github.com/cherrycutter/todo_app/internal/repos"
+	"github.com/cherrycutter/todo_app/internal/services"
 	"github.com/cherrycutter/todo_app/pkg/logger"
 	"github.com/gin-gonic/gin"
+	"net/http"
 )
 
 type errorResponse struct {
 	Message string `json:"message"`
+	Code    string `json:"code,omitempty"`
 }
 
+// Error codes of the 401 responses, clients refresh their access token on token_expired
+const (
+	codeMissingToken = "missing_token"
+	codeInvalidToken = "invalid_token"
+	codeTokenExpired = "token_expired"
+)
+
 // newErrorResponse writes a message to logger and sends a JSON response containing the error message with the specified status code
 func newErrorResponse(ctx *gin.Context, statusCode int, message string) {
 	logger.Error.Println(message)
 	ctx.JSON(statusCode, errorResponse{Message: message})
 }
+
+// newAuthErrorResponse sends a 401 response with a machine-readable error code
+func newAuthErrorResponse(ctx *gin.Context, code, message string) {
+	logger.Error.Println(message)
+	ctx.Header("WWW-Authenticate", `Bearer error="`+code+`"`)
+	ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse{Message: message, Code: code})
+}
+
+// newServiceErrorResponse sends the error response matching an error returned by a service
+func newServiceErrorResponse(ctx *gin.Context, err error) {
+	var preconditionErr *services.PreconditionFailedError
+	switch {
+	case errors.As(err, &preconditionErr):
+		// the client gets the current todo to merge its changes into
+		logger.Error.Println(err.Error())
+		writeTodo(ctx, http.StatusPreconditionFailed, preconditionErr.Current)
+	case errors.Is(err, services.ErrUnauthenticated):
+		newAuthErrorResponse(ctx, codeMissingToken, err.Error())
+	case errors.Is(err, services.ErrInvalidRefreshToken):
+		newAuthErrorResponse(ctx, codeInvalidToken, err.Error())
+	default:
+		statusCode, message := serviceErrorStatus(err)
+		newErrorResponse(ctx, statusCode, message)
+	}
+}
+
+// serviceErrorStatus returns the status code and the message matching an error returned by a service
+func serviceErrorStatus(err error) (int, string) {
+	var validationErr *services.ValidationError
+	var filterErr *repos.FilterError
+	switch {
+	case errors.Is(err, repos.ErrTodoNotFound):
+		return http.StatusNotFound, "todo not found"
+	case errors.Is(err, repos.ErrParentNotFound):
+		return http.StatusNotFound, "parent todo not found"
+	case errors.Is(err, repos.ErrTodoCycle), errors.Is(err, repos.ErrTodoDepthExceeded), errors.Is(err, repos.ErrParentTrashed):
+		return http.StatusConflict, err.Error()
+	case errors.Is(err, repos.ErrVersionMismatch):
+		return http.StatusPreconditionFailed, err.Error()
+	case errors.Is(err, repos.ErrSubtaskOrderFailed):
+		return http.StatusBadRequest, err.Error()
+	case errors.Is(err, repos.ErrTagNotFound):
+		return http.StatusNotFound, "tag not found"
+	case errors.Is(err, repos.ErrTagExists):
+		return http.StatusConflict, err.Error()
+	case errors.Is(err, repos.ErrProjectNotFound):
+		return http.StatusNotFound, "project not found"
+	case errors.Is(err, services.ErrInboxProject):
+		return http.StatusConflict, err.Error()
+	case errors.Is(err, repos.ErrAPIKeyNotFound):
+		return http.StatusNotFound, "api key not found"
+	case errors.Is(err, repos.ErrWebhookNotFound):
+		return http.StatusNotFound, "webhook not found"
+	case errors.Is(err, repos.ErrEmailTaken):
+		return http.StatusConflict, err.Error()
+	case errors.Is(err, services.ErrInvalidCursor):
+		return http.StatusBadRequest, "invalid cursor param"
+	case errors.Is(err, services.ErrIdempotencyKeyReused):
+		return http.StatusUnprocessableEntity, err.Error()
+	case errors.Is(err, services.ErrIdempotencyKeyInProgress):
+		return http.StatusConflict, err.Error()
+	case errors.Is(err, services.ErrUnauthenticated), errors.Is(err, services.ErrInvalidCredentials), errors.Is(err, services.ErrInvalidRefreshToken):
+		return http.StatusUnauthorized, err.Error()
+	case errors.As(err, &validationErr):
+		return http.StatusBadRequest, validationErr.Message
+	case errors.As(err, &filterErr):
+		return http.StatusBadRequest, filterErr.Error()
+	default:
+		return http.StatusInternalServerError, err.Error()
+	}
+}
@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// StatsHandler serves the state of the instance for monitoring, it needs no authentication and is only registered on
+// the admin address
+type StatsHandler struct {
+	poolStats func() models.PoolStats
+}
+
+func NewStatsHandler(poolStats func() models.PoolStats) *StatsHandler {
+	return &StatsHandler{poolStats: poolStats}
+}
+
+func (h *StatsHandler) RegisterRoutes(router gin.IRouter) {
+	router.GET("/stats/db", h.GetDBStats)
+}
+
+// GetDBStats godoc
+// @Summary Get database pool stats
+// @Description Returns the connections of the database pool of this instance and the counts of their use since it started
+// @Tags stats
+// @Produce json
+// @Success 200 {object} models.PoolStats
+// @Router /stats/db [get]
+func (h *StatsHandler) GetDBStats(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, h.poolStats())
+}
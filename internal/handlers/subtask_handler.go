@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+// GetSubtasks godoc
+// @Summary Get the subtasks of a todo
+// @Description Returns the direct subtasks of a todo in their order
+// @Tags subtasks
+// @Accept json
+// @Produce json
+// @Param id path int true "Todo ID"
+// @Success 200 {array} models.TodoModel
+// @Failure 400 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /todo/{id}/subtasks [get]
+func (h *TodoHandler) GetSubtasks(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		newErrorResponse(ctx, http.StatusBadRequest, "invalid id param")
+		return
+	}
+	subtasks, err := h.service.GetSubtasks(ctx.Request.Context(), id)
+	if err != nil {
+		newServiceErrorResponse(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, subtasks)
+}
+
+// PostSubtask godoc
+// @Summary Add a subtask to a todo
+// @Description Creates a todo nested under the todo, after its last subtask. The parent_id of the body is ignored
+// @Tags subtasks
+// @Accept json
+// @Produce json
+// @Param id path int true "Todo ID"
+// @Param todo body models.TodoModel true "Todo Model"
+// @Success 201 {object} models.TodoModel
+// @Failure 400 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 409 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /todo/{id}/subtasks [post]
+func (h *TodoHandler) PostSubtask(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		newErrorResponse(ctx, http.StatusBadRequest, "invalid id param")
+		return
+	}
+	var todo models.TodoModel
+	if err = ctx.BindJSON(&todo); err != nil {
+		newErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	createdTodo, err := h.service.CreateSubtask(ctx.Request.Context(), id, todo)
+	if err != nil {
+		newServiceErrorResponse(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, createdTodo)
+}
+
+// ReorderSubtasks godoc
+// @Summary Reorder the subtasks of a todo
+// @Description Puts the subtasks of a todo in the given order, the ids must list every subtask exactly once
+// @Tags subtasks
+// @Accept json
+// @Produce json
+// @Param id path int true "Todo ID"
+// @Param order body models.SubtaskOrder true "Subtask ids in their new order"
+// @Success 200 {array} models.TodoModel
+// @Failure 400 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /todo/{id}/subtasks/order [put]
+func (h *TodoHandler) ReorderSubtasks(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		newErrorResponse(ctx, http.StatusBadRequest, "invalid id param")
+		return
+	}
+	var order models.SubtaskOrder
+	if err = ctx.BindJSON(&order); err != nil {
+		newErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	subtasks, err := h.service.ReorderSubtasks(ctx.Request.Context(), id, order.Ids)
+	if err != nil {
+		newServiceErrorResponse(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, subtasks)
+}
+
+// ToggleSubtask godoc
+// @Summary Toggle a subtask
+// @Description Flips the completed flag of a direct subtask of the todo
+// @Tags subtasks
+// @Accept json
+// @Produce json
+// @Param id path int true "Todo ID"
+// @Param subtask_id path int true "Subtask ID"
+// @Success 200 {object} models.TodoModel
+// @Failure 400 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /todo/{id}/subtasks/{subtask_id}/toggle [post]
+func (h *TodoHandler) ToggleSubtask(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		newErrorResponse(ctx, http.StatusBadRequest, "invalid id param")
+		return
+	}
+	subtaskId, err := strconv.Atoi(ctx.Param("subtask_id"))
+	if err != nil {
+		newErrorResponse(ctx, http.StatusBadRequest, "invalid subtask_id param")
+		return
+	}
+	subtask, err := h.service.ToggleSubtask(ctx.Request.Context(), id, subtaskId)
+	if err != nil {
+		newServiceErrorResponse(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, subtask)
+}
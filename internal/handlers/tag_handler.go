@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"github.com/cherrycutter/todo_app/internal/auth"
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/cherrycutter/todo_app/internal/services"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+type TagHandler struct {
+	service services.TagService
+}
+
+func NewTagHandler(service services.TagService) *TagHandler {
+	return &TagHandler{service: service}
+}
+
+func (h *TagHandler) RegisterRoutes(router gin.IRouter) {
+	read := RequireScope(auth.ScopeTagsRead)
+	write := RequireScope(auth.ScopeTagsWrite)
+	tagTodo := RequireScope(auth.ScopeTodosWrite)
+
+	router.GET("/tags", read, h.GetTags)
+	router.GET("/tags/:id", read, h.GetTag)
+	router.POST("/tags", write, h.PostTag)
+	router.PUT("/tags/:id", write, h.UpdateTag)
+	router.DELETE("/tags/:id", write, h.DeleteTag)
+	router.POST("/todo/:id/tags/:tag_id", tagTodo, h.AttachTag)
+	router.DELETE("/todo/:id/tags/:tag_id", tagTodo, h.DetachTag)
+}
+
+// GetTags godoc
+// @Summary Get all tags
+// @Description Returns a list of all tags ordered by name
+// @Tags tags
+// @Accept  json
+// @Produce  json
+// @Success 200 {array} models.TagModel
+// @Failure 500 {object} errorResponse
+// @Router /tags [get]
+func (h *TagHandler) GetTags(ctx *gin.Context) {
+	tags, err := h.service.GetTags(ctx.Request.Context())
+	if err != nil {
+		newErrorResponse(ctx, http.StatusInternalServerError, "failed to get tags")
+		return
+	}
+	ctx.JSON(http.StatusOK, tags)
+}
+
+// GetTag godoc
+// @Summary Get tag by ID
+// @Description Returns one tag by id
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Param id path int true "Tag ID"
+// @Success 200 {object} models.TagModel
+// @Failure 400 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /tags/{id} [get]
+func (h *TagHandler) GetTag(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		newErrorResponse(ctx, http.StatusBadRequest, "invalid id param")
+		return
+	}
+	tag, err := h.service.GetTag(ctx.Request.Context(), id)
+	if err != nil {
+		newServiceErrorResponse(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, tag)
+}
+
+// PostTag godoc
+// @Summary Create a new tag
+// @Description Creates one new tag, names are unique
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Param tag body models.TagModel true "Tag Model"
+// @Success 201 {object} models.TagModel
+// @Failure 400 {object} errorResponse
+// @Failure 409 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /tags [post]
+func (h *TagHandler) PostTag(ctx *gin.Context) {
+	var tag models.TagModel
+	if err := ctx.BindJSON(&tag); err != nil {
+		newErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	createdTag, err := h.service.CreateTag(ctx.Request.Context(), tag)
+	if err != nil {
+		newServiceErrorResponse(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, createdTag)
+}
+
+// UpdateTag godoc
+// @Summary Replace an existing tag
+// @Description Replaces name, color and description of an existing tag by id
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Param id path int true "Tag ID"
+// @Param tag body models.TagModel true "Tag Model"
+// @Success 200 {object} models.TagModel
+// @Failure 400 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 409 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /tags/{id} [put]
+func (h *TagHandler) UpdateTag(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		newErrorResponse(ctx, http.StatusBadRequest, "invalid id param")
+		return
+	}
+	var tag models.TagModel
+	if err = ctx.BindJSON(&tag); err != nil {
+		newErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	updatedTag, err := h.service.UpdateTag(ctx.Request.Context(), id, tag)
+	if err != nil {
+		newServiceErrorResponse(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, updatedTag)
+}
+
+// DeleteTag godoc
+// @Summary Delete tag by ID
+// @Description Deletes an existing tag by id and detaches it from all todos
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Param id path int true "Tag ID"
+// @Success 200 {object} errorResponse
+// @Failure 400 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /tags/{id} [delete]
+func (h *TagHandler) DeleteTag(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		newErrorResponse(ctx, http.StatusBadRequest, "invalid id param")
+		return
+	}
+	if err = h.service.DeleteTag(ctx.Request.Context(), id); err != nil {
+		newServiceErrorResponse(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "tag deleted successfully"})
+}
+
+// AttachTag godoc
+// @Summary Attach a tag to a todo
+// @Description Attaches an existing tag to an existing todo, attaching it twice has no effect
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Param id path int true "Todo ID"
+// @Param tag_id path int true "Tag ID"
+// @Success 200 {object} errorResponse
+// @Failure 400 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /todo/{id}/tags/{tag_id} [post]
+func (h *TagHandler) AttachTag(ctx *gin.Context) {
+	todoId, tagId, err := parseTodoTagParams(ctx)
+	if err != nil {
+		newErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err = h.service.AttachTag(ctx.Request.Context(), todoId, tagId); err != nil {
+		newServiceErrorResponse(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "tag attached successfully"})
+}
+
+// DetachTag godoc
+// @Summary Detach a tag from a todo
+// @Description Removes a tag from a todo
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Param id path int true "Todo ID"
+// @Param tag_id path int true "Tag ID"
+// @Success 200 {object} errorResponse
+// @Failure 400 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /todo/{id}/tags/{tag_id} [delete]
+func (h *TagHandler) DetachTag(ctx *gin.Context) {
+	todoId, tagId, err := parseTodoTagParams(ctx)
+	if err != nil {
+		newErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err = h.service.DetachTag(ctx.Request.Context(), todoId, tagId); err != nil {
+		newServiceErrorResponse(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "tag detached successfully"})
+}
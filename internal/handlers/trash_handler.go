@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+// GetTrash godoc
+// @Summary Get trashed todos
+// @Description Returns a page of the todos in the trash, ordered by creation time unless sort is given. Trashed todos are purged after the configured retention
+// @Tags trash
+// @Accept json
+// @Produce json
+// @Param limit query int false "Page size (1-100)" default(20)
+// @Param cursor query string false "Cursor returned as next_cursor by the previous page"
+// @Param with_total query bool false "Include the total number of todos"
+// @Param completed query bool false "Only completed or only open todos"
+// @Param title_contains query string false "Case-insensitive substring of the title"
+// @Param sort query string false "Comma separated field:direction list" example(created_at:desc)
+// @Success 200 {object} models.TodoPage
+// @Failure 400 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /trash [get]
+func (h *TodoHandler) GetTrash(ctx *gin.Context) {
+	listTodos(ctx, h.service, todoTrashQueryParams, func(filter *models.TodoFilter) error {
+		filter.Trashed = true
+		return nil
+	})
+}
+
+// RestoreTodo godoc
+// @Summary Restore a todo from the trash
+// @Description Takes a todo out of the trash together with the subtasks trashed along with it. A subtask cannot be restored while its parent is in the trash
+// @Tags trash
+// @Accept json
+// @Produce json
+// @Param id path int true "Todo ID"
+// @Success 200 {object} models.TodoModel
+// @Failure 400 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 409 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /trash/{id}/restore [post]
+func (h *TodoHandler) RestoreTodo(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		newErrorResponse(ctx, http.StatusBadRequest, "invalid id param")
+		return
+	}
+	todo, err := h.service.RestoreTodo(ctx.Request.Context(), id)
+	if err != nil {
+		newServiceErrorResponse(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, todo)
+}
+
+// DeleteTrashedTodo godoc
+// @Summary Permanently delete a trashed todo
+// @Description Deletes a todo that is in the trash for good, together with its subtasks
+// @Tags trash
+// @Accept json
+// @Produce json
+// @Param id path int true "Todo ID"
+// @Success 200 {object} errorResponse
+// @Failure 400 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /trash/{id} [delete]
+func (h *TodoHandler) DeleteTrashedTodo(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		newErrorResponse(ctx, http.StatusBadRequest, "invalid id param")
+		return
+	}
+	if err = h.service.DeleteTrashedTodo(ctx.Request.Context(), id); err != nil {
+		newServiceErrorResponse(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "todo deleted permanently"})
+}
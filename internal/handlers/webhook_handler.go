@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/cherrycutter/todo_app/internal/services"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+type WebhookHandler struct {
+	service services.WebhookService
+}
+
+func NewWebhookHandler(service services.WebhookService) *WebhookHandler {
+	return &WebhookHandler{service: service}
+}
+
+func (h *WebhookHandler) RegisterRoutes(router gin.IRouter) {
+	webhooks := router.Group("/webhooks", RequireUserToken())
+	webhooks.GET("", h.GetWebhooks)
+	webhooks.POST("", h.PostWebhook)
+	webhooks.GET("/:id", h.GetWebhook)
+	webhooks.PUT("/:id", h.PutWebhook)
+	webhooks.DELETE("/:id", h.DeleteWebhook)
+	webhooks.GET("/:id/deliveries", h.GetWebhookDeliveries)
+}
+
+// GetWebhooks godoc
+// @Summary Get all webhooks
+// @Description Returns the webhooks of the user, without their secret
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Success 200 {array} models.WebhookModel
+// @Failure 401 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /webhooks [get]
+func (h *WebhookHandler) GetWebhooks(ctx *gin.Context) {
+	webhooks, err := h.service.GetWebhooks(ctx.Request.Context())
+	if err != nil {
+		newServiceErrorResponse(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, webhooks)
+}
+
+// GetWebhook godoc
+// @Summary Get a webhook
+// @Description Returns a webhook by id, without its secret
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Success 200 {object} models.WebhookModel
+// @Failure 400 {object} errorResponse
+// @Failure 401 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /webhooks/{id} [get]
+func (h *WebhookHandler) GetWebhook(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		newErrorResponse(ctx, http.StatusBadRequest, "invalid id param")
+		return
+	}
+	webhook, err := h.service.GetWebhook(ctx.Request.Context(), id)
+	if err != nil {
+		newServiceErrorResponse(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, webhook)
+}
+
+// PostWebhook godoc
+// @Summary Create a new webhook
+// @Description Subscribes a url to todo events (todo.created, todo.updated, todo.deleted). Payloads are signed with the secret, which is generated when left empty and only shown in this response
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param webhook body models.WebhookModel true "Url, event types and optional secret of the webhook"
+// @Success 201 {object} models.WebhookModel
+// @Failure 400 {object} errorResponse
+// @Failure 401 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /webhooks [post]
+func (h *WebhookHandler) PostWebhook(ctx *gin.Context) {
+	var webhook models.WebhookModel
+	if err := ctx.BindJSON(&webhook); err != nil {
+		newErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	createdWebhook, err := h.service.CreateWebhook(ctx.Request.Context(), webhook)
+	if err != nil {
+		newServiceErrorResponse(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, createdWebhook)
+}
+
+// PutWebhook godoc
+// @Summary Update a webhook
+// @Description Replaces the url and event types of a webhook, and its secret when one is given. The webhook becomes healthy again and its pending deliveries are retried
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Param webhook body models.WebhookModel true "Url, event types and optional secret of the webhook"
+// @Success 200 {object} models.WebhookModel
+// @Failure 400 {object} errorResponse
+// @Failure 401 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /webhooks/{id} [put]
+func (h *WebhookHandler) PutWebhook(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		newErrorResponse(ctx, http.StatusBadRequest, "invalid id param")
+		return
+	}
+	var webhook models.WebhookModel
+	if err = ctx.BindJSON(&webhook); err != nil {
+		newErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	updatedWebhook, err := h.service.UpdateWebhook(ctx.Request.Context(), id, webhook)
+	if err != nil {
+		newServiceErrorResponse(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, updatedWebhook)
+}
+
+// DeleteWebhook godoc
+// @Summary Delete a webhook
+// @Description Deletes a webhook together with its deliveries
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Success 200 {object} errorResponse
+// @Failure 400 {object} errorResponse
+// @Failure 401 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /webhooks/{id} [delete]
+func (h *WebhookHandler) DeleteWebhook(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		newErrorResponse(ctx, http.StatusBadRequest, "invalid id param")
+		return
+	}
+	if err = h.service.DeleteWebhook(ctx.Request.Context(), id); err != nil {
+		newServiceErrorResponse(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "webhook deleted successfully"})
+}
+
+// GetWebhookDeliveries godoc
+// @Summary Get the deliveries of a webhook
+// @Description Returns the latest deliveries of a webhook newest first, each with its status and the outcome of every attempt
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Param limit query int false "Number of deliveries (1-100)" default(20)
+// @Success 200 {array} models.WebhookDelivery
+// @Failure 400 {object} errorResponse
+// @Failure 401 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /webhooks/{id}/deliveries [get]
+func (h *WebhookHandler) GetWebhookDeliveries(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		newErrorResponse(ctx, http.StatusBadRequest, "invalid id param")
+		return
+	}
+	if err = checkQueryParams(ctx, webhookDeliveriesQueryParams); err != nil {
+		newErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	params, err := parseListParams(ctx)
+	if err != nil {
+		newErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	deliveries, err := h.service.GetWebhookDeliveries(ctx.Request.Context(), id, params.Limit)
+	if err != nil {
+		newServiceErrorResponse(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, deliveries)
+}
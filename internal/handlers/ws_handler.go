@@ -0,0 +1,317 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/cherrycutter/todo_app/internal/auth"
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/cherrycutter/todo_app/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// wsProtocol is the subprotocol of the websocket endpoint, browsers offer it next to "bearer.<token>"
+// because they cannot send an Authorization header
+const (
+	wsProtocol             = "todos.v1"
+	wsBearerProtocolPrefix = "bearer."
+)
+
+// Types of the messages sent by clients
+const (
+	wsTypeSubscribe   = "subscribe"
+	wsTypeUnsubscribe = "unsubscribe"
+	wsTypeView        = "view"
+	wsTypeLeave       = "leave"
+	wsTypeCreate      = "create"
+	wsTypeUpdate      = "update"
+	wsTypePatch       = "patch"
+	wsTypeDelete      = "delete"
+)
+
+// Types of the messages sent to clients
+const (
+	wsTypeWelcome  = "welcome"
+	wsTypeAck      = "ack"
+	wsTypeError    = "error"
+	wsTypeEvent    = "event"
+	wsTypePresence = "presence"
+)
+
+// wsUpgrader accepts connections from any origin, they are authenticated by a token and not by cookies
+var wsUpgrader = websocket.Upgrader{
+	Subprotocols: []string{wsProtocol},
+	CheckOrigin: func(r *http.Request) bool {
+		return true
+	},
+}
+
+// wsRequest is a message sent by a client, its id is echoed by the answer
+type wsRequest struct {
+	Id        string          `json:"id"`
+	Type      string          `json:"type"`
+	TodoId    int             `json:"todo_id"`
+	ProjectId int             `json:"project_id"`
+	IfMatch   *int            `json:"if_match"`
+	Cascade   bool            `json:"cascade"`
+	Todo      json.RawMessage `json:"todo"`
+}
+
+type wsWelcomeMessage struct {
+	Type     string `json:"type"`
+	ClientId string `json:"client_id"`
+}
+
+// wsAckMessage acknowledges a request, mutations carry the todo they wrote
+type wsAckMessage struct {
+	Type   string            `json:"type"`
+	Id     string            `json:"id"`
+	Status int               `json:"status"`
+	Todo   *models.TodoModel `json:"todo,omitempty"`
+}
+
+// wsErrorMessage answers a failed request with the status and message the HTTP endpoint would send,
+// a failed If-Match carries the current todo
+type wsErrorMessage struct {
+	Type    string            `json:"type"`
+	Id      string            `json:"id,omitempty"`
+	Status  int               `json:"status"`
+	Message string            `json:"message"`
+	Todo    *models.TodoModel `json:"todo,omitempty"`
+}
+
+type wsEventMessage struct {
+	Type  string           `json:"type"`
+	Event models.TodoEvent `json:"event"`
+}
+
+type wsPresenceMessage struct {
+	Type    string     `json:"type"`
+	TodoId  int        `json:"todo_id"`
+	Viewers []wsViewer `json:"viewers"`
+}
+
+type WSHandler struct {
+	todos    services.TodoService
+	projects services.ProjectService
+	events   services.EventService
+	hub      *wsHub
+}
+
+func NewWSHandler(todos services.TodoService, projects services.ProjectService, events services.EventService) *WSHandler {
+	return &WSHandler{todos: todos, projects: projects, events: events, hub: newWSHub()}
+}
+
+func (h *WSHandler) RegisterRoutes(router gin.IRouter) {
+	router.GET("/ws", RequireScope(auth.ScopeTodosRead), h.Connect)
+}
+
+// WebSocketProtocolAuth lets browsers authenticate a websocket connection with a "bearer.<token>" subprotocol,
+// it has to run before BearerAuth
+func WebSocketProtocolAuth() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if ctx.GetHeader("Authorization") == "" {
+			for _, protocol := range websocket.Subprotocols(ctx.Request) {
+				if token, ok := strings.CutPrefix(protocol, wsBearerProtocolPrefix); ok {
+					ctx.Request.Header.Set("Authorization", "Bearer "+token)
+					break
+				}
+			}
+		}
+		ctx.Next()
+	}
+}
+
+// Connect godoc
+// @Summary Collaborate over a websocket
+// @Description Upgrades to a websocket (subprotocol todos.v1) to subscribe to todos and projects, receive their changes, send creates, updates, patches and deletes acknowledged by id, and see which clients view a todo. Browsers authenticate with a bearer.<token> subprotocol
+// @Tags events
+// @Param Sec-WebSocket-Protocol header string false "todos.v1, bearer.<token>"
+// @Success 101
+// @Failure 400 {object} errorResponse
+// @Failure 401 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /ws [get]
+func (h *WSHandler) Connect(ctx *gin.Context) {
+	userId, ok := auth.UserIdFromContext(ctx.Request.Context())
+	if !ok {
+		newServiceErrorResponse(ctx, services.ErrUnauthenticated)
+		return
+	}
+	reqCtx, cancel := context.WithCancel(ctx.Request.Context())
+	defer cancel()
+	events, err := h.events.Subscribe(reqCtx, nil)
+	if err != nil {
+		newServiceErrorResponse(ctx, err)
+		return
+	}
+	conn, err := wsUpgrader.Upgrade(ctx.Writer, ctx.Request, nil)
+	if err != nil {
+		// the upgrader already answered the request
+		return
+	}
+	client, err := newWSClient(userId, conn)
+	if err != nil {
+		conn.Close()
+		return
+	}
+
+	h.hub.register(client)
+	defer h.hub.unregister(client)
+	defer client.close()
+	go client.writePump()
+	go h.forwardEvents(client, events)
+
+	client.send(wsWelcomeMessage{Type: wsTypeWelcome, ClientId: client.id})
+	h.readPump(reqCtx, client)
+}
+
+// forwardEvents sends the events a client subscribed to. The stream ends when the client could not keep up or
+// the relay failed, the client is then disconnected to reconnect and fetch its todos again
+func (h *WSHandler) forwardEvents(c *wsClient, events <-chan models.TodoEvent) {
+	for event := range events {
+		if c.wants(event) {
+			c.send(wsEventMessage{Type: wsTypeEvent, Event: event})
+		}
+	}
+	c.close()
+}
+
+// readPump handles the requests of a client one after the other until it disconnects
+func (h *WSHandler) readPump(ctx context.Context, c *wsClient) {
+	c.conn.SetReadLimit(wsMaxMessageSize)
+	c.conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+	})
+	for {
+		_, raw, err := c.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		var req wsRequest
+		if err = json.Unmarshal(raw, &req); err != nil {
+			c.send(wsErrorMessage{Type: wsTypeError, Status: http.StatusBadRequest, Message: err.Error()})
+			continue
+		}
+		todo, err := h.handle(ctx, c, req)
+		if err != nil {
+			c.send(newWSErrorMessage(req.Id, err))
+			continue
+		}
+		status := http.StatusOK
+		if req.Type == wsTypeCreate {
+			status = http.StatusCreated
+		}
+		c.send(wsAckMessage{Type: wsTypeAck, Id: req.Id, Status: status, Todo: todo})
+	}
+}
+
+// handle runs a request of a client through the same services as the HTTP endpoints, mutations return their todo
+func (h *WSHandler) handle(ctx context.Context, c *wsClient, req wsRequest) (*models.TodoModel, error) {
+	switch req.Type {
+	case wsTypeSubscribe, wsTypeUnsubscribe:
+		if (req.TodoId == 0) == (req.ProjectId == 0) {
+			return nil, &services.ValidationError{Message: "either todo_id or project_id is required"}
+		}
+		subscribed := req.Type == wsTypeSubscribe
+		if subscribed {
+			if err := h.checkReadable(ctx, req); err != nil {
+				return nil, err
+			}
+		}
+		c.subscribe(req.TodoId, req.ProjectId, subscribed)
+		return nil, nil
+	case wsTypeView:
+		if _, err := h.todos.GetTodo(ctx, req.TodoId); err != nil {
+			return nil, err
+		}
+		h.hub.view(c, req.TodoId)
+		return nil, nil
+	case wsTypeLeave:
+		h.hub.leave(c, req.TodoId)
+		return nil, nil
+	case wsTypeCreate, wsTypeUpdate, wsTypePatch, wsTypeDelete:
+		if !auth.HasScope(ctx, auth.ScopeTodosWrite) {
+			return nil, errWSInsufficientScope
+		}
+		return h.mutate(ctx, req)
+	default:
+		return nil, &services.ValidationError{Message: "unknown message type " + req.Type}
+	}
+}
+
+// checkReadable makes sure the todo or project of a subscription exists and can be read
+func (h *WSHandler) checkReadable(ctx context.Context, req wsRequest) error {
+	if req.TodoId != 0 {
+		_, err := h.todos.GetTodo(ctx, req.TodoId)
+		return err
+	}
+	if !auth.HasScope(ctx, auth.ScopeProjectsRead) {
+		return errWSInsufficientScope
+	}
+	_, err := h.projects.GetProject(ctx, req.ProjectId)
+	return err
+}
+
+// mutate applies a create, update, patch or delete like the matching HTTP endpoint
+func (h *WSHandler) mutate(ctx context.Context, req wsRequest) (*models.TodoModel, error) {
+	opts := models.TodoUpdateOptions{CascadeCompletion: req.Cascade, IfMatch: req.IfMatch}
+	var todo models.TodoModel
+	var err error
+	switch req.Type {
+	case wsTypeCreate:
+		if err = unmarshalWSTodo(req.Todo, &todo); err != nil {
+			return nil, err
+		}
+		todo, err = h.todos.CreateTodo(ctx, todo)
+	case wsTypeUpdate:
+		if err = unmarshalWSTodo(req.Todo, &todo); err != nil {
+			return nil, err
+		}
+		todo, err = h.todos.UpdateTodo(ctx, req.TodoId, todo, opts)
+	case wsTypePatch:
+		var patch models.TodoPatch
+		if err = unmarshalWSTodo(req.Todo, &patch); err != nil {
+			return nil, err
+		}
+		todo, err = h.todos.PatchTodo(ctx, req.TodoId, patch, opts)
+	case wsTypeDelete:
+		return nil, h.todos.DeleteTodo(ctx, req.TodoId, req.IfMatch)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &todo, nil
+}
+
+func unmarshalWSTodo(raw json.RawMessage, v interface{}) error {
+	if len(raw) == 0 {
+		return &services.ValidationError{Message: "field todo is required"}
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return &services.ValidationError{Message: err.Error()}
+	}
+	return nil
+}
+
+var errWSInsufficientScope = errors.New("api key lacks the scope of the request")
+
+// newWSErrorMessage describes a failed request like newServiceErrorResponse
+func newWSErrorMessage(id string, err error) wsErrorMessage {
+	msg := wsErrorMessage{Type: wsTypeError, Id: id}
+	var preconditionErr *services.PreconditionFailedError
+	switch {
+	case errors.As(err, &preconditionErr):
+		msg.Status, msg.Message, msg.Todo = http.StatusPreconditionFailed, err.Error(), &preconditionErr.Current
+	case errors.Is(err, errWSInsufficientScope):
+		msg.Status, msg.Message = http.StatusForbidden, err.Error()
+	default:
+		msg.Status, msg.Message = serviceErrorStatus(err)
+	}
+	return msg
+}
@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/gorilla/websocket"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// wsSendBufferSize is how many messages a client can fall behind before it is disconnected
+	wsSendBufferSize = 64
+	// wsMaxMessageSize bounds the messages read from a client
+	wsMaxMessageSize = 64 << 10
+	// wsWriteTimeout bounds the time a message takes to be written to a client
+	wsWriteTimeout = 10 * time.Second
+	// wsPongTimeout is how long a client stays connected without answering a ping
+	wsPongTimeout = 60 * time.Second
+	// wsPingInterval is how often clients are pinged, a ping must be answered before wsPongTimeout
+	wsPingInterval = wsPongTimeout * 9 / 10
+)
+
+// wsHub keeps track of the websocket clients of this instance and of the todos they are viewing
+type wsHub struct {
+	mu sync.Mutex
+	// clients are the connected clients by user
+	clients map[int]map[*wsClient]struct{}
+	// viewers are the clients viewing a todo by user and todo
+	viewers map[wsTodoKey]map[*wsClient]struct{}
+}
+
+type wsTodoKey struct {
+	userId int
+	todoId int
+}
+
+// wsViewer is a client viewing a todo
+type wsViewer struct {
+	UserId   int    `json:"user_id"`
+	ClientId string `json:"client_id"`
+}
+
+func newWSHub() *wsHub {
+	return &wsHub{
+		clients: make(map[int]map[*wsClient]struct{}),
+		viewers: make(map[wsTodoKey]map[*wsClient]struct{}),
+	}
+}
+
+func (h *wsHub) register(c *wsClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.clients[c.userId] == nil {
+		h.clients[c.userId] = make(map[*wsClient]struct{})
+	}
+	h.clients[c.userId][c] = struct{}{}
+}
+
+// unregister forgets a disconnected client, the todos it was viewing lose a viewer
+func (h *wsHub) unregister(c *wsClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.clients[c.userId], c)
+	if len(h.clients[c.userId]) == 0 {
+		delete(h.clients, c.userId)
+	}
+	for key, viewers := range h.viewers {
+		if _, ok := viewers[c]; ok && key.userId == c.userId {
+			h.leaveLocked(c, key)
+		}
+	}
+}
+
+// view marks a client as viewing a todo and tells the clients of the user who views it
+func (h *wsHub) view(c *wsClient, todoId int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	key := wsTodoKey{userId: c.userId, todoId: todoId}
+	if h.viewers[key] == nil {
+		h.viewers[key] = make(map[*wsClient]struct{})
+	}
+	h.viewers[key][c] = struct{}{}
+	h.broadcastPresence(key)
+}
+
+// leave marks a client as no longer viewing a todo
+func (h *wsHub) leave(c *wsClient, todoId int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.leaveLocked(c, wsTodoKey{userId: c.userId, todoId: todoId})
+}
+
+// leaveLocked removes a viewer of a todo, h.mu must be held
+func (h *wsHub) leaveLocked(c *wsClient, key wsTodoKey) {
+	if _, ok := h.viewers[key][c]; !ok {
+		return
+	}
+	delete(h.viewers[key], c)
+	if len(h.viewers[key]) == 0 {
+		delete(h.viewers, key)
+	}
+	h.broadcastPresence(key)
+}
+
+// broadcastPresence sends the viewers of a todo to every client of its user, h.mu must be held
+func (h *wsHub) broadcastPresence(key wsTodoKey) {
+	viewers := make([]wsViewer, 0, len(h.viewers[key]))
+	for c := range h.viewers[key] {
+		viewers = append(viewers, wsViewer{UserId: c.userId, ClientId: c.id})
+	}
+	sort.Slice(viewers, func(i, j int) bool {
+		return viewers[i].ClientId < viewers[j].ClientId
+	})
+	msg := wsPresenceMessage{Type: wsTypePresence, TodoId: key.todoId, Viewers: viewers}
+	for c := range h.clients[key.userId] {
+		c.send(msg)
+	}
+}
+
+// wsClient is a websocket connection of a user. Messages are queued in a bounded buffer written by one goroutine,
+// a client whose buffer is full is disconnected
+type wsClient struct {
+	id     string
+	userId int
+	conn   *websocket.Conn
+
+	queue     chan []byte
+	done      chan struct{}
+	closeOnce sync.Once
+
+	mu sync.Mutex
+	// todos and projects are the subscriptions of the client
+	todos    map[int]bool
+	projects map[int]bool
+}
+
+func newWSClient(userId int, conn *websocket.Conn) (*wsClient, error) {
+	raw := make([]byte, 8)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, err
+	}
+	return &wsClient{
+		id:       hex.EncodeToString(raw),
+		userId:   userId,
+		conn:     conn,
+		queue:    make(chan []byte, wsSendBufferSize),
+		done:     make(chan struct{}),
+		todos:    make(map[int]bool),
+		projects: make(map[int]bool),
+	}, nil
+}
+
+// send queues a message without blocking, a client that fell too far behind is disconnected
+func (c *wsClient) send(msg interface{}) {
+	raw, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+	select {
+	case <-c.done:
+	case c.queue <- raw:
+	default:
+		c.close()
+	}
+}
+
+// close disconnects the client, it can be called more than once
+func (c *wsClient) close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+		c.conn.Close()
+	})
+}
+
+// writePump writes the queued messages and pings the client until it is closed
+func (c *wsClient) writePump() {
+	ping := time.NewTicker(wsPingInterval)
+	defer ping.Stop()
+	defer c.close()
+	for {
+		select {
+		case <-c.done:
+			return
+		case raw := <-c.queue:
+			c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+			if err := c.conn.WriteMessage(websocket.TextMessage, raw); err != nil {
+				return
+			}
+		case <-ping.C:
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// subscribe adds or removes a subscription to a todo or a project, one of the ids is 0
+func (c *wsClient) subscribe(todoId, projectId int, subscribed bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if todoId != 0 {
+		setSubscription(c.todos, todoId, subscribed)
+	} else {
+		setSubscription(c.projects, projectId, subscribed)
+	}
+}
+
+func setSubscription(ids map[int]bool, id int, subscribed bool) {
+	if subscribed {
+		ids[id] = true
+	} else {
+		delete(ids, id)
+	}
+}
+
+// wants tells whether an event concerns a todo or a project the client subscribed to
+func (c *wsClient) wants(event models.TodoEvent) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.todos[event.TodoId] {
+		return true
+	}
+	return event.Todo != nil && event.Todo.ProjectId != nil && c.projects[*event.Todo.ProjectId]
+}
@@ -0,0 +1,79 @@
+package metrics
+
+import (
+	"context"
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/prometheus/client_golang/prometheus"
+	"time"
+)
+
+var (
+	poolMaxConnsDesc      = prometheus.NewDesc("db_pool_max_conns", "Maximum number of connections of the database pool.", nil, nil)
+	poolTotalConnsDesc    = prometheus.NewDesc("db_pool_total_conns", "Connections of the database pool.", nil, nil)
+	poolIdleConnsDesc     = prometheus.NewDesc("db_pool_idle_conns", "Idle connections of the database pool.", nil, nil)
+	poolAcquiredConnsDesc = prometheus.NewDesc("db_pool_acquired_conns", "Connections of the database pool in use.", nil, nil)
+	poolAcquiresDesc      = prometheus.NewDesc("db_pool_acquires_total", "Connections acquired from the database pool.", nil, nil)
+	poolEmptyAcquiresDesc = prometheus.NewDesc("db_pool_empty_acquires_total", "Acquires that had to wait for a connection of the database pool.", nil, nil)
+	poolAcquireTimeDesc   = prometheus.NewDesc("db_pool_acquire_duration_seconds_total", "Time spent acquiring connections of the database pool.", nil, nil)
+	poolNewConnsDesc      = prometheus.NewDesc("db_pool_new_conns_total", "Connections opened by the database pool.", nil, nil)
+
+	todosTotalDesc = prometheus.NewDesc("todos_total", "Todos of every user that are not in the trash.", nil, nil)
+	todosOpenDesc  = prometheus.NewDesc("todos_open", "Todos of every user that are neither completed nor in the trash.", nil, nil)
+)
+
+// poolCollector reads the stats of the database pool on every scrape
+type poolCollector struct {
+	stats func() models.PoolStats
+}
+
+// NewPoolCollector collects the stats of the database pool
+func NewPoolCollector(stats func() models.PoolStats) prometheus.Collector {
+	return &poolCollector{stats: stats}
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{poolMaxConnsDesc, poolTotalConnsDesc, poolIdleConnsDesc, poolAcquiredConnsDesc,
+		poolAcquiresDesc, poolEmptyAcquiresDesc, poolAcquireTimeDesc, poolNewConnsDesc} {
+		ch <- desc
+	}
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.stats()
+	ch <- prometheus.MustNewConstMetric(poolMaxConnsDesc, prometheus.GaugeValue, float64(stats.MaxConns))
+	ch <- prometheus.MustNewConstMetric(poolTotalConnsDesc, prometheus.GaugeValue, float64(stats.TotalConns))
+	ch <- prometheus.MustNewConstMetric(poolIdleConnsDesc, prometheus.GaugeValue, float64(stats.IdleConns))
+	ch <- prometheus.MustNewConstMetric(poolAcquiredConnsDesc, prometheus.GaugeValue, float64(stats.AcquiredConns))
+	ch <- prometheus.MustNewConstMetric(poolAcquiresDesc, prometheus.CounterValue, float64(stats.AcquireCount))
+	ch <- prometheus.MustNewConstMetric(poolEmptyAcquiresDesc, prometheus.CounterValue, float64(stats.EmptyAcquireCount))
+	ch <- prometheus.MustNewConstMetric(poolAcquireTimeDesc, prometheus.CounterValue, float64(stats.AcquireDurationMs)/1000)
+	ch <- prometheus.MustNewConstMetric(poolNewConnsDesc, prometheus.CounterValue, float64(stats.NewConnsCount))
+}
+
+// todoCollector counts the todos on every scrape, a failed count leaves the gauges out of the scrape
+type todoCollector struct {
+	count   func(ctx context.Context) (models.TodoCounts, error)
+	timeout time.Duration
+}
+
+// NewTodoCollector collects the number of todos and of open todos, each count is bounded by timeout
+func NewTodoCollector(count func(ctx context.Context) (models.TodoCounts, error), timeout time.Duration) prometheus.Collector {
+	return &todoCollector{count: count, timeout: timeout}
+}
+
+func (c *todoCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- todosTotalDesc
+	ch <- todosOpenDesc
+}
+
+func (c *todoCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	counts, err := c.count(ctx)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(todosTotalDesc, err)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(todosTotalDesc, prometheus.GaugeValue, float64(counts.Total))
+	ch <- prometheus.MustNewConstMetric(todosOpenDesc, prometheus.GaugeValue, float64(counts.Open))
+}
@@ -0,0 +1,80 @@
+package metrics
+
+import (
+	"errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Metrics holds the Prometheus collectors of the API in a registry of its own
+type Metrics struct {
+	registry        *prometheus.Registry
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	queryDuration   *prometheus.HistogramVec
+}
+
+// New creates the request and query metrics together with the Go runtime and process metrics
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "HTTP requests handled, by method, route template and status.",
+		}, []string{"method", "route", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "Time taken to handle HTTP requests, by method, route template and status.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		queryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "db_query_duration_seconds",
+			Help:    "Time taken by repository methods, by repository, method and status.",
+			Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"repository", "method", "status"}),
+	}
+	m.registry.MustRegister(
+		m.requests,
+		m.requestDuration,
+		m.queryDuration,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// Register adds collectors of other parts of the API
+func (m *Metrics) Register(collectors ...prometheus.Collector) error {
+	var errs []error
+	for _, collector := range collectors {
+		errs = append(errs, m.registry.Register(collector))
+	}
+	return errors.Join(errs...)
+}
+
+// Handler serves the metrics in the Prometheus text exposition format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// ObserveRequest records a handled request, route is the template of the matched route
+func (m *Metrics) ObserveRequest(method, route string, status int, duration time.Duration) {
+	statusLabel := strconv.Itoa(status)
+	m.requests.WithLabelValues(method, route, statusLabel).Inc()
+	m.requestDuration.WithLabelValues(method, route, statusLabel).Observe(duration.Seconds())
+}
+
+// QueryObserver returns an observer of the methods of a repository, their status is ok or error
+func (m *Metrics) QueryObserver(repository string) func(method string, duration time.Duration, err error) {
+	return func(method string, duration time.Duration, err error) {
+		status := "ok"
+		if err != nil {
+			status = "error"
+		}
+		m.queryDuration.WithLabelValues(repository, method, status).Observe(duration.Seconds())
+	}
+}
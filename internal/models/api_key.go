@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// APIKeyModel describes an API key, the key itself is only returned when it is created or rotated
+type APIKeyModel struct {
+	Id         int        `json:"id" example:"1"`
+	Name       string     `json:"name" example:"ci bot"`
+	Prefix     string     `json:"prefix" example:"tda_x1Y2z3"`
+	Scopes     []string   `json:"scopes" example:"todos:read,todos:write"`
+	LastUsedAt *time.Time `json:"last_used_at" example:"2024-06-01T09:00:00Z"`
+	CreatedAt  time.Time  `json:"created_at" example:"2023-05-23T08:00:00Z"`
+}
+
+// CreatedAPIKey is an API key together with its secret value
+type CreatedAPIKey struct {
+	APIKeyModel
+	Key string `json:"key"`
+}
@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// AuditOperation is the kind of change recorded by an audit entry
+type AuditOperation string
+
+const (
+	AuditCreate  AuditOperation = "create"
+	AuditUpdate  AuditOperation = "update"
+	AuditDelete  AuditOperation = "delete"
+	AuditRestore AuditOperation = "restore"
+	AuditPurge   AuditOperation = "purge"
+)
+
+// AuditChange is the value of a field before and after a change, Old is null for creates and New for deletes
+type AuditChange struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+// AuditEntry records who changed a todo, when and how, Changes only lists the fields that changed
+type AuditEntry struct {
+	Id        int64                  `json:"id" example:"1"`
+	TodoId    int                    `json:"todo_id" example:"1"`
+	ActorId   int                    `json:"actor_id" example:"7"`
+	Operation AuditOperation         `json:"operation" example:"update"`
+	Changes   map[string]AuditChange `json:"changes"`
+	CreatedAt time.Time              `json:"created_at" example:"2023-05-23T08:00:00Z"`
+}
+
+// AuditFilter narrows down the audit log, nil fields do not filter
+type AuditFilter struct {
+	TodoId    *int
+	ActorId   *int
+	Operation *AuditOperation
+	From      *time.Time
+	To        *time.Time
+}
+
+// AuditCursor points right after the last entry of a page, entries are listed newest first
+type AuditCursor struct {
+	Id int64 `json:"id"`
+}
+
+// AuditPage is one page of audit entries together with the opaque cursor of the next page
+type AuditPage struct {
+	Items      []AuditEntry `json:"items"`
+	NextCursor string       `json:"next_cursor,omitempty" example:"eyJpZCI6NDJ9"`
+}
@@ -0,0 +1,55 @@
+package models
+
+// MaxBulkOperations bounds the number of operations of a bulk request
+const MaxBulkOperations = 500
+
+// BulkMode tells how a bulk request handles a failed operation
+type BulkMode string
+
+const (
+	// BulkAtomic runs all operations in one transaction, a failed operation rolls back the whole request
+	BulkAtomic BulkMode = "atomic"
+	// BulkBestEffort runs every operation on its own and reports the outcome of each
+	BulkBestEffort BulkMode = "best_effort"
+)
+
+// BulkOp is the kind of a bulk operation
+type BulkOp string
+
+const (
+	BulkCreate BulkOp = "create"
+	BulkUpdate BulkOp = "update"
+	BulkDelete BulkOp = "delete"
+)
+
+// TodoBulkRequest is the body of the bulk request, operations run in the order they are listed
+type TodoBulkRequest struct {
+	Mode       BulkMode            `json:"mode" example:"atomic"`
+	Operations []TodoBulkOperation `json:"operations"`
+}
+
+// TodoBulkOperation creates a todo, replaces the todo Id or moves it to the trash. Updates and deletes
+// with IfMatch only apply while the todo has that version
+type TodoBulkOperation struct {
+	Op      BulkOp     `json:"op" example:"create"`
+	Id      int        `json:"id,omitempty" example:"1"`
+	IfMatch *int       `json:"if_match,omitempty" example:"3"`
+	Todo    *TodoModel `json:"todo,omitempty"`
+}
+
+// TodoBulkResult is the outcome of one operation, Todo is the created or updated todo
+// and the current todo when IfMatch did not match
+type TodoBulkResult struct {
+	Index  int        `json:"index" example:"0"`
+	Op     BulkOp     `json:"op" example:"create"`
+	Id     int        `json:"id,omitempty" example:"1"`
+	Status int        `json:"status" example:"201"`
+	Error  string     `json:"error,omitempty"`
+	Todo   *TodoModel `json:"todo,omitempty"`
+	Err    error      `json:"-"`
+}
+
+// TodoBulkResponse lists the results of a bulk request in the order of its operations
+type TodoBulkResponse struct {
+	Results []TodoBulkResult `json:"results"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// TodoEventType is the kind of change streamed by the event feed
+type TodoEventType string
+
+const (
+	TodoCreated TodoEventType = "created"
+	TodoUpdated TodoEventType = "updated"
+	TodoDeleted TodoEventType = "deleted"
+	// TodoEventsReset tells a resuming client that events were lost and its todos have to be fetched again
+	TodoEventsReset TodoEventType = "reset"
+)
+
+// TodoEvent is a change of a todo of the user, Todo is the todo after the change or, for deletes, before it
+type TodoEvent struct {
+	Id        int64         `json:"id,omitempty" example:"42"`
+	Type      TodoEventType `json:"type" example:"updated"`
+	TodoId    int           `json:"todo_id,omitempty" example:"1"`
+	Todo      *TodoModel    `json:"todo,omitempty"`
+	CreatedAt time.Time     `json:"created_at" example:"2023-05-23T08:00:00Z"`
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// SortField is one key of a list ordering
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// TodoFilter narrows down and orders the todos returned by list queries, zero values mean no restriction.
+// Todos match Tags when they carry any of them, or all of them when TagMatchAll is set
+type TodoFilter struct {
+	Completed     *bool
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	TitleContains string
+	DueFrom       *time.Time
+	DueTo         *time.Time
+	Overdue       bool
+	ProjectId     *int
+	SeriesId      *int
+	Trashed       bool
+	Tags          []string
+	TagMatchAll   bool
+	Sort          []SortField
+}
+
+// FormatSort renders sort fields back into the field:direction,... form accepted by the sort query param
+func FormatSort(fields []SortField) string {
+	parts := make([]string, 0, len(fields))
+	for _, f := range fields {
+		dir := "asc"
+		if f.Desc {
+			dir = "desc"
+		}
+		parts = append(parts, f.Field+":"+dir)
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,24 @@
+package models
+
+// HealthStatus tells whether a check, or the app as a whole, is usable
+type HealthStatus string
+
+const (
+	HealthUp   HealthStatus = "up"
+	HealthDown HealthStatus = "down"
+)
+
+// HealthCheckResult is the outcome of one check, a check that is not critical does not make the app unready
+type HealthCheckResult struct {
+	Name      string       `json:"name" example:"database"`
+	Status    HealthStatus `json:"status" example:"up"`
+	Critical  bool         `json:"critical" example:"true"`
+	LatencyMs int64        `json:"latency_ms" example:"2"`
+	Error     string       `json:"error,omitempty" example:"context deadline exceeded"`
+}
+
+// HealthReport lists the checks of the app, its status is down when a critical check is down
+type HealthReport struct {
+	Status HealthStatus        `json:"status" example:"up"`
+	Checks []HealthCheckResult `json:"checks"`
+}
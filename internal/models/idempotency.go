@@ -0,0 +1,14 @@
+package models
+
+// IdempotencyRecord is a request stored under an idempotency key, Response is nil until the request completed
+type IdempotencyRecord struct {
+	Fingerprint string
+	Response    *IdempotentResponse
+}
+
+// IdempotentResponse is the response of a request replayed to its retries
+type IdempotentResponse struct {
+	StatusCode int
+	Headers    map[string]string
+	Body       []byte
+}
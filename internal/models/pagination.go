@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+// ListParams holds the pagination options of a list request
+type ListParams struct {
+	Limit     int
+	Cursor    string
+	WithTotal bool
+}
+
+// TodoCursor holds the sortable fields of the last todo of a page, list queries continue right after it.
+// Sort is the ordering the cursor was produced for, a cursor cannot be reused with another ordering
+type TodoCursor struct {
+	Id        int        `json:"id"`
+	Title     string     `json:"title,omitempty"`
+	Completed bool       `json:"completed,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	DueAt     *time.Time `json:"due_at,omitempty"`
+	Sort      string     `json:"sort,omitempty"`
+}
+
+// NewTodoCursor returns the cursor pointing right after the given todo
+func NewTodoCursor(todo TodoModel, sort []SortField) TodoCursor {
+	return TodoCursor{
+		Id:        todo.Id,
+		Title:     todo.Title,
+		Completed: todo.Completed,
+		CreatedAt: todo.CreatedAt,
+		DueAt:     todo.DueAt,
+		Sort:      FormatSort(sort),
+	}
+}
+
+// TodoPage is one page of todos together with the opaque cursor of the next page
+type TodoPage struct {
+	Items      []TodoModel `json:"items"`
+	NextCursor string      `json:"next_cursor,omitempty" example:"eyJpZCI6MSwiY3JlYXRlZF9hdCI6IjIwMjMtMDUtMjNUMDg6MDA6MDBaIn0"`
+	Total      *int        `json:"total,omitempty" example:"42"`
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// PatchField is a field of a JSON merge patch (RFC 7396). Set tells whether the field was present
+// in the document, Null whether it was present with a null value
+type PatchField[T any] struct {
+	Set   bool
+	Null  bool
+	Value T
+}
+
+// UnmarshalJSON is only called for fields present in the document, including explicit nulls
+func (f *PatchField[T]) UnmarshalJSON(data []byte) error {
+	f.Set = true
+	if string(data) == "null" {
+		f.Null = true
+		return nil
+	}
+	return json.Unmarshal(data, &f.Value)
+}
+
+// pointer returns the value of a nullable field, nil for an explicit null
+func (f PatchField[T]) pointer() *T {
+	if f.Null {
+		return nil
+	}
+	v := f.Value
+	return &v
+}
+
+// TodoPatch is a merge patch of a todo, fields missing from the document are left untouched
+type TodoPatch struct {
+	Title       PatchField[string]    `json:"title" swaggertype:"string" example:"Sample Todo"`
+	Description PatchField[string]    `json:"description" swaggertype:"string" example:"This is a sample todo item"`
+	Completed   PatchField[bool]      `json:"completed" swaggertype:"boolean" example:"true"`
+	DueAt       PatchField[time.Time] `json:"due_at" swaggertype:"string" example:"2023-05-30T17:00:00+02:00"`
+	RemindAt    PatchField[time.Time] `json:"remind_at" swaggertype:"string" example:"2023-05-30T09:00:00+02:00"`
+	ProjectId   PatchField[int]       `json:"project_id" swaggertype:"integer" example:"1"`
+	ParentId    PatchField[int]       `json:"parent_id" swaggertype:"integer" example:"1"`
+	Recurrence  PatchField[string]    `json:"recurrence" swaggertype:"string" example:"FREQ=WEEKLY;BYDAY=TU"`
+}
+
+// IsEmpty tells whether the patch changes nothing
+func (p TodoPatch) IsEmpty() bool {
+	return !p.Title.Set && !p.Description.Set && !p.Completed.Set && !p.DueAt.Set && !p.RemindAt.Set && !p.ProjectId.Set && !p.ParentId.Set &&
+		!p.Recurrence.Set
+}
+
+// Apply returns the todo with the patch merged into it, a null description, due date, reminder, project,
+// parent or recurrence clears it
+func (p TodoPatch) Apply(todo TodoModel) TodoModel {
+	if p.Title.Set {
+		todo.Title = p.Title.Value
+	}
+	if p.Description.Set {
+		todo.Description = p.Description.Value
+	}
+	if p.Completed.Set {
+		todo.Completed = p.Completed.Value
+	}
+	if p.DueAt.Set {
+		todo.DueAt = p.DueAt.pointer()
+	}
+	if p.RemindAt.Set {
+		todo.RemindAt = p.RemindAt.pointer()
+	}
+	if p.ProjectId.Set {
+		todo.ProjectId = p.ProjectId.pointer()
+	}
+	if p.ParentId.Set {
+		todo.ParentId = p.ParentId.pointer()
+	}
+	if p.Recurrence.Set {
+		todo.Recurrence = p.Recurrence.pointer()
+	}
+	return todo
+}
@@ -0,0 +1,18 @@
+package models
+
+// PoolStats describes the database connection pool. The counts and durations since the start of the pool only grow,
+// EmptyAcquireCount counts the acquires that had to wait for a connection
+type PoolStats struct {
+	MaxConns                int32 `json:"max_conns" example:"10"`
+	TotalConns              int32 `json:"total_conns" example:"4"`
+	IdleConns               int32 `json:"idle_conns" example:"3"`
+	AcquiredConns           int32 `json:"acquired_conns" example:"1"`
+	ConstructingConns       int32 `json:"constructing_conns" example:"0"`
+	AcquireCount            int64 `json:"acquire_count" example:"1200"`
+	AcquireDurationMs       int64 `json:"acquire_duration_ms" example:"35"`
+	EmptyAcquireCount       int64 `json:"empty_acquire_count" example:"4"`
+	CanceledAcquireCount    int64 `json:"canceled_acquire_count" example:"0"`
+	NewConnsCount           int64 `json:"new_conns_count" example:"6"`
+	MaxIdleDestroyCount     int64 `json:"max_idle_destroy_count" example:"2"`
+	MaxLifetimeDestroyCount int64 `json:"max_lifetime_destroy_count" example:"0"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+type ProjectModel struct {
+	Id          int       `json:"id" example:"1"`
+	Name        string    `json:"name" example:"Website relaunch"`
+	Description string    `json:"description" example:"Everything for the new website"`
+	Color       string    `json:"color" example:"#ff7f50"`
+	Archived    bool      `json:"archived" example:"false"`
+	Inbox       bool      `json:"inbox" example:"false"`
+	CreatedAt   time.Time `json:"created_at" example:"2023-05-23T08:00:00Z"`
+}
+
+// ProjectDeleteMode tells what happens to the todos of a deleted project
+type ProjectDeleteMode string
+
+const (
+	// ProjectDeleteCascade moves the todos to the trash together with their subtasks before deleting the project
+	ProjectDeleteCascade ProjectDeleteMode = "cascade"
+	// ProjectDeleteMoveToInbox moves the todos to the inbox project
+	ProjectDeleteMoveToInbox ProjectDeleteMode = "move"
+)
@@ -0,0 +1,20 @@
+package models
+
+// TodoSearch is a full-text search request, Completed optionally narrows the hits down like the list filter
+type TodoSearch struct {
+	Query     string
+	Completed *bool
+}
+
+// TodoSearchHit is a todo matching a search with its rank and the matching parts highlighted
+type TodoSearchHit struct {
+	Todo                 TodoModel `json:"todo"`
+	Rank                 float32   `json:"rank" example:"0.6079271"`
+	TitleHighlight       string    `json:"title_highlight" example:"Write the <mark>report</mark>"`
+	DescriptionHighlight string    `json:"description_highlight" example:"quarterly <mark>report</mark> for the team"`
+}
+
+// TodoSearchResult holds the best ranked hits of a search
+type TodoSearchResult struct {
+	Items []TodoSearchHit `json:"items"`
+}
@@ -0,0 +1,7 @@
+package models
+
+// TodoCounts counts the todos of every user that are not in the trash
+type TodoCounts struct {
+	Total int64 `json:"total" example:"1200"`
+	Open  int64 `json:"open" example:"340"`
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+type TagModel struct {
+	Id          int       `json:"id" example:"1"`
+	Name        string    `json:"name" example:"backend"`
+	Color       string    `json:"color" example:"#1e90ff"`
+	Description string    `json:"description" example:"Server side work"`
+	CreatedAt   time.Time `json:"created_at" example:"2023-05-23T08:00:00Z"`
+}
@@ -3,9 +3,52 @@ package models
 import "time"
 
 type TodoModel struct {
-	Id          int       `json:"id" example:"1"`
-	Title       string    `json:"title" example:"Sample Todo"`
-	Description string    `json:"description" example:"This is a sample todo item"`
-	Completed   bool      `json:"completed" example:"false"`
-	CreatedAt   time.Time `json:"created_at" example:"2023-05-23T08:00:00Z"`
+	Id          int           `json:"id" example:"1"`
+	Title       string        `json:"title" example:"Sample Todo"`
+	Description string        `json:"description" example:"This is a sample todo item"`
+	Completed   bool          `json:"completed" example:"false"`
+	CreatedAt   time.Time     `json:"created_at" example:"2023-05-23T08:00:00Z"`
+	DueAt       *time.Time    `json:"due_at" example:"2023-05-30T17:00:00+02:00"`
+	RemindAt    *time.Time    `json:"remind_at" example:"2023-05-30T09:00:00+02:00"`
+	ProjectId   *int          `json:"project_id" example:"1"`
+	ParentId    *int          `json:"parent_id" example:"1"`
+	Position    int           `json:"position" example:"0"`
+	Recurrence  *string       `json:"recurrence" example:"FREQ=WEEKLY;BYDAY=TU"`
+	SeriesId    *int          `json:"series_id" example:"1"`
+	DeletedAt   *time.Time    `json:"deleted_at,omitempty" example:"2023-06-01T08:00:00Z"`
+	Version     int           `json:"version" example:"1"`
+	Progress    *TodoProgress `json:"progress,omitempty"`
+	Tags        []TagModel    `json:"tags"`
+}
+
+// TodoProgress counts the completed direct subtasks of a todo, it is only set on todos with subtasks
+type TodoProgress struct {
+	Done  int `json:"done" example:"3"`
+	Total int `json:"total" example:"5"`
+}
+
+// TodoUpdateOptions tunes how an update of a todo is applied
+type TodoUpdateOptions struct {
+	// CascadeCompletion completes all subtasks, at any depth, when the todo gets completed
+	CascadeCompletion bool
+	// IfMatch is the version the todo must still have for the update to apply, nil applies it unconditionally
+	IfMatch *int
+}
+
+// SubtaskOrder is the body of the subtask reorder request
+type SubtaskOrder struct {
+	Ids []int `json:"ids" example:"3,1,2"`
+}
+
+// TodoOccurrence is an upcoming instance of a recurring todo
+type TodoOccurrence struct {
+	DueAt    time.Time  `json:"due_at" example:"2023-06-06T17:00:00+02:00"`
+	RemindAt *time.Time `json:"remind_at" example:"2023-06-06T09:00:00+02:00"`
+	// Recurrence is the rule the instance continues the series with, its COUNT only covers the instances left
+	Recurrence string `json:"-"`
+}
+
+// TodoOccurrences is the preview of the next instances of a recurring todo
+type TodoOccurrences struct {
+	Items []TodoOccurrence `json:"items"`
 }
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+type UserModel struct {
+	Id           int       `json:"id" example:"1"`
+	Email        string    `json:"email" example:"jane@example.com"`
+	PasswordHash string    `json:"-"`
+	CreatedAt    time.Time `json:"created_at" example:"2023-05-23T08:00:00Z"`
+}
+
+// Credentials is the body of the register and login requests
+type Credentials struct {
+	Email    string `json:"email" example:"jane@example.com"`
+	Password string `json:"password" example:"correct horse battery staple"`
+}
+
+// TokenPair is returned by login and refresh, the refresh token can be used once
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type" example:"Bearer"`
+	ExpiresIn    int    `json:"expires_in" example:"900"`
+}
+
+// RefreshRequest is the body of the refresh and logout requests
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
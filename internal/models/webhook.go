@@ -0,0 +1,86 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Event types a webhook can subscribe to
+const (
+	WebhookTodoCreated = "todo.created"
+	WebhookTodoUpdated = "todo.updated"
+	WebhookTodoDeleted = "todo.deleted"
+)
+
+// WebhookEventTypes lists every event type a webhook can subscribe to
+var WebhookEventTypes = []string{WebhookTodoCreated, WebhookTodoUpdated, WebhookTodoDeleted}
+
+// WebhookModel is an endpoint notified of changes of todos, the secret signing its payloads is only returned
+// when the webhook is created
+type WebhookModel struct {
+	Id           int       `json:"id" example:"1"`
+	Url          string    `json:"url" example:"https://chat.example.com/hooks/todos"`
+	Secret       string    `json:"secret,omitempty" example:"whsec_3q2-7wEXAMPLE"`
+	EventTypes   []string  `json:"event_types" example:"todo.created,todo.updated"`
+	Healthy      bool      `json:"healthy" example:"true"`
+	FailureCount int       `json:"failure_count" example:"0"`
+	CreatedAt    time.Time `json:"created_at" example:"2023-05-23T08:00:00Z"`
+}
+
+// WebhookDeliveryStatus is the state of a delivery, pending deliveries are retried until they succeed or
+// run out of attempts
+type WebhookDeliveryStatus string
+
+const (
+	WebhookPending   WebhookDeliveryStatus = "pending"
+	WebhookDelivered WebhookDeliveryStatus = "delivered"
+	WebhookFailed    WebhookDeliveryStatus = "failed"
+)
+
+// WebhookDelivery is a payload to post to a webhook together with the attempts made so far
+type WebhookDelivery struct {
+	Id            int64                 `json:"id" example:"42"`
+	WebhookId     int                   `json:"webhook_id" example:"1"`
+	EventType     string                `json:"event_type" example:"todo.updated"`
+	Payload       json.RawMessage       `json:"payload" swaggertype:"object"`
+	Status        WebhookDeliveryStatus `json:"status" example:"pending"`
+	Attempts      []WebhookAttempt      `json:"attempts"`
+	NextAttemptAt *time.Time            `json:"next_attempt_at" example:"2024-06-01T08:05:00Z"`
+	CreatedAt     time.Time             `json:"created_at" example:"2024-06-01T08:00:00Z"`
+	DeliveredAt   *time.Time            `json:"delivered_at" example:"2024-06-01T08:05:01Z"`
+}
+
+// WebhookAttempt is the outcome of one post of a delivery, StatusCode is nil when no response was received
+type WebhookAttempt struct {
+	StatusCode  *int      `json:"status_code" example:"500"`
+	Error       string    `json:"error,omitempty" example:"unexpected status 500"`
+	DurationMs  int64     `json:"duration_ms" example:"120"`
+	AttemptedAt time.Time `json:"attempted_at" example:"2024-06-01T08:00:01Z"`
+}
+
+// WebhookJob is a pending delivery claimed by the dispatcher, Attempts is the number of attempts made before
+type WebhookJob struct {
+	DeliveryId int64
+	WebhookId  int
+	Url        string
+	Secret     string
+	EventType  string
+	Payload    json.RawMessage
+	Attempts   int
+	CreatedAt  time.Time
+}
+
+// WebhookTodoData is the data of the payload of a todo event, Todo is the todo after the change or, for deletes,
+// before it
+type WebhookTodoData struct {
+	TodoId int        `json:"todo_id" example:"1"`
+	Todo   *TodoModel `json:"todo"`
+}
+
+// WebhookPayload is the body posted to a webhook, its id is the id of the delivery and stays the same on retries
+type WebhookPayload struct {
+	Id        int64           `json:"id" example:"42"`
+	Type      string          `json:"type" example:"todo.updated"`
+	CreatedAt time.Time       `json:"created_at" example:"2024-06-01T08:00:00Z"`
+	Data      json.RawMessage `json:"data" swaggertype:"object"`
+}
@@ -0,0 +1,112 @@
+package repos
+
+import (
+	"context"
+	"errors"
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+type APIKeyRepositoryImpl struct {
+	db PgxConnIface
+}
+
+func NewAPIKeyRepo(db PgxConnIface) APIKeyRepository {
+	return &APIKeyRepositoryImpl{db: db}
+}
+
+var (
+	ErrAPIKeyNotFound = errors.New("api key not found")
+)
+
+// apiKeyColumns is the column list scanned by scanAPIKey
+const apiKeyColumns = "id, name, prefix, scopes, last_used_at, created_at"
+
+// scanAPIKey scans a row selected with apiKeyColumns
+func scanAPIKey(row pgx.Row, key *models.APIKeyModel) error {
+	return row.Scan(&key.Id, &key.Name, &key.Prefix, &key.Scopes, &key.LastUsedAt, &key.CreatedAt)
+}
+
+// GetAllAPIKeys returns the keys of the owner that are not revoked
+func (r *APIKeyRepositoryImpl) GetAllAPIKeys(ctx context.Context, ownerId int) ([]models.APIKeyModel, error) {
+	rows, err := r.db.Query(ctx, "SELECT "+apiKeyColumns+" FROM api_key WHERE user_id = $1 AND revoked_at IS NULL ORDER BY id", ownerId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []models.APIKeyModel{}
+	for rows.Next() {
+		var key models.APIKeyModel
+		if err = scanAPIKey(rows, &key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+func (r *APIKeyRepositoryImpl) CreateAPIKey(ctx context.Context, ownerId int, key models.APIKeyModel, keyHash string) (models.APIKeyModel, error) {
+	query := `
+		INSERT INTO api_key (user_id, name, prefix, key_hash, scopes)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`
+	err := r.db.QueryRow(ctx, query, ownerId, key.Name, key.Prefix, keyHash, key.Scopes).Scan(&key.Id, &key.CreatedAt)
+	if err != nil {
+		return models.APIKeyModel{}, err
+	}
+	return key, nil
+}
+
+// RotateAPIKey replaces the secret of a key, the old secret stops working immediately
+func (r *APIKeyRepositoryImpl) RotateAPIKey(ctx context.Context, ownerId, id int, prefix, keyHash string) (models.APIKeyModel, error) {
+	query := `
+		UPDATE api_key
+		SET prefix = $1, key_hash = $2, last_used_at = NULL
+		WHERE id = $3 AND user_id = $4 AND revoked_at IS NULL
+		RETURNING ` + apiKeyColumns
+	var key models.APIKeyModel
+	if err := scanAPIKey(r.db.QueryRow(ctx, query, prefix, keyHash, id, ownerId), &key); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.APIKeyModel{}, ErrAPIKeyNotFound
+		}
+		return models.APIKeyModel{}, err
+	}
+	return key, nil
+}
+
+func (r *APIKeyRepositoryImpl) RevokeAPIKey(ctx context.Context, ownerId, id int) error {
+	cmdTag, err := r.db.Exec(ctx, "UPDATE api_key SET revoked_at = NOW() WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL", id, ownerId)
+	if err != nil {
+		return err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// UseAPIKey looks up a key that is not revoked by its hash, records its use and returns its owner and scopes
+func (r *APIKeyRepositoryImpl) UseAPIKey(ctx context.Context, keyHash string) (int, []string, error) {
+	query := `
+		UPDATE api_key
+		SET last_used_at = NOW()
+		WHERE key_hash = $1 AND revoked_at IS NULL
+		RETURNING user_id, scopes
+	`
+	var userId int
+	var scopes []string
+	if err := r.db.QueryRow(ctx, query, keyHash).Scan(&userId, &scopes); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, nil, ErrAPIKeyNotFound
+		}
+		return 0, nil, err
+	}
+	return userId, scopes, nil
+}
@@ -0,0 +1,146 @@
+package repos
+
+import (
+	"context"
+	"errors"
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestCreateAPIKey(t *testing.T) {
+	mockDB, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer mockDB.Close(context.Background())
+
+	r := NewAPIKeyRepo(NewDB(mockDB))
+	now := time.Now()
+	scopes := []string{"todos:read"}
+
+	rows := pgxmock.NewRows([]string{"id", "created_at"}).AddRow(1, now)
+	mockDB.ExpectQuery("INSERT INTO api_key \\(user_id, name, prefix, key_hash, scopes\\) VALUES \\(\\$1, \\$2, \\$3, \\$4, \\$5\\) RETURNING id, created_at").
+		WithArgs(testUserId, "ci bot", "tda_abcdef", "hash", scopes).
+		WillReturnRows(rows)
+
+	got, err := r.CreateAPIKey(context.Background(), testUserId, models.APIKeyModel{Name: "ci bot", Prefix: "tda_abcdef", Scopes: scopes}, "hash")
+	assert.NoError(t, err)
+	assert.Equal(t, models.APIKeyModel{Id: 1, Name: "ci bot", Prefix: "tda_abcdef", Scopes: scopes, CreatedAt: now}, got)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestRotateAPIKey(t *testing.T) {
+	mockDB, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer mockDB.Close(context.Background())
+
+	r := NewAPIKeyRepo(NewDB(mockDB))
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    models.APIKeyModel
+		wantErr error
+	}{
+		{
+			name: "Ok",
+			mock: func() {
+				rows := pgxmock.NewRows([]string{"id", "name", "prefix", "scopes", "last_used_at", "created_at"}).
+					AddRow(1, "ci bot", "tda_ghijkl", []string{"todos:read"}, noTime, now)
+				mockDB.ExpectQuery("UPDATE api_key SET prefix = \\$1, key_hash = \\$2, last_used_at = NULL WHERE id = \\$3 AND user_id = \\$4 AND revoked_at IS NULL RETURNING (.+)").
+					WithArgs("tda_ghijkl", "hash", 1, testUserId).
+					WillReturnRows(rows)
+			},
+			want: models.APIKeyModel{Id: 1, Name: "ci bot", Prefix: "tda_ghijkl", Scopes: []string{"todos:read"}, CreatedAt: now},
+		},
+		{
+			name: "Not Found",
+			mock: func() {
+				mockDB.ExpectQuery("UPDATE api_key").
+					WithArgs("tda_ghijkl", "hash", 1, testUserId).
+					WillReturnError(pgx.ErrNoRows)
+			},
+			wantErr: ErrAPIKeyNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			got, err := r.RotateAPIKey(context.Background(), testUserId, 1, "tda_ghijkl", "hash")
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+			assert.NoError(t, mockDB.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestUseAPIKey(t *testing.T) {
+	mockDB, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer mockDB.Close(context.Background())
+
+	r := NewAPIKeyRepo(NewDB(mockDB))
+
+	tests := []struct {
+		name       string
+		mock       func()
+		wantUserId int
+		wantScopes []string
+		wantErr    error
+	}{
+		{
+			name: "Ok",
+			mock: func() {
+				rows := pgxmock.NewRows([]string{"user_id", "scopes"}).AddRow(testUserId, []string{"todos:read"})
+				mockDB.ExpectQuery("UPDATE api_key SET last_used_at = NOW\\(\\) WHERE key_hash = \\$1 AND revoked_at IS NULL RETURNING user_id, scopes").
+					WithArgs("hash").
+					WillReturnRows(rows)
+			},
+			wantUserId: testUserId,
+			wantScopes: []string{"todos:read"},
+		},
+		{
+			name: "Revoked",
+			mock: func() {
+				mockDB.ExpectQuery("UPDATE api_key").WithArgs("hash").WillReturnError(pgx.ErrNoRows)
+			},
+			wantErr: ErrAPIKeyNotFound,
+		},
+		{
+			name: "Query Error",
+			mock: func() {
+				mockDB.ExpectQuery("UPDATE api_key").WithArgs("hash").WillReturnError(errors.New("query error"))
+			},
+			wantErr: errors.New("query error"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			userId, scopes, err := r.UseAPIKey(context.Background(), "hash")
+			if tt.wantErr != nil {
+				assert.EqualError(t, err, tt.wantErr.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.wantUserId, userId)
+				assert.Equal(t, tt.wantScopes, scopes)
+			}
+			assert.NoError(t, mockDB.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package repos
+
+import (
+	"context"
+)
+
+// pgxDB runs transactions on top of a pool, a connection or a transaction
+type pgxDB struct {
+	PgxIface
+}
+
+func NewDB(conn PgxIface) PgxConnIface {
+	return &pgxDB{PgxIface: conn}
+}
+
+func (d *pgxDB) WithTx(ctx context.Context, fn func(tx PgxConnIface) error) error {
+	// beginning within a transaction creates a savepoint
+	tx, err := d.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	// rolling back a committed transaction is a no-op
+	defer tx.Rollback(ctx)
+
+	if err = fn(&pgxDB{PgxIface: tx}); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,164 @@
+package repos
+
+import (
+	"context"
+	"errors"
+	"github.com/cherrycutter/todo_app/internal/tracing"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// sqlLiteral matches the placeholders, string literals and number literals of a statement
+var sqlLiteral = regexp.MustCompile(`\$\d+|'(?:[^']|'')*'|\b\d+(?:\.\d+)?\b`)
+
+// tracedDB makes a span of every statement run on a database handle
+type tracedDB struct {
+	db     PgxConnIface
+	tracer trace.Tracer
+	// tx is the span of the transaction the handle belongs to, its statements are children of it
+	tx trace.Span
+}
+
+// NewTracedDB wraps a database handle so that every statement, batch and transaction run on it is a span of tracer.
+// The spans carry the statement with its literals replaced, the arguments are never recorded
+func NewTracedDB(db PgxConnIface, tracer trace.Tracer) PgxConnIface {
+	return &tracedDB{db: db, tracer: tracer}
+}
+
+func (d *tracedDB) start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if d.tx != nil {
+		ctx = trace.ContextWithSpan(ctx, d.tx)
+	}
+	attrs = append(attrs, semconv.DBSystemPostgreSQL)
+	return d.tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+func (d *tracedDB) startStatement(ctx context.Context, sql string) (context.Context, trace.Span) {
+	operation := sqlOperation(sql)
+	return d.start(ctx, operation, semconv.DBOperationName(operation), semconv.DBQueryText(sanitizeQuery(sql)))
+}
+
+func (d *tracedDB) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	ctx, span := d.startStatement(ctx, sql)
+	rows, err := d.db.Query(ctx, sql, args...)
+	if err != nil {
+		tracing.End(span, err)
+		return rows, err
+	}
+	return &tracedRows{Rows: rows, span: span}, nil
+}
+
+func (d *tracedDB) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	ctx, span := d.startStatement(ctx, sql)
+	return &tracedRow{row: d.db.QueryRow(ctx, sql, args...), span: span}
+}
+
+func (d *tracedDB) Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error) {
+	ctx, span := d.startStatement(ctx, sql)
+	tag, err := d.db.Exec(ctx, sql, arguments...)
+	tracing.End(span, err)
+	return tag, err
+}
+
+// SendBatch makes one span of a batch, its statements are sent together
+func (d *tracedDB) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults {
+	queries := make([]string, len(b.QueuedQueries))
+	for i, query := range b.QueuedQueries {
+		queries[i] = sanitizeQuery(query.SQL)
+	}
+	ctx, span := d.start(ctx, "BATCH",
+		semconv.DBOperationName("BATCH"),
+		semconv.DBQueryText(strings.Join(queries, "; ")),
+		attribute.Int("db.operation.batch.size", b.Len()),
+	)
+	return &tracedBatchResults{BatchResults: d.db.SendBatch(ctx, b), span: span}
+}
+
+// WithTx makes a span of the transaction, the statements run by fn are its children
+func (d *tracedDB) WithTx(ctx context.Context, fn func(tx PgxConnIface) error) error {
+	ctx, span := d.start(ctx, "TRANSACTION")
+	err := d.db.WithTx(ctx, func(tx PgxConnIface) error {
+		return fn(&tracedDB{db: tx, tracer: d.tracer, tx: span})
+	})
+	tracing.End(span, err)
+	return err
+}
+
+// tracedRow ends the span of its statement once scanned, finding no row is not a failure
+type tracedRow struct {
+	row  pgx.Row
+	span trace.Span
+}
+
+func (r *tracedRow) Scan(dest ...interface{}) error {
+	err := r.row.Scan(dest...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		tracing.End(r.span, nil)
+	} else {
+		tracing.End(r.span, err)
+	}
+	return err
+}
+
+// tracedRows ends the span of its statement once all rows are read or the rows are closed
+type tracedRows struct {
+	pgx.Rows
+	span trace.Span
+	once sync.Once
+}
+
+func (r *tracedRows) Next() bool {
+	if r.Rows.Next() {
+		return true
+	}
+	r.end()
+	return false
+}
+
+func (r *tracedRows) Close() {
+	r.Rows.Close()
+	r.end()
+}
+
+func (r *tracedRows) end() {
+	r.once.Do(func() {
+		tracing.End(r.span, r.Rows.Err())
+	})
+}
+
+// tracedBatchResults ends the span of its batch once closed
+type tracedBatchResults struct {
+	pgx.BatchResults
+	span trace.Span
+}
+
+func (b *tracedBatchResults) Close() error {
+	err := b.BatchResults.Close()
+	tracing.End(b.span, err)
+	return err
+}
+
+// sqlOperation returns the first keyword of a statement, such as SELECT or WITH
+func sqlOperation(sql string) string {
+	operation, _, _ := strings.Cut(strings.TrimSpace(sql), " ")
+	operation, _, _ = strings.Cut(operation, "\n")
+	return strings.ToUpper(operation)
+}
+
+// sanitizeQuery collapses the whitespace of a statement and replaces its string and number literals with ?,
+// so that no value reaches the spans even when it is not passed as an argument
+func sanitizeQuery(sql string) string {
+	sql = sqlLiteral.ReplaceAllStringFunc(sql, func(literal string) string {
+		if strings.HasPrefix(literal, "$") {
+			return literal
+		}
+		return "?"
+	})
+	return strings.Join(strings.Fields(sql), " ")
+}
@@ -0,0 +1,93 @@
+package repos
+
+import (
+	"context"
+	"errors"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"testing"
+	"time"
+)
+
+func TestTracedDB(t *testing.T) {
+	mockDB, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer mockDB.Close(context.Background())
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	r := NewTodoRepo(NewTracedDB(NewDB(mockDB), provider.Tracer("test")), testMaxDepth)
+
+	ctx, request := provider.Tracer("test").Start(context.Background(), "request")
+	before := time.Now()
+	dbErr := errors.New("db error")
+
+	mockDB.ExpectBegin()
+	mockDB.ExpectExec("DELETE FROM todo WHERE deleted_at < \\$1").WithArgs(before).WillReturnResult(pgxmock.NewResult("DELETE", 4))
+	mockDB.ExpectExec("DELETE FROM todo WHERE deleted_at < \\$1").WithArgs(before).WillReturnError(dbErr)
+	mockDB.ExpectRollback()
+
+	err = r.InTx(ctx, func(repo TodoRepository) error {
+		if _, err := repo.PurgeTrash(ctx, before); err != nil {
+			return err
+		}
+		_, err := repo.PurgeTrash(ctx, before)
+		return err
+	})
+	assert.ErrorIs(t, err, dbErr)
+	request.End()
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+
+	spans := exporter.GetSpans()
+	if !assert.Len(t, spans, 4) {
+		return
+	}
+	purged, failed, tx := spans[0], spans[1], spans[2]
+	assert.Equal(t, "DELETE", purged.Name)
+	assert.Contains(t, purged.Attributes, semconv.DBQueryText("DELETE FROM todo WHERE deleted_at < $1"))
+	assert.Contains(t, purged.Attributes, semconv.DBSystemPostgreSQL)
+	assert.Equal(t, codes.Unset, purged.Status.Code)
+	assert.Equal(t, codes.Error, failed.Status.Code)
+
+	// the statements belong to the transaction, which belongs to the request
+	assert.Equal(t, "TRANSACTION", tx.Name)
+	assert.Equal(t, tx.SpanContext.SpanID(), purged.Parent.SpanID())
+	assert.Equal(t, tx.SpanContext.SpanID(), failed.Parent.SpanID())
+	assert.Equal(t, request.SpanContext().SpanID(), tx.Parent.SpanID())
+	assert.Equal(t, codes.Error, tx.Status.Code)
+}
+
+func TestSanitizeQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{
+			name:  "Placeholders",
+			query: "SELECT id FROM todo\n\t\tWHERE owner_id = $1 AND id = $2",
+			want:  "SELECT id FROM todo WHERE owner_id = $1 AND id = $2",
+		},
+		{
+			name:  "Literals",
+			query: "SELECT 'it''s', 1.5, todo2 FROM todo WHERE title = 'secret' LIMIT 10",
+			want:  "SELECT ?, ?, todo2 FROM todo WHERE title = ? LIMIT ?",
+		},
+		{
+			name:  "Casts",
+			query: "SELECT pg_notify('todo_events', concat($1::int, ':', id))",
+			want:  "SELECT pg_notify(?, concat($1::int, ?, id))",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, sanitizeQuery(tt.query))
+		})
+	}
+}
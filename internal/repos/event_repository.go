@@ -0,0 +1,141 @@
+package repos
+
+import (
+	"context"
+	"errors"
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/jackc/pgx/v5"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type EventRepositoryImpl struct {
+	db PgxConnIface
+}
+
+func NewEventRepo(db PgxConnIface) EventRepository {
+	return &EventRepositoryImpl{db: db}
+}
+
+var (
+	ErrEventNotFound = errors.New("event not found")
+)
+
+// todoEventColumns is the column list scanned by scanTodoEvent
+const todoEventColumns = "id, type, todo_id, todo, created_at"
+
+func scanTodoEvent(row pgx.Row, event *models.TodoEvent) error {
+	return row.Scan(&event.Id, &event.Type, &event.TodoId, &event.Todo, &event.CreatedAt)
+}
+
+// GetEvent returns an event of the owner
+func (r *EventRepositoryImpl) GetEvent(ctx context.Context, ownerId int, id int64) (models.TodoEvent, error) {
+	var event models.TodoEvent
+	query := "SELECT " + todoEventColumns + " FROM todo_event WHERE id = $1 AND owner_id = $2"
+	if err := scanTodoEvent(r.db.QueryRow(ctx, query, id, ownerId), &event); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.TodoEvent{}, ErrEventNotFound
+		}
+		return models.TodoEvent{}, err
+	}
+	return event, nil
+}
+
+// GetEventsAfter returns up to limit events of the owner following the event after, oldest first. found is false
+// when the event after is no longer in the log, events following it may have been purged as well. The ids of
+// the events of an owner are handed out in the order of the commits, see AddTodoEvents, so no event below after
+// can still be committed
+func (r *EventRepositoryImpl) GetEventsAfter(ctx context.Context, ownerId int, after int64, limit int) ([]models.TodoEvent, bool, error) {
+	// the event after is selected too, to tell whether it is still in the log
+	query := "SELECT " + todoEventColumns + " FROM todo_event WHERE owner_id = $1 AND id >= $2 ORDER BY id LIMIT $3"
+	rows, err := r.db.Query(ctx, query, ownerId, after, limit+1)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	events := []models.TodoEvent{}
+	found := false
+	for rows.Next() {
+		var event models.TodoEvent
+		if err = scanTodoEvent(rows, &event); err != nil {
+			return nil, false, err
+		}
+		if event.Id == after {
+			found = true
+			continue
+		}
+		events = append(events, event)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, false, err
+	}
+	if len(events) > limit {
+		events = events[:limit]
+	}
+	return events, found, nil
+}
+
+// PurgeEvents deletes the events created before a point in time and returns how many were deleted
+func (r *EventRepositoryImpl) PurgeEvents(ctx context.Context, before time.Time) (int64, error) {
+	tag, err := r.db.Exec(ctx, "DELETE FROM todo_event WHERE created_at < $1", before)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+type EventListenerImpl struct {
+	// connect opens the connection held by a listener, it cannot be shared while it waits for notifications
+	connect func(ctx context.Context) (*pgx.Conn, error)
+}
+
+func NewEventListener(connect func(ctx context.Context) (*pgx.Conn, error)) EventListener {
+	return &EventListenerImpl{connect: connect}
+}
+
+// Listen passes the owner and id of every new todo event to notify until ctx is done, which returns nil.
+// Notifications sent while the listener is not connected are lost, an error means the connection failed
+func (l *EventListenerImpl) Listen(ctx context.Context, notify func(ownerId int, eventId int64)) error {
+	conn, err := l.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err = conn.Exec(ctx, "LISTEN "+TodoEventsChannel); err != nil {
+		return err
+	}
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		ownerId, eventId, ok := parseEventNotification(notification.Payload)
+		if ok {
+			notify(ownerId, eventId)
+		}
+	}
+}
+
+// parseEventNotification reads the "<owner id>:<event id>" payload of a todo_events notification
+func parseEventNotification(payload string) (int, int64, bool) {
+	owner, id, found := strings.Cut(payload, ":")
+	if !found {
+		return 0, 0, false
+	}
+	ownerId, err := strconv.Atoi(owner)
+	if err != nil {
+		return 0, 0, false
+	}
+	eventId, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return ownerId, eventId, true
+}
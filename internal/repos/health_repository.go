@@ -0,0 +1,43 @@
+package repos
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+)
+
+// SchemaVersion is the version of the last migration in schema, the code expects the database to be at least
+// at this version. It has to be bumped with every new migration, TestSchemaVersion fails until it is
+const SchemaVersion = 18
+
+type HealthRepositoryImpl struct {
+	db PgxConnIface
+}
+
+func NewHealthRepo(db PgxConnIface) HealthRepository {
+	return &HealthRepositoryImpl{db: db}
+}
+
+var (
+	ErrNoMigrations = errors.New("no migration has been applied")
+)
+
+// Ping makes a round trip to the database
+func (r *HealthRepositoryImpl) Ping(ctx context.Context) error {
+	_, err := r.db.Exec(ctx, "SELECT 1")
+	return err
+}
+
+// GetSchemaVersion returns the version of the last migration applied to the database, dirty means it failed halfway
+func (r *HealthRepositoryImpl) GetSchemaVersion(ctx context.Context) (int64, bool, error) {
+	var version int64
+	var dirty bool
+	err := r.db.QueryRow(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, false, ErrNoMigrations
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return version, dirty, nil
+}
@@ -0,0 +1,89 @@
+package repos
+
+import (
+	"context"
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestSchemaVersion(t *testing.T) {
+	entries, err := os.ReadDir("../../schema")
+	if err != nil {
+		t.Fatalf("failed to read schema: %v", err)
+	}
+	var newest int64
+	for _, entry := range entries {
+		number, _, found := strings.Cut(entry.Name(), "_")
+		if !found || !strings.HasSuffix(entry.Name(), ".up.sql") {
+			continue
+		}
+		version, err := strconv.ParseInt(number, 10, 64)
+		if err != nil {
+			t.Fatalf("migration %s is not numbered: %v", entry.Name(), err)
+		}
+		newest = max(newest, version)
+	}
+	assert.EqualValues(t, newest, SchemaVersion, "SchemaVersion must be the number of the newest migration")
+}
+
+func TestGetSchemaVersion(t *testing.T) {
+	mockDB, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer mockDB.Close(context.Background())
+
+	r := NewHealthRepo(NewDB(mockDB))
+	query := "SELECT version, dirty FROM schema_migrations LIMIT 1"
+
+	tests := []struct {
+		name        string
+		mock        func()
+		wantVersion int64
+		wantDirty   bool
+		wantErr     error
+	}{
+		{
+			name: "Ok",
+			mock: func() {
+				mockDB.ExpectQuery(query).WillReturnRows(pgxmock.NewRows([]string{"version", "dirty"}).AddRow(int64(17), false))
+			},
+			wantVersion: 17,
+		},
+		{
+			name: "Dirty",
+			mock: func() {
+				mockDB.ExpectQuery(query).WillReturnRows(pgxmock.NewRows([]string{"version", "dirty"}).AddRow(int64(16), true))
+			},
+			wantVersion: 16,
+			wantDirty:   true,
+		},
+		{
+			name: "No Migrations",
+			mock: func() {
+				mockDB.ExpectQuery(query).WillReturnError(pgx.ErrNoRows)
+			},
+			wantErr: ErrNoMigrations,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			version, dirty, err := r.GetSchemaVersion(context.Background())
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.wantVersion, version)
+				assert.Equal(t, tt.wantDirty, dirty)
+			}
+			assert.NoError(t, mockDB.ExpectationsWereMet())
+		})
+	}
+}
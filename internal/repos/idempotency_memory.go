@@ -0,0 +1,76 @@
+package repos
+
+import (
+	"context"
+	"github.com/cherrycutter/todo_app/internal/models"
+	"sync"
+	"time"
+)
+
+// IdempotencyMemoryRepositoryImpl keeps idempotency keys in memory, they are lost on restart and not shared
+// between instances of the API
+type IdempotencyMemoryRepositoryImpl struct {
+	mu   sync.Mutex
+	keys map[idempotencyMemoryKey]idempotencyMemoryEntry
+}
+
+type idempotencyMemoryKey struct {
+	userId int
+	key    string
+}
+
+type idempotencyMemoryEntry struct {
+	record    models.IdempotencyRecord
+	expiresAt time.Time
+}
+
+func NewIdempotencyMemoryRepo() IdempotencyRepository {
+	return &IdempotencyMemoryRepositoryImpl{keys: make(map[idempotencyMemoryKey]idempotencyMemoryEntry)}
+}
+
+func (r *IdempotencyMemoryRepositoryImpl) ReserveIdempotencyKey(_ context.Context, userId int, key, fingerprint string, expiresAt time.Time) (models.IdempotencyRecord, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	k := idempotencyMemoryKey{userId: userId, key: key}
+	if entry, ok := r.keys[k]; ok && entry.expiresAt.After(time.Now()) {
+		return entry.record, false, nil
+	}
+	record := models.IdempotencyRecord{Fingerprint: fingerprint}
+	r.keys[k] = idempotencyMemoryEntry{record: record, expiresAt: expiresAt}
+	return record, true, nil
+}
+
+func (r *IdempotencyMemoryRepositoryImpl) SaveIdempotentResponse(_ context.Context, userId int, key string, response models.IdempotentResponse) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	k := idempotencyMemoryKey{userId: userId, key: key}
+	if entry, ok := r.keys[k]; ok {
+		entry.record.Response = &response
+		r.keys[k] = entry
+	}
+	return nil
+}
+
+func (r *IdempotencyMemoryRepositoryImpl) DeleteIdempotencyKey(_ context.Context, userId int, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.keys, idempotencyMemoryKey{userId: userId, key: key})
+	return nil
+}
+
+func (r *IdempotencyMemoryRepositoryImpl) PurgeIdempotencyKeys(_ context.Context, now time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var purged int64
+	for k, entry := range r.keys {
+		if !entry.expiresAt.After(now) {
+			delete(r.keys, k)
+			purged++
+		}
+	}
+	return purged, nil
+}
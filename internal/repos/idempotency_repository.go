@@ -0,0 +1,89 @@
+package repos
+
+import (
+	"context"
+	"errors"
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/jackc/pgx/v5"
+	"time"
+)
+
+type IdempotencyRepositoryImpl struct {
+	db PgxConnIface
+}
+
+func NewIdempotencyRepo(db PgxConnIface) IdempotencyRepository {
+	return &IdempotencyRepositoryImpl{db: db}
+}
+
+// ReserveIdempotencyKey stores the fingerprint of a request under a key of the user unless the key is already taken
+// by a request that has not expired, which is returned instead. An expired key is taken over in the same statement.
+// A key still being reserved by another request gives a record without fingerprint
+func (r *IdempotencyRepositoryImpl) ReserveIdempotencyKey(ctx context.Context, userId int, key, fingerprint string, expiresAt time.Time) (models.IdempotencyRecord, bool, error) {
+	query := `
+		WITH reserved AS (
+			INSERT INTO idempotency_key (user_id, key, fingerprint, expires_at)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (user_id, key) DO UPDATE
+			SET fingerprint = EXCLUDED.fingerprint, status_code = NULL, headers = NULL, body = NULL,
+				created_at = NOW(), expires_at = EXCLUDED.expires_at
+			WHERE idempotency_key.expires_at <= NOW()
+			RETURNING fingerprint
+		)
+		SELECT true, fingerprint, NULL::INTEGER, NULL::JSONB, NULL::BYTEA FROM reserved
+		UNION ALL
+		SELECT false, fingerprint, status_code, headers, body FROM idempotency_key
+		WHERE user_id = $1 AND key = $2 AND NOT EXISTS (SELECT 1 FROM reserved)
+	`
+	// a key reserved by a concurrent request that is not committed yet is neither inserted nor selected,
+	// the statement runs again with a new snapshot once the insert it waited for went through
+	for attempt := 0; attempt < idempotencyReserveAttempts; attempt++ {
+		record, reserved, err := scanIdempotencyRecord(r.db.QueryRow(ctx, query, userId, key, fingerprint, expiresAt))
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return record, reserved, err
+		}
+	}
+	// the fingerprint of the other request is unknown, so the caller cannot tell the key was reused
+	return models.IdempotencyRecord{}, false, nil
+}
+
+// idempotencyReserveAttempts is how many times a key is reserved before it is given up as in progress
+const idempotencyReserveAttempts = 2
+
+// scanIdempotencyRecord scans the row returned by the reservation of a key
+func scanIdempotencyRecord(row pgx.Row) (models.IdempotencyRecord, bool, error) {
+	var reserved bool
+	var record models.IdempotencyRecord
+	var statusCode *int
+	var headers map[string]string
+	var body []byte
+	if err := row.Scan(&reserved, &record.Fingerprint, &statusCode, &headers, &body); err != nil {
+		return models.IdempotencyRecord{}, false, err
+	}
+	if statusCode != nil {
+		record.Response = &models.IdempotentResponse{StatusCode: *statusCode, Headers: headers, Body: body}
+	}
+	return record, reserved, nil
+}
+
+// SaveIdempotentResponse stores the response of the request reserved under a key
+func (r *IdempotencyRepositoryImpl) SaveIdempotentResponse(ctx context.Context, userId int, key string, response models.IdempotentResponse) error {
+	query := "UPDATE idempotency_key SET status_code = $1, headers = $2, body = $3 WHERE user_id = $4 AND key = $5"
+	_, err := r.db.Exec(ctx, query, response.StatusCode, response.Headers, response.Body, userId, key)
+	return err
+}
+
+// DeleteIdempotencyKey releases a key, so that the next request sent with it runs again
+func (r *IdempotencyRepositoryImpl) DeleteIdempotencyKey(ctx context.Context, userId int, key string) error {
+	_, err := r.db.Exec(ctx, "DELETE FROM idempotency_key WHERE user_id = $1 AND key = $2", userId, key)
+	return err
+}
+
+// PurgeIdempotencyKeys deletes the keys of every user that expired before now
+func (r *IdempotencyRepositoryImpl) PurgeIdempotencyKeys(ctx context.Context, now time.Time) (int64, error) {
+	cmdTag, err := r.db.Exec(ctx, "DELETE FROM idempotency_key WHERE expires_at <= $1", now)
+	if err != nil {
+		return 0, err
+	}
+	return cmdTag.RowsAffected(), nil
+}
@@ -0,0 +1,161 @@
+package repos
+
+import (
+	"context"
+	"errors"
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestReserveIdempotencyKey(t *testing.T) {
+	mockDB, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer mockDB.Close(context.Background())
+
+	r := NewIdempotencyRepo(NewDB(mockDB))
+
+	expiresAt := time.Now().Add(time.Hour)
+	columns := []string{"reserved", "fingerprint", "status_code", "headers", "body"}
+	statusCode := 201
+	headers := map[string]string{"Content-Type": "application/json; charset=utf-8"}
+
+	tests := []struct {
+		name         string
+		mock         func()
+		want         models.IdempotencyRecord
+		wantReserved bool
+		wantErr      error
+	}{
+		{
+			name: "Reserved",
+			mock: func() {
+				rows := pgxmock.NewRows(columns).AddRow(true, "fp", nil, nil, nil)
+				mockDB.ExpectQuery("WITH reserved AS \\( INSERT INTO idempotency_key (.+) ON CONFLICT \\(user_id, key\\) DO UPDATE (.+) WHERE idempotency_key.expires_at <= NOW\\(\\)").
+					WithArgs(testUserId, "key", "fp", expiresAt).
+					WillReturnRows(rows)
+			},
+			want:         models.IdempotencyRecord{Fingerprint: "fp"},
+			wantReserved: true,
+		},
+		{
+			name: "Completed",
+			mock: func() {
+				rows := pgxmock.NewRows(columns).AddRow(false, "fp", &statusCode, headers, []byte(`{"id":1}`))
+				mockDB.ExpectQuery("WITH reserved AS").
+					WithArgs(testUserId, "key", "fp", expiresAt).
+					WillReturnRows(rows)
+			},
+			want: models.IdempotencyRecord{
+				Fingerprint: "fp",
+				Response:    &models.IdempotentResponse{StatusCode: 201, Headers: headers, Body: []byte(`{"id":1}`)},
+			},
+		},
+		{
+			name: "Reserved Concurrently",
+			mock: func() {
+				mockDB.ExpectQuery("WITH reserved AS").
+					WithArgs(testUserId, "key", "fp", expiresAt).
+					WillReturnError(pgx.ErrNoRows)
+				rows := pgxmock.NewRows(columns).AddRow(false, "other", nil, nil, nil)
+				mockDB.ExpectQuery("WITH reserved AS").
+					WithArgs(testUserId, "key", "fp", expiresAt).
+					WillReturnRows(rows)
+			},
+			want: models.IdempotencyRecord{Fingerprint: "other"},
+		},
+		{
+			name: "Still Reserved Concurrently",
+			mock: func() {
+				for i := 0; i < idempotencyReserveAttempts; i++ {
+					mockDB.ExpectQuery("WITH reserved AS").
+						WithArgs(testUserId, "key", "fp", expiresAt).
+						WillReturnError(pgx.ErrNoRows)
+				}
+			},
+			want: models.IdempotencyRecord{},
+		},
+		{
+			name: "Query Error",
+			mock: func() {
+				mockDB.ExpectQuery("WITH reserved AS").
+					WithArgs(testUserId, "key", "fp", expiresAt).
+					WillReturnError(errors.New("query error"))
+			},
+			wantErr: errors.New("query error"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			got, reserved, err := r.ReserveIdempotencyKey(context.Background(), testUserId, "key", "fp", expiresAt)
+			if tt.wantErr != nil {
+				assert.EqualError(t, err, tt.wantErr.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+				assert.Equal(t, tt.wantReserved, reserved)
+			}
+			assert.NoError(t, mockDB.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestSaveIdempotentResponse(t *testing.T) {
+	mockDB, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer mockDB.Close(context.Background())
+
+	r := NewIdempotencyRepo(NewDB(mockDB))
+
+	response := models.IdempotentResponse{StatusCode: 200, Headers: map[string]string{"ETag": `"2"`}, Body: []byte("{}")}
+	mockDB.ExpectExec("UPDATE idempotency_key SET status_code = \\$1, headers = \\$2, body = \\$3 WHERE user_id = \\$4 AND key = \\$5").
+		WithArgs(200, response.Headers, response.Body, testUserId, "key").
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+	assert.NoError(t, r.SaveIdempotentResponse(context.Background(), testUserId, "key", response))
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestIdempotencyMemoryRepo(t *testing.T) {
+	r := NewIdempotencyMemoryRepo()
+	ctx := context.Background()
+	expiresAt := time.Now().Add(time.Hour)
+
+	record, reserved, err := r.ReserveIdempotencyKey(ctx, testUserId, "key", "fp", expiresAt)
+	assert.NoError(t, err)
+	assert.True(t, reserved)
+	assert.Equal(t, models.IdempotencyRecord{Fingerprint: "fp"}, record)
+
+	// another user has its own keys
+	_, reserved, err = r.ReserveIdempotencyKey(ctx, testUserId+1, "key", "other", expiresAt)
+	assert.NoError(t, err)
+	assert.True(t, reserved)
+
+	record, reserved, err = r.ReserveIdempotencyKey(ctx, testUserId, "key", "other", expiresAt)
+	assert.NoError(t, err)
+	assert.False(t, reserved)
+	assert.Equal(t, models.IdempotencyRecord{Fingerprint: "fp"}, record)
+
+	response := models.IdempotentResponse{StatusCode: 201, Body: []byte("{}")}
+	assert.NoError(t, r.SaveIdempotentResponse(ctx, testUserId, "key", response))
+	record, reserved, err = r.ReserveIdempotencyKey(ctx, testUserId, "key", "fp", expiresAt)
+	assert.NoError(t, err)
+	assert.False(t, reserved)
+	assert.Equal(t, &response, record.Response)
+
+	purged, err := r.PurgeIdempotencyKeys(ctx, expiresAt)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, purged)
+	_, reserved, err = r.ReserveIdempotencyKey(ctx, testUserId, "key", "fp", expiresAt)
+	assert.NoError(t, err)
+	assert.True(t, reserved)
+}
@@ -0,0 +1,136 @@
+package repos
+
+import (
+	"context"
+	"errors"
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+type ProjectRepositoryImpl struct {
+	db PgxConnIface
+}
+
+func NewProjectRepo(db PgxConnIface) ProjectRepository {
+	return &ProjectRepositoryImpl{db: db}
+}
+
+var (
+	ErrProjectNotFound = errors.New("project not found")
+)
+
+// projectColumns is the column list scanned by scanProject
+const projectColumns = "id, name, COALESCE(description, ''), COALESCE(color, ''), archived, inbox, created_at"
+
+// scanProject scans a row selected with projectColumns
+func scanProject(row pgx.Row, project *models.ProjectModel) error {
+	return row.Scan(&project.Id, &project.Name, &project.Description, &project.Color, &project.Archived, &project.Inbox, &project.CreatedAt)
+}
+
+func (r *ProjectRepositoryImpl) GetAllProjects(ctx context.Context, ownerId int, includeArchived bool) ([]models.ProjectModel, error) {
+	query := "SELECT " + projectColumns + " FROM project WHERE owner_id = $1"
+	if !includeArchived {
+		query += " AND NOT archived"
+	}
+	rows, err := r.db.Query(ctx, query+" ORDER BY inbox DESC, name, id", ownerId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	projects := []models.ProjectModel{}
+	for rows.Next() {
+		var project models.ProjectModel
+		if err = scanProject(rows, &project); err != nil {
+			return nil, err
+		}
+		projects = append(projects, project)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return projects, nil
+}
+
+func (r *ProjectRepositoryImpl) GetProjectById(ctx context.Context, ownerId, id int) (models.ProjectModel, error) {
+	var project models.ProjectModel
+	err := scanProject(r.db.QueryRow(ctx, "SELECT "+projectColumns+" FROM project WHERE id = $1 AND owner_id = $2", id, ownerId), &project)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.ProjectModel{}, ErrProjectNotFound
+		}
+		return models.ProjectModel{}, err
+	}
+	return project, nil
+}
+
+func (r *ProjectRepositoryImpl) CreateProject(ctx context.Context, ownerId int, project models.ProjectModel) (models.ProjectModel, error) {
+	query := `
+		INSERT INTO project (name, description, color, archived, owner_id)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, inbox, created_at
+	`
+	err := r.db.QueryRow(ctx, query, project.Name, project.Description, project.Color, project.Archived, ownerId).
+		Scan(&project.Id, &project.Inbox, &project.CreatedAt)
+	if err != nil {
+		return models.ProjectModel{}, err
+	}
+	return project, nil
+}
+
+func (r *ProjectRepositoryImpl) UpdateProject(ctx context.Context, ownerId, id int, project models.ProjectModel) (models.ProjectModel, error) {
+	query := `
+		UPDATE project
+		SET name = $1, description = $2, color = $3, archived = $4
+		WHERE id = $5 AND owner_id = $6
+		RETURNING ` + projectColumns
+	var updatedProject models.ProjectModel
+	err := scanProject(r.db.QueryRow(ctx, query, project.Name, project.Description, project.Color, project.Archived, id, ownerId), &updatedProject)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.ProjectModel{}, ErrProjectNotFound
+		}
+		return models.ProjectModel{}, err
+	}
+	return updatedProject, nil
+}
+
+// DeleteProjectById deletes a project after moving its todos to the owner's inbox, both happen in a single statement
+// and the moved todos get a new version.
+// In cascade mode the todos are moved to the trash first together with their subtasks, wherever those live, and
+// can be restored to the inbox until the trash is purged. The inbox itself is never deleted
+func (r *ProjectRepositoryImpl) DeleteProjectById(ctx context.Context, ownerId, id int, mode models.ProjectDeleteMode) error {
+	var todos string
+	switch mode {
+	case models.ProjectDeleteCascade:
+		todos = `
+			WITH RECURSIVE trashed AS (
+				SELECT id FROM todo WHERE project_id = $1 AND owner_id = $2 AND deleted_at IS NULL
+				UNION ALL
+				SELECT t.id FROM todo t JOIN trashed s ON t.parent_id = s.id WHERE t.deleted_at IS NULL
+			), todos AS (
+				UPDATE todo SET
+					deleted_at = CASE WHEN id IN (SELECT id FROM trashed) THEN NOW() ELSE deleted_at END,
+					project_id = CASE WHEN project_id = $1 THEN (SELECT id FROM project WHERE inbox AND owner_id = $2) ELSE project_id END,
+					version = version + 1
+				WHERE (project_id = $1 OR id IN (SELECT id FROM trashed))`
+	case models.ProjectDeleteMoveToInbox:
+		todos = "WITH todos AS (UPDATE todo SET project_id = (SELECT id FROM project WHERE inbox AND owner_id = $2), version = version + 1 WHERE project_id = $1"
+	default:
+		return &FilterError{Param: "mode", Value: string(mode)}
+	}
+	query := todos + ` AND EXISTS (SELECT 1 FROM project WHERE id = $1 AND owner_id = $2 AND NOT inbox))
+		DELETE FROM project
+		WHERE id = $1 AND owner_id = $2 AND NOT inbox
+	`
+	cmdTag, err := r.db.Exec(ctx, query, id, ownerId)
+	if err != nil {
+		return err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return ErrProjectNotFound
+	}
+	return nil
+}
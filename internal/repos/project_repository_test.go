@@ -0,0 +1,137 @@
+package repos
+
+import (
+	"context"
+	"errors"
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestGetProjectById(t *testing.T) {
+	mockDB, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer mockDB.Close(context.Background())
+
+	r := NewProjectRepo(NewDB(mockDB))
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    models.ProjectModel
+		wantErr error
+	}{
+		{
+			name: "Ok",
+			mock: func() {
+				rows := pgxmock.NewRows([]string{"id", "name", "description", "color", "archived", "inbox", "created_at"}).
+					AddRow(1, "Inbox", "", "", false, true, now)
+				mockDB.ExpectQuery("SELECT (.+) FROM project WHERE id = \\$1 AND owner_id = \\$2").WithArgs(1, testUserId).WillReturnRows(rows)
+			},
+			want: models.ProjectModel{Id: 1, Name: "Inbox", Inbox: true, CreatedAt: now},
+		},
+		{
+			name: "Not Found",
+			mock: func() {
+				rows := pgxmock.NewRows([]string{"id", "name", "description", "color", "archived", "inbox", "created_at"})
+				mockDB.ExpectQuery("SELECT (.+) FROM project WHERE id = \\$1 AND owner_id = \\$2").WithArgs(1, testUserId).WillReturnRows(rows)
+			},
+			wantErr: ErrProjectNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			got, err := r.GetProjectById(context.Background(), testUserId, 1)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+			assert.NoError(t, mockDB.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestDeleteProjectById(t *testing.T) {
+	mockDB, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer mockDB.Close(context.Background())
+
+	r := NewProjectRepo(NewDB(mockDB))
+
+	tests := []struct {
+		name    string
+		mock    func()
+		mode    models.ProjectDeleteMode
+		wantErr error
+	}{
+		{
+			name: "Ok_Cascade",
+			mock: func() {
+				mockDB.ExpectExec("WITH RECURSIVE trashed AS (.+) UPDATE todo SET deleted_at = CASE WHEN id IN \\(SELECT id FROM trashed\\) THEN NOW\\(\\) (.+)\\) DELETE FROM project WHERE id = \\$1 AND owner_id = \\$2 AND NOT inbox").
+					WithArgs(2, testUserId).
+					WillReturnResult(pgxmock.NewResult("DELETE", 1))
+			},
+			mode: models.ProjectDeleteCascade,
+		},
+		{
+			name: "Ok_MoveToInbox",
+			mock: func() {
+				mockDB.ExpectExec("WITH todos AS \\(UPDATE todo SET project_id = \\(SELECT id FROM project WHERE inbox AND owner_id = \\$2\\), version = version \\+ 1 WHERE project_id = \\$1 (.+)\\) DELETE FROM project WHERE id = \\$1 AND owner_id = \\$2 AND NOT inbox").
+					WithArgs(2, testUserId).
+					WillReturnResult(pgxmock.NewResult("DELETE", 1))
+			},
+			mode: models.ProjectDeleteMoveToInbox,
+		},
+		{
+			name: "Not Found",
+			mock: func() {
+				mockDB.ExpectExec("DELETE FROM project").
+					WithArgs(2, testUserId).
+					WillReturnResult(pgxmock.NewResult("DELETE", 0))
+			},
+			mode:    models.ProjectDeleteCascade,
+			wantErr: ErrProjectNotFound,
+		},
+		{
+			name: "Unknown Mode",
+			mock: func() {
+			},
+			mode:    "archive",
+			wantErr: &FilterError{Param: "mode", Value: "archive"},
+		},
+		{
+			name: "Query Error",
+			mock: func() {
+				mockDB.ExpectExec("DELETE FROM project").
+					WithArgs(2, testUserId).
+					WillReturnError(errors.New("query error"))
+			},
+			mode:    models.ProjectDeleteCascade,
+			wantErr: errors.New("query error"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			err := r.DeleteProjectById(context.Background(), testUserId, 2, tt.mode)
+			if tt.wantErr != nil {
+				assert.EqualError(t, err, tt.wantErr.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mockDB.ExpectationsWereMet())
+		})
+	}
+}
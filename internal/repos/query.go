@@ -0,0 +1,31 @@
+package repos
+
+import (
+	"fmt"
+	"strings"
+)
+
+// queryBuilder collects WHERE conditions together with their positional arguments
+type queryBuilder struct {
+	conds []string
+	args  []interface{}
+}
+
+// arg registers a new argument and returns its placeholder
+func (b *queryBuilder) arg(value interface{}) string {
+	b.args = append(b.args, value)
+	return fmt.Sprintf("$%d", len(b.args))
+}
+
+// where adds a condition, conditions are joined with AND
+func (b *queryBuilder) where(cond string) {
+	b.conds = append(b.conds, cond)
+}
+
+// whereClause returns the WHERE clause of the collected conditions or an empty string if there are none
+func (b *queryBuilder) whereClause() string {
+	if len(b.conds) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(b.conds, " AND ")
+}
@@ -0,0 +1,55 @@
+package repos
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"time"
+)
+
+type RefreshTokenRepositoryImpl struct {
+	db PgxConnIface
+}
+
+func NewRefreshTokenRepo(db PgxConnIface) RefreshTokenRepository {
+	return &RefreshTokenRepositoryImpl{db: db}
+}
+
+var (
+	ErrRefreshTokenNotFound = errors.New("refresh token not found")
+)
+
+func (r *RefreshTokenRepositoryImpl) CreateRefreshToken(ctx context.Context, userId int, tokenHash string, expiresAt time.Time) error {
+	_, err := r.db.Exec(ctx, "INSERT INTO refresh_token (user_id, token_hash, expires_at) VALUES ($1, $2, $3)", userId, tokenHash, expiresAt)
+	return err
+}
+
+// RotateRefreshToken revokes a valid refresh token and stores its replacement for the same user in a single statement,
+// ErrRefreshTokenNotFound is returned when the token is unknown, expired or already revoked
+func (r *RefreshTokenRepositoryImpl) RotateRefreshToken(ctx context.Context, tokenHash, newTokenHash string, expiresAt time.Time) (int, error) {
+	query := `
+		WITH revoked AS (
+			UPDATE refresh_token
+			SET revoked_at = NOW()
+			WHERE token_hash = $1 AND revoked_at IS NULL AND expires_at > NOW()
+			RETURNING user_id
+		)
+		INSERT INTO refresh_token (user_id, token_hash, expires_at)
+		SELECT user_id, $2, $3 FROM revoked
+		RETURNING user_id
+	`
+	var userId int
+	if err := r.db.QueryRow(ctx, query, tokenHash, newTokenHash, expiresAt).Scan(&userId); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, ErrRefreshTokenNotFound
+		}
+		return 0, err
+	}
+	return userId, nil
+}
+
+// RevokeRefreshToken revokes a refresh token, revoking a token twice is a no-op
+func (r *RefreshTokenRepositoryImpl) RevokeRefreshToken(ctx context.Context, tokenHash string) error {
+	_, err := r.db.Exec(ctx, "UPDATE refresh_token SET revoked_at = NOW() WHERE token_hash = $1 AND revoked_at IS NULL", tokenHash)
+	return err
+}
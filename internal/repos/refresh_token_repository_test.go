@@ -0,0 +1,89 @@
+package repos
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestRotateRefreshToken(t *testing.T) {
+	mockDB, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer mockDB.Close(context.Background())
+
+	r := NewRefreshTokenRepo(NewDB(mockDB))
+	expiresAt := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    int
+		wantErr error
+	}{
+		{
+			name: "Ok",
+			mock: func() {
+				rows := pgxmock.NewRows([]string{"user_id"}).AddRow(testUserId)
+				mockDB.ExpectQuery("WITH revoked AS \\( UPDATE refresh_token SET revoked_at = NOW\\(\\) WHERE token_hash = \\$1 AND revoked_at IS NULL AND expires_at > NOW\\(\\) RETURNING user_id \\) INSERT INTO refresh_token \\(user_id, token_hash, expires_at\\) SELECT user_id, \\$2, \\$3 FROM revoked").
+					WithArgs("old", "new", expiresAt).
+					WillReturnRows(rows)
+			},
+			want: testUserId,
+		},
+		{
+			name: "Revoked Or Expired",
+			mock: func() {
+				mockDB.ExpectQuery("WITH revoked AS").
+					WithArgs("old", "new", expiresAt).
+					WillReturnError(pgx.ErrNoRows)
+			},
+			wantErr: ErrRefreshTokenNotFound,
+		},
+		{
+			name: "Query Error",
+			mock: func() {
+				mockDB.ExpectQuery("WITH revoked AS").
+					WithArgs("old", "new", expiresAt).
+					WillReturnError(errors.New("query error"))
+			},
+			wantErr: errors.New("query error"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			got, err := r.RotateRefreshToken(context.Background(), "old", "new", expiresAt)
+			if tt.wantErr != nil {
+				assert.EqualError(t, err, tt.wantErr.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+			assert.NoError(t, mockDB.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRevokeRefreshToken(t *testing.T) {
+	mockDB, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer mockDB.Close(context.Background())
+
+	r := NewRefreshTokenRepo(NewDB(mockDB))
+
+	mockDB.ExpectExec("UPDATE refresh_token SET revoked_at = NOW\\(\\) WHERE token_hash = \\$1 AND revoked_at IS NULL").
+		WithArgs("hash").
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+	assert.NoError(t, r.RevokeRefreshToken(context.Background(), "hash"))
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
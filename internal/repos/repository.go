@@ -5,22 +5,67 @@ import (
 	"errors"
 	"github.com/cherrycutter/todo_app/internal/models"
 	"github.com/jackc/pgx/v5"
+	"strings"
 )
 
 type TodoRepositoryImpl struct {
 	db PgxConnIface
+	// maxDepth is the number of levels todos can be nested
+	maxDepth int
 }
 
-func NewTodoRepo(db PgxConnIface) TodoRepository {
-	return &TodoRepositoryImpl{db: db}
+func NewTodoRepo(db PgxConnIface, maxDepth int) TodoRepository {
+	return &TodoRepositoryImpl{db: db, maxDepth: maxDepth}
 }
 
 var (
-	ErrTodoNotFound = errors.New("todo not found")
+	ErrTodoNotFound       = errors.New("todo not found")
+	ErrParentNotFound     = errors.New("parent todo not found")
+	ErrTodoCycle          = errors.New("a todo cannot be nested under itself or one of its subtasks")
+	ErrTodoDepthExceeded  = errors.New("todos cannot be nested this deep")
+	ErrSubtaskOrderFailed = errors.New("ids must list every subtask of the todo exactly once")
+	ErrParentTrashed      = errors.New("the parent todo is in the trash, restore it first")
+	ErrVersionMismatch    = errors.New("todo was modified by another request")
 )
 
-func (r *TodoRepositoryImpl) GetAllTodos(ctx context.Context) ([]models.TodoModel, error) {
-	rows, err := r.db.Query(ctx, "SELECT * FROM todo")
+// todoColumns is the column list scanned by scanTodo, the last two columns count the done and all direct subtasks
+// that are not in the trash
+const todoColumns = "id, title, COALESCE(description, ''), completed, created_at, due_at, remind_at, project_id, parent_id, position, " +
+	"recurrence, series_id, deleted_at, version, " +
+	"(SELECT COUNT(*) FILTER (WHERE s.completed) FROM todo s WHERE s.parent_id = todo.id AND s.deleted_at IS NULL), " +
+	"(SELECT COUNT(*) FROM todo s WHERE s.parent_id = todo.id AND s.deleted_at IS NULL)"
+
+// scanTodo scans a row selected with todoColumns
+func scanTodo(row pgx.Row, todo *models.TodoModel, extra ...interface{}) error {
+	var progress models.TodoProgress
+	dest := []interface{}{
+		&todo.Id, &todo.Title, &todo.Description, &todo.Completed, &todo.CreatedAt, &todo.DueAt, &todo.RemindAt, &todo.ProjectId,
+		&todo.ParentId, &todo.Position, &todo.Recurrence, &todo.SeriesId, &todo.DeletedAt, &todo.Version, &progress.Done, &progress.Total,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return err
+	}
+	todo.Progress = nil
+	if progress.Total > 0 {
+		todo.Progress = &progress
+	}
+	return nil
+}
+
+func (r *TodoRepositoryImpl) GetAllTodos(ctx context.Context, ownerId int, filter models.TodoFilter, limit int, after *models.TodoCursor) ([]models.TodoModel, error) {
+	keys, err := todoOrder(filter.Sort)
+	if err != nil {
+		return nil, err
+	}
+	var qb queryBuilder
+	qb.where("owner_id = " + qb.arg(ownerId))
+	applyTodoFilter(&qb, filter)
+	if after != nil {
+		applyKeyset(&qb, keys, after)
+	}
+	query := "SELECT " + todoColumns + " FROM todo" + qb.whereClause() + orderByClause(keys) + " LIMIT " + qb.arg(limit)
+
+	rows, err := r.db.Query(ctx, query, qb.args...)
 	if err != nil {
 		return nil, err
 	}
@@ -29,7 +74,7 @@ func (r *TodoRepositoryImpl) GetAllTodos(ctx context.Context) ([]models.TodoMode
 	var todos []models.TodoModel
 	for rows.Next() {
 		var todo models.TodoModel
-		if err = rows.Scan(&todo.Id, &todo.Title, &todo.Description, &todo.Completed, &todo.CreatedAt); err != nil {
+		if err = scanTodo(rows, &todo); err != nil {
 			return nil, err
 		}
 		todos = append(todos, todo)
@@ -38,74 +83,204 @@ func (r *TodoRepositoryImpl) GetAllTodos(ctx context.Context) ([]models.TodoMode
 	if err = rows.Err(); err != nil {
 		return nil, err
 	}
+	rows.Close()
 
+	if err = loadTodoTags(ctx, r.db, todos); err != nil {
+		return nil, err
+	}
 	return todos, nil
 }
 
-func (r *TodoRepositoryImpl) GetTodoById(ctx context.Context, id int) (models.TodoModel, error) {
+func (r *TodoRepositoryImpl) CountTodos(ctx context.Context, ownerId int, filter models.TodoFilter) (int, error) {
+	var qb queryBuilder
+	qb.where("owner_id = " + qb.arg(ownerId))
+	applyTodoFilter(&qb, filter)
+
+	var total int
+	if err := r.db.QueryRow(ctx, "SELECT COUNT(*) FROM todo"+qb.whereClause(), qb.args...).Scan(&total); err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+func (r *TodoRepositoryImpl) GetTodoById(ctx context.Context, ownerId, id int) (models.TodoModel, error) {
 	var todo models.TodoModel
-	err := r.db.QueryRow(ctx, "SELECT * FROM todo WHERE id = $1", id).
-		Scan(&todo.Id, &todo.Title, &todo.Description, &todo.Completed, &todo.CreatedAt)
+	err := scanTodo(r.db.QueryRow(ctx, "SELECT "+todoColumns+" FROM todo WHERE id = $1 AND owner_id = $2 AND deleted_at IS NULL", id, ownerId), &todo)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return models.TodoModel{}, ErrTodoNotFound
 		}
 		return models.TodoModel{}, err
 	}
-	return todo, nil
+	return r.withTags(ctx, todo)
 }
 
-func (r *TodoRepositoryImpl) CreateTodo(ctx context.Context, todo models.TodoModel) (models.TodoModel, error) {
-	query := `
-			INSERT INTO todo (title, description, completed, created_at)
-			VALUES ($1, $2, $3, NOW())
-			RETURNING id, created_at
-		`
-	err := r.db.QueryRow(ctx, query, todo.Title, todo.Description, todo.Completed).Scan(&todo.Id, &todo.CreatedAt)
-	if err != nil {
+// createTodoQuery inserts a todo, a subtask is appended after its siblings
+var createTodoQuery = `
+		INSERT INTO todo (title, description, completed, created_at, due_at, remind_at, project_id, owner_id, parent_id, position, recurrence)
+		VALUES ($1, $2, $3, NOW(), $4, $5, $6, $7, $8, ` + nextPosition("$8") + `, $9)
+		RETURNING id, created_at, position, version
+	`
+
+// createTodoArgs returns the arguments of createTodoQuery
+func createTodoArgs(ownerId int, todo models.TodoModel) []interface{} {
+	return []interface{}{todo.Title, todo.Description, todo.Completed, todo.DueAt, todo.RemindAt, todo.ProjectId, ownerId, todo.ParentId, todo.Recurrence}
+}
+
+// scanCreatedTodo completes a todo with the row returned by createTodoQuery
+func scanCreatedTodo(row pgx.Row, todo models.TodoModel) (models.TodoModel, error) {
+	if err := row.Scan(&todo.Id, &todo.CreatedAt, &todo.Position, &todo.Version); err != nil {
+		if isPgError(err, pgForeignKeyViolation) {
+			return models.TodoModel{}, ErrProjectNotFound
+		}
 		return models.TodoModel{}, err
 	}
+	todo.Progress = nil
+	todo.Tags = []models.TagModel{}
 	return todo, nil
 }
 
-func (r *TodoRepositoryImpl) UpdateTodo(ctx context.Context, id int, todo models.TodoModel) (models.TodoModel, error) {
-	query := `
-		UPDATE todo
-		SET title = $1, description = $2, completed = $3
-		WHERE id = $4
-		RETURNING id, title, description, completed, created_at
-	`
-	var updatedTodo models.TodoModel
-	err := r.db.QueryRow(
-		ctx,
-		query,
+// CreateTodo inserts a todo, a subtask is appended after its siblings
+func (r *TodoRepositoryImpl) CreateTodo(ctx context.Context, ownerId int, todo models.TodoModel) (models.TodoModel, error) {
+	if todo.ParentId != nil {
+		return withParent(ctx, r, ownerId, 0, *todo.ParentId, func(tx *TodoRepositoryImpl) (models.TodoModel, error) {
+			return tx.createTodo(ctx, ownerId, todo)
+		})
+	}
+	return r.createTodo(ctx, ownerId, todo)
+}
+
+func (r *TodoRepositoryImpl) createTodo(ctx context.Context, ownerId int, todo models.TodoModel) (models.TodoModel, error) {
+	return scanCreatedTodo(r.db.QueryRow(ctx, createTodoQuery, createTodoArgs(ownerId, todo)...), todo)
+}
+
+// UpdateTodo replaces a todo, when next is given and the update completes the todo the next instance
+// of its series is created in the same statement and returned as well. With ifMatch the todo is only replaced
+// while it has that version
+func (r *TodoRepositoryImpl) UpdateTodo(ctx context.Context, ownerId, id int, todo models.TodoModel, next *models.TodoOccurrence, ifMatch *int) (models.TodoModel, *models.TodoModel, error) {
+	var result todoUpdate
+	var err error
+	if todo.ParentId != nil {
+		result, err = withParent(ctx, r, ownerId, id, *todo.ParentId, func(tx *TodoRepositoryImpl) (todoUpdate, error) {
+			return tx.updateTodo(ctx, ownerId, id, todo, next, ifMatch)
+		})
+	} else {
+		result, err = r.updateTodo(ctx, ownerId, id, todo, next, ifMatch)
+	}
+	return result.todo, result.spawned, err
+}
+
+func (r *TodoRepositoryImpl) updateTodo(ctx context.Context, ownerId, id int, todo models.TodoModel, next *models.TodoOccurrence, ifMatch *int) (todoUpdate, error) {
+	qb := queryBuilder{args: []interface{}{
 		todo.Title,
 		todo.Description,
 		todo.Completed,
+		todo.DueAt,
+		todo.RemindAt,
+		todo.ProjectId,
 		id,
-	).Scan(
-		&updatedTodo.Id,
-		&updatedTodo.Title,
-		&updatedTodo.Description,
-		&updatedTodo.Completed,
-		&updatedTodo.CreatedAt,
-	)
-	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return models.TodoModel{}, ErrTodoNotFound
-		}
+		ownerId,
+		todo.ParentId,
+		todo.Recurrence,
+	}}
+	set := `title = $1, description = $2, completed = $3, due_at = $4, remind_at = $5, project_id = $6,
+			position = ` + movedPosition("$9") + `, parent_id = $9, recurrence = $10, version = version + 1`
+	query := updateTodoQuery(&qb, set, " WHERE id = $7 AND owner_id = $8 AND deleted_at IS NULL"+versionCondition(&qb, ifMatch), next)
+	return r.runUpdateTodoQuery(ctx, ownerId, id, query, qb.args, next, ifMatch)
+}
+
+// PatchTodo updates only the fields present in the patch, a null field is set to NULL. Like UpdateTodo it creates
+// and returns the next instance of the series when next is given and the patch completes the todo, and only
+// applies the patch while the todo has the version ifMatch
+func (r *TodoRepositoryImpl) PatchTodo(ctx context.Context, ownerId, id int, patch models.TodoPatch, next *models.TodoOccurrence, ifMatch *int) (models.TodoModel, *models.TodoModel, error) {
+	var result todoUpdate
+	var err error
+	if patch.ParentId.Set && !patch.ParentId.Null {
+		result, err = withParent(ctx, r, ownerId, id, patch.ParentId.Value, func(tx *TodoRepositoryImpl) (todoUpdate, error) {
+			return tx.patchTodo(ctx, ownerId, id, patch, next, ifMatch)
+		})
+	} else {
+		result, err = r.patchTodo(ctx, ownerId, id, patch, next, ifMatch)
+	}
+	return result.todo, result.spawned, err
+}
+
+func (r *TodoRepositoryImpl) patchTodo(ctx context.Context, ownerId, id int, patch models.TodoPatch, next *models.TodoOccurrence, ifMatch *int) (todoUpdate, error) {
+	var qb queryBuilder
+	var set []string
+	if patch.Title.Set {
+		set = append(set, "title = "+qb.arg(patchValue(patch.Title)))
+	}
+	if patch.Description.Set {
+		set = append(set, "description = "+qb.arg(patchValue(patch.Description)))
+	}
+	if patch.Completed.Set {
+		set = append(set, "completed = "+qb.arg(patchValue(patch.Completed)))
+	}
+	if patch.DueAt.Set {
+		set = append(set, "due_at = "+qb.arg(patchValue(patch.DueAt)))
+	}
+	if patch.RemindAt.Set {
+		set = append(set, "remind_at = "+qb.arg(patchValue(patch.RemindAt)))
+	}
+	if patch.ProjectId.Set {
+		set = append(set, "project_id = "+qb.arg(patchValue(patch.ProjectId)))
+	}
+	if patch.ParentId.Set {
+		parent := qb.arg(patchValue(patch.ParentId))
+		set = append(set, "position = "+movedPosition(parent), "parent_id = "+parent)
+	}
+	if patch.Recurrence.Set {
+		set = append(set, "recurrence = "+qb.arg(patchValue(patch.Recurrence)))
+	}
+	if len(set) == 0 {
+		todo, err := r.GetTodoById(ctx, ownerId, id)
+		return todoUpdate{todo: todo}, err
+	}
+	qb.where("id = " + qb.arg(id))
+	qb.where("owner_id = " + qb.arg(ownerId))
+	qb.where("deleted_at IS NULL")
+	set = append(set, "version = version + 1")
+	query := updateTodoQuery(&qb, strings.Join(set, ", "), qb.whereClause()+versionCondition(&qb, ifMatch), next)
+	return r.runUpdateTodoQuery(ctx, ownerId, id, query, qb.args, next, ifMatch)
+}
+
+// withTags returns the todo with its tags loaded
+func (r *TodoRepositoryImpl) withTags(ctx context.Context, todo models.TodoModel) (models.TodoModel, error) {
+	todos := []models.TodoModel{todo}
+	if err := loadTodoTags(ctx, r.db, todos); err != nil {
 		return models.TodoModel{}, err
 	}
-	return updatedTodo, nil
+	return todos[0], nil
 }
 
-func (r *TodoRepositoryImpl) DeleteTodoById(ctx context.Context, id int) error {
-	cmdTag, err := r.db.Exec(ctx, "DELETE FROM todo WHERE id = $1", id)
+// patchValue returns the SQL argument of a patch field, nil for an explicit null
+func patchValue[T any](f models.PatchField[T]) interface{} {
+	if f.Null {
+		return nil
+	}
+	return f.Value
+}
+
+// DeleteTodoById moves a todo to the trash together with its subtasks, they share the deletion time
+// so that restoring the todo brings them back as well. With ifMatch the todo is only trashed while it has that version
+func (r *TodoRepositoryImpl) DeleteTodoById(ctx context.Context, ownerId, id int, ifMatch *int) error {
+	qb := queryBuilder{args: []interface{}{id, ownerId}}
+	query := `
+		WITH RECURSIVE subtree AS (
+			SELECT id FROM todo WHERE id = $1 AND owner_id = $2 AND deleted_at IS NULL` + versionCondition(&qb, ifMatch) + `
+			UNION ALL
+			SELECT t.id FROM todo t JOIN subtree s ON t.parent_id = s.id WHERE t.deleted_at IS NULL
+		)
+		UPDATE todo SET deleted_at = NOW()
+		WHERE id IN (SELECT id FROM subtree)
+	`
+	cmdTag, err := r.db.Exec(ctx, query, qb.args...)
 	if err != nil {
 		return err
 	}
 	if cmdTag.RowsAffected() == 0 {
-		return ErrTodoNotFound
+		return r.missingTodoError(ctx, ownerId, id, ifMatch)
 	}
 	return nil
 }
@@ -5,18 +5,130 @@ import (
 	"github.com/cherrycutter/todo_app/internal/models"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
+	"time"
 )
 
 type TodoRepository interface {
-	GetAllTodos(ctx context.Context) ([]models.TodoModel, error)
-	GetTodoById(ctx context.Context, id int) (models.TodoModel, error)
-	CreateTodo(ctx context.Context, todo models.TodoModel) (models.TodoModel, error)
-	UpdateTodo(ctx context.Context, id int, todo models.TodoModel) (models.TodoModel, error)
-	DeleteTodoById(ctx context.Context, id int) error
+	GetAllTodos(ctx context.Context, ownerId int, filter models.TodoFilter, limit int, after *models.TodoCursor) ([]models.TodoModel, error)
+	CountTodos(ctx context.Context, ownerId int, filter models.TodoFilter) (int, error)
+	GetTodoById(ctx context.Context, ownerId, id int) (models.TodoModel, error)
+	CreateTodo(ctx context.Context, ownerId int, todo models.TodoModel) (models.TodoModel, error)
+	UpdateTodo(ctx context.Context, ownerId, id int, todo models.TodoModel, next *models.TodoOccurrence, ifMatch *int) (models.TodoModel, *models.TodoModel, error)
+	PatchTodo(ctx context.Context, ownerId, id int, patch models.TodoPatch, next *models.TodoOccurrence, ifMatch *int) (models.TodoModel, *models.TodoModel, error)
+	DeleteTodoById(ctx context.Context, ownerId, id int, ifMatch *int) error
+	SearchTodos(ctx context.Context, ownerId int, search models.TodoSearch, limit int) ([]models.TodoSearchHit, error)
+	GetSubtasks(ctx context.Context, ownerId, parentId int) ([]models.TodoModel, error)
+	ReorderSubtasks(ctx context.Context, ownerId, parentId int, ids []int) error
+	ToggleSubtask(ctx context.Context, ownerId, parentId, id int) (models.TodoModel, error)
+	CompleteSubtasks(ctx context.Context, ownerId, id int) error
+	RestoreTodo(ctx context.Context, ownerId, id int) error
+	DeleteTrashedTodo(ctx context.Context, ownerId, id int) error
+	PurgeTrash(ctx context.Context, before time.Time) (int64, error)
+	CreateTodos(ctx context.Context, ownerId int, todos []models.TodoModel) ([]models.TodoModel, error)
+	InTx(ctx context.Context, fn func(repo TodoRepository) error) error
+	LockTodo(ctx context.Context, ownerId, id int) (models.TodoModel, error)
+	LockSubtree(ctx context.Context, ownerId, id int) ([]models.TodoModel, error)
+	LockProjectTodos(ctx context.Context, ownerId, projectId int) ([]models.TodoModel, error)
+	LockTaggedTodos(ctx context.Context, ownerId, tagId int) ([]models.TodoModel, error)
+	LockTodos(ctx context.Context, ownerId int, ids []int) ([]models.TodoModel, error)
+	Projects() ProjectRepository
+	Tags() TagRepository
+	AddAuditEntries(ctx context.Context, ownerId int, entries []models.AuditEntry) error
+	GetAuditEntries(ctx context.Context, ownerId int, filter models.AuditFilter, limit int, after *models.AuditCursor) ([]models.AuditEntry, error)
+	AddTodoEvents(ctx context.Context, ownerId int, events []models.TodoEvent) error
+	AddWebhookDeliveries(ctx context.Context, ownerId int, deliveries []models.WebhookDelivery) error
 }
 
+type TagRepository interface {
+	GetAllTags(ctx context.Context, ownerId int) ([]models.TagModel, error)
+	GetTagById(ctx context.Context, ownerId, id int) (models.TagModel, error)
+	CreateTag(ctx context.Context, ownerId int, tag models.TagModel) (models.TagModel, error)
+	UpdateTag(ctx context.Context, ownerId, id int, tag models.TagModel) (models.TagModel, error)
+	DeleteTagById(ctx context.Context, ownerId, id int) error
+	AttachTag(ctx context.Context, ownerId, todoId, tagId int) error
+	DetachTag(ctx context.Context, ownerId, todoId, tagId int) error
+}
+
+type ProjectRepository interface {
+	GetAllProjects(ctx context.Context, ownerId int, includeArchived bool) ([]models.ProjectModel, error)
+	GetProjectById(ctx context.Context, ownerId, id int) (models.ProjectModel, error)
+	CreateProject(ctx context.Context, ownerId int, project models.ProjectModel) (models.ProjectModel, error)
+	UpdateProject(ctx context.Context, ownerId, id int, project models.ProjectModel) (models.ProjectModel, error)
+	DeleteProjectById(ctx context.Context, ownerId, id int, mode models.ProjectDeleteMode) error
+}
+
+type UserRepository interface {
+	CreateUser(ctx context.Context, user models.UserModel) (models.UserModel, error)
+	GetUserByEmail(ctx context.Context, email string) (models.UserModel, error)
+}
+
+type RefreshTokenRepository interface {
+	CreateRefreshToken(ctx context.Context, userId int, tokenHash string, expiresAt time.Time) error
+	RotateRefreshToken(ctx context.Context, tokenHash, newTokenHash string, expiresAt time.Time) (int, error)
+	RevokeRefreshToken(ctx context.Context, tokenHash string) error
+}
+
+type APIKeyRepository interface {
+	GetAllAPIKeys(ctx context.Context, ownerId int) ([]models.APIKeyModel, error)
+	CreateAPIKey(ctx context.Context, ownerId int, key models.APIKeyModel, keyHash string) (models.APIKeyModel, error)
+	RotateAPIKey(ctx context.Context, ownerId, id int, prefix, keyHash string) (models.APIKeyModel, error)
+	RevokeAPIKey(ctx context.Context, ownerId, id int) error
+	UseAPIKey(ctx context.Context, keyHash string) (int, []string, error)
+}
+
+type IdempotencyRepository interface {
+	ReserveIdempotencyKey(ctx context.Context, userId int, key, fingerprint string, expiresAt time.Time) (models.IdempotencyRecord, bool, error)
+	SaveIdempotentResponse(ctx context.Context, userId int, key string, response models.IdempotentResponse) error
+	DeleteIdempotencyKey(ctx context.Context, userId int, key string) error
+	PurgeIdempotencyKeys(ctx context.Context, now time.Time) (int64, error)
+}
+
+type EventRepository interface {
+	GetEvent(ctx context.Context, ownerId int, id int64) (models.TodoEvent, error)
+	GetEventsAfter(ctx context.Context, ownerId int, after int64, limit int) ([]models.TodoEvent, bool, error)
+	PurgeEvents(ctx context.Context, before time.Time) (int64, error)
+}
+
+type EventListener interface {
+	Listen(ctx context.Context, notify func(ownerId int, eventId int64)) error
+}
+
+type WebhookRepository interface {
+	GetAllWebhooks(ctx context.Context, ownerId int) ([]models.WebhookModel, error)
+	GetWebhookById(ctx context.Context, ownerId, id int) (models.WebhookModel, error)
+	CreateWebhook(ctx context.Context, ownerId int, webhook models.WebhookModel) (models.WebhookModel, error)
+	UpdateWebhook(ctx context.Context, ownerId, id int, webhook models.WebhookModel) (models.WebhookModel, error)
+	DeleteWebhookById(ctx context.Context, ownerId, id int) error
+	GetWebhookDeliveries(ctx context.Context, ownerId, webhookId, limit int) ([]models.WebhookDelivery, error)
+	ClaimWebhookDeliveries(ctx context.Context, now, leaseUntil time.Time, limit int) ([]models.WebhookJob, error)
+	RecordWebhookAttempt(ctx context.Context, job models.WebhookJob, attempt models.WebhookAttempt, retryAt *time.Time, unhealthyAfter int) error
+}
+
+type HealthRepository interface {
+	Ping(ctx context.Context) error
+	GetSchemaVersion(ctx context.Context) (int64, bool, error)
+}
+
+type StatsRepository interface {
+	CountAllTodos(ctx context.Context) (models.TodoCounts, error)
+}
+
+// PgxConnIface is the database handle of the repositories, NewDB turns a pool, a connection or a transaction into one
 type PgxConnIface interface {
 	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
 	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
 	Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error)
+	SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults
+	// WithTx runs fn with a handle whose statements all belong to one transaction, the transaction is committed
+	// when fn returns nil and rolled back otherwise. Within a transaction it runs fn in a savepoint
+	WithTx(ctx context.Context, fn func(tx PgxConnIface) error) error
+}
+
+// PgxIface is what NewDB needs from a database handle, pgxpool.Pool, pgx.Conn and pgx.Tx implement it
+type PgxIface interface {
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+	Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error)
+	SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults
+	Begin(ctx context.Context) (pgx.Tx, error)
 }
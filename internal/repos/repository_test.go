@@ -11,6 +11,29 @@ import (
 	"time"
 )
 
+var (
+	todoRowColumns = []string{"id", "title", "description", "completed", "created_at", "due_at", "remind_at", "project_id",
+		"parent_id", "position", "recurrence", "series_id", "deleted_at", "version", "subtasks_done", "subtasks_total"}
+	tagRowColumns = []string{"todo_id", "id", "name", "color", "description", "created_at"}
+	noTime        *time.Time
+	noProject     *int
+	noRule        *string
+)
+
+const (
+	// testUserId is the owner every repository call is scoped to
+	testUserId = 7
+	// testMaxDepth is the number of levels todos can be nested in the tests
+	testMaxDepth = 3
+)
+
+// expectTodoTags expects the batched tag query of the given todos
+func expectTodoTags(mockDB pgxmock.PgxConnIface, rows *pgxmock.Rows, ids ...int) {
+	mockDB.ExpectQuery("SELECT (.+) FROM todo_tag tt JOIN tag t ON t.id = tt.tag_id WHERE tt.todo_id = ANY\\(\\$1\\)").
+		WithArgs(ids).
+		WillReturnRows(rows)
+}
+
 func TestGetAllTodos(t *testing.T) {
 	mockDB, err := pgxmock.NewConn()
 	if err != nil {
@@ -18,33 +41,183 @@ func TestGetAllTodos(t *testing.T) {
 	}
 	defer mockDB.Close(context.Background())
 
-	r := NewTodoRepo(mockDB)
+	r := NewTodoRepo(NewDB(mockDB), testMaxDepth)
+	now := time.Now()
+	due := now.Add(-time.Hour)
+	remind := now.Add(-2 * time.Hour)
+
+	completed := false
 
+	type args struct {
+		filter models.TodoFilter
+		limit  int
+		after  *models.TodoCursor
+	}
 	tests := []struct {
 		name    string
 		mock    func()
+		input   args
 		want    []models.TodoModel
 		wantErr bool
 	}{
 		{
 			name: "Ok",
 			mock: func() {
-				rows := pgxmock.NewRows([]string{"id", "title", "description", "completed", "created_at"}).
-					AddRow(1, "title1", "description1", false, time.Now()).
-					AddRow(2, "title2", "description2", true, time.Now())
-				mockDB.ExpectQuery("SELECT \\* FROM todo").WillReturnRows(rows)
+				rows := pgxmock.NewRows(todoRowColumns).
+					AddRow(1, "title1", "description1", false, now, nil, nil, nil, nil, 0, nil, nil, nil, 1, 0, 0).
+					AddRow(2, "title2", "description2", true, now, nil, nil, nil, nil, 0, nil, nil, nil, 1, 0, 0)
+				mockDB.ExpectQuery("SELECT (.+) FROM todo WHERE owner_id = \\$1 AND deleted_at IS NULL ORDER BY created_at, id LIMIT \\$2").
+					WithArgs(testUserId, 10).
+					WillReturnRows(rows)
+				expectTodoTags(mockDB, pgxmock.NewRows(tagRowColumns).AddRow(1, 7, "backend", "#1e90ff", "", now), 1, 2)
+			},
+			input: args{
+				limit: 10,
+			},
+			want: []models.TodoModel{
+				{Id: 1, Title: "title1", Description: "description1", Completed: false, CreatedAt: now, Version: 1, Tags: []models.TagModel{{Id: 7, Name: "backend", Color: "#1e90ff", CreatedAt: now}}},
+				{Id: 2, Title: "title2", Description: "description2", Completed: true, CreatedAt: now, Version: 1, Tags: []models.TagModel{}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Ok_AfterCursor",
+			mock: func() {
+				rows := pgxmock.NewRows(todoRowColumns).
+					AddRow(3, "title3", "description3", false, now, nil, nil, nil, nil, 0, nil, nil, nil, 1, 0, 0)
+				mockDB.ExpectQuery("SELECT (.+) FROM todo WHERE owner_id = \\$1 AND deleted_at IS NULL AND \\(created_at, id\\) > \\(\\$2, \\$3\\) ORDER BY created_at, id LIMIT \\$4").
+					WithArgs(testUserId, now, 2, 10).
+					WillReturnRows(rows)
+				expectTodoTags(mockDB, pgxmock.NewRows(tagRowColumns), 3)
+			},
+			input: args{
+				limit: 10,
+				after: &models.TodoCursor{CreatedAt: now, Id: 2},
+			},
+			want: []models.TodoModel{
+				{Id: 3, Title: "title3", Description: "description3", Completed: false, CreatedAt: now, Version: 1, Tags: []models.TagModel{}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Ok_Filtered",
+			mock: func() {
+				rows := pgxmock.NewRows(todoRowColumns).
+					AddRow(1, "50% done", "description1", false, now, nil, nil, nil, nil, 0, nil, nil, nil, 1, 0, 0)
+				mockDB.ExpectQuery("SELECT (.+) FROM todo WHERE owner_id = \\$1 AND deleted_at IS NULL AND completed = \\$2 AND title ILIKE \\$3 ORDER BY created_at, id LIMIT \\$4").
+					WithArgs(testUserId, false, "%50\\%%", 10).
+					WillReturnRows(rows)
+				expectTodoTags(mockDB, pgxmock.NewRows(tagRowColumns), 1)
+			},
+			input: args{
+				filter: models.TodoFilter{Completed: &completed, TitleContains: "50%"},
+				limit:  10,
+			},
+			want: []models.TodoModel{
+				{Id: 1, Title: "50% done", Description: "description1", Completed: false, CreatedAt: now, Version: 1, Tags: []models.TagModel{}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Ok_MixedSortAfterCursor",
+			mock: func() {
+				rows := pgxmock.NewRows(todoRowColumns).
+					AddRow(3, "b", "description3", false, now, nil, nil, nil, nil, 0, nil, nil, nil, 1, 0, 0)
+				mockDB.ExpectQuery("SELECT (.+) FROM todo WHERE owner_id = \\$1 AND deleted_at IS NULL AND \\(\\(created_at < \\$2\\) OR \\(created_at = \\$3 AND title > \\$4\\) OR \\(created_at = \\$5 AND title = \\$6 AND id > \\$7\\)\\) ORDER BY created_at DESC, title, id LIMIT \\$8").
+					WithArgs(testUserId, now, now, "a", now, "a", 2, 10).
+					WillReturnRows(rows)
+				expectTodoTags(mockDB, pgxmock.NewRows(tagRowColumns), 3)
+			},
+			input: args{
+				filter: models.TodoFilter{Sort: []models.SortField{{Field: "created_at", Desc: true}, {Field: "title"}}},
+				limit:  10,
+				after:  &models.TodoCursor{Id: 2, Title: "a", CreatedAt: now},
+			},
+			want: []models.TodoModel{
+				{Id: 3, Title: "b", Description: "description3", Completed: false, CreatedAt: now, Version: 1, Tags: []models.TagModel{}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Ok_Overdue",
+			mock: func() {
+				rows := pgxmock.NewRows(todoRowColumns).
+					AddRow(4, "title4", "description4", false, now, &due, nil, nil, nil, 0, nil, nil, nil, 1, 0, 0)
+				mockDB.ExpectQuery("SELECT (.+) FROM todo WHERE owner_id = \\$1 AND deleted_at IS NULL AND due_at < NOW\\(\\) AND NOT completed ORDER BY COALESCE\\(due_at, 'infinity'\\), id LIMIT \\$2").
+					WithArgs(testUserId, 10).
+					WillReturnRows(rows)
+				expectTodoTags(mockDB, pgxmock.NewRows(tagRowColumns), 4)
+			},
+			input: args{
+				filter: models.TodoFilter{Overdue: true, Sort: []models.SortField{{Field: "due_at"}}},
+				limit:  10,
 			},
 			want: []models.TodoModel{
-				{Id: 1, Title: "title1", Description: "description1", Completed: false, CreatedAt: time.Now()},
-				{Id: 2, Title: "title2", Description: "description2", Completed: true, CreatedAt: time.Now()},
+				{Id: 4, Title: "title4", Description: "description4", Completed: false, CreatedAt: now, DueAt: &due, Version: 1, Tags: []models.TagModel{}},
 			},
 			wantErr: false,
 		},
+		{
+			name: "Ok_DueRange",
+			mock: func() {
+				rows := pgxmock.NewRows(todoRowColumns).
+					AddRow(4, "title4", "description4", false, now, &due, &remind, nil, nil, 0, nil, nil, nil, 1, 0, 0)
+				mockDB.ExpectQuery("SELECT (.+) FROM todo WHERE owner_id = \\$1 AND deleted_at IS NULL AND due_at >= \\$2 AND due_at < \\$3 ORDER BY (.+) LIMIT \\$4").
+					WithArgs(testUserId, remind, due.Add(time.Hour), 10).
+					WillReturnRows(rows)
+				expectTodoTags(mockDB, pgxmock.NewRows(tagRowColumns), 4)
+			},
+			input: args{
+				filter: models.TodoFilter{DueFrom: &remind, DueTo: func() *time.Time { t := due.Add(time.Hour); return &t }()},
+				limit:  10,
+			},
+			want: []models.TodoModel{
+				{Id: 4, Title: "title4", Description: "description4", Completed: false, CreatedAt: now, DueAt: &due, RemindAt: &remind, Version: 1, Tags: []models.TagModel{}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Ok_AllTags",
+			mock: func() {
+				rows := pgxmock.NewRows(todoRowColumns).
+					AddRow(5, "title5", "description5", false, now, nil, nil, nil, nil, 0, nil, nil, nil, 1, 0, 0)
+				mockDB.ExpectQuery("SELECT (.+) FROM todo WHERE owner_id = \\$1 AND deleted_at IS NULL AND id IN \\(SELECT tt.todo_id FROM todo_tag tt JOIN tag t ON t.id = tt.tag_id WHERE t.name = ANY\\(\\$2\\) GROUP BY tt.todo_id HAVING COUNT\\(DISTINCT t.id\\) = \\$3\\) ORDER BY created_at, id LIMIT \\$4").
+					WithArgs(testUserId, []string{"ops", "backend", "ops"}, 2, 10).
+					WillReturnRows(rows)
+				expectTodoTags(mockDB, pgxmock.NewRows(tagRowColumns).
+					AddRow(5, 1, "backend", "", "", now).
+					AddRow(5, 2, "ops", "", "", now), 5)
+			},
+			input: args{
+				filter: models.TodoFilter{Tags: []string{"ops", "backend", "ops"}, TagMatchAll: true},
+				limit:  10,
+			},
+			want: []models.TodoModel{
+				{Id: 5, Title: "title5", Description: "description5", Completed: false, CreatedAt: now, Version: 1, Tags: []models.TagModel{
+					{Id: 1, Name: "backend", CreatedAt: now},
+					{Id: 2, Name: "ops", CreatedAt: now},
+				}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Unknown Sort Field",
+			mock: func() {
+			},
+			input: args{
+				filter: models.TodoFilter{Sort: []models.SortField{{Field: "password"}}},
+				limit:  10,
+			},
+			wantErr: true,
+		},
 		{
 			name: "No Rows",
 			mock: func() {
-				rows := pgxmock.NewRows([]string{"id", "title", "description", "completed", "created_at"})
-				mockDB.ExpectQuery("SELECT \\* FROM todo").WillReturnRows(rows)
+				rows := pgxmock.NewRows(todoRowColumns)
+				mockDB.ExpectQuery("SELECT (.+) FROM todo").WithArgs(testUserId, 10).WillReturnRows(rows)
+			},
+			input: args{
+				limit: 10,
 			},
 			want:    []models.TodoModel(nil),
 			wantErr: false,
@@ -52,7 +225,10 @@ func TestGetAllTodos(t *testing.T) {
 		{
 			name: "Query Error",
 			mock: func() {
-				mockDB.ExpectQuery("SELECT \\* FROM todo").WillReturnError(errors.New("query error"))
+				mockDB.ExpectQuery("SELECT (.+) FROM todo").WithArgs(testUserId, 10).WillReturnError(errors.New("query error"))
+			},
+			input: args{
+				limit: 10,
 			},
 			want:    nil,
 			wantErr: true,
@@ -63,7 +239,70 @@ func TestGetAllTodos(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mock()
 
-			got, err := r.GetAllTodos(context.Background())
+			got, err := r.GetAllTodos(context.Background(), testUserId, tt.input.filter, tt.input.limit, tt.input.after)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+			assert.NoError(t, mockDB.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestCountTodos(t *testing.T) {
+	mockDB, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer mockDB.Close(context.Background())
+
+	r := NewTodoRepo(NewDB(mockDB), testMaxDepth)
+	completed := true
+
+	tests := []struct {
+		name    string
+		mock    func()
+		filter  models.TodoFilter
+		want    int
+		wantErr bool
+	}{
+		{
+			name: "Ok",
+			mock: func() {
+				rows := pgxmock.NewRows([]string{"count"}).AddRow(42)
+				mockDB.ExpectQuery("SELECT COUNT\\(\\*\\) FROM todo WHERE owner_id = \\$1").WithArgs(testUserId).WillReturnRows(rows)
+			},
+			want:    42,
+			wantErr: false,
+		},
+		{
+			name: "Ok_Filtered",
+			mock: func() {
+				rows := pgxmock.NewRows([]string{"count"}).AddRow(7)
+				mockDB.ExpectQuery("SELECT COUNT\\(\\*\\) FROM todo WHERE owner_id = \\$1 AND deleted_at IS NULL AND completed = \\$2").
+					WithArgs(testUserId, true).
+					WillReturnRows(rows)
+			},
+			filter:  models.TodoFilter{Completed: &completed},
+			want:    7,
+			wantErr: false,
+		},
+		{
+			name: "Query Error",
+			mock: func() {
+				mockDB.ExpectQuery("SELECT COUNT\\(\\*\\) FROM todo").WithArgs(testUserId).WillReturnError(errors.New("query error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			got, err := r.CountTodos(context.Background(), testUserId, tt.filter)
 			if tt.wantErr {
 				assert.Error(t, err)
 			} else {
@@ -82,7 +321,7 @@ func TestGetTodoById(t *testing.T) {
 	}
 	defer mockDB.Close(context.Background())
 
-	r := NewTodoRepo(mockDB)
+	r := NewTodoRepo(NewDB(mockDB), testMaxDepth)
 
 	type args struct {
 		id int
@@ -97,9 +336,10 @@ func TestGetTodoById(t *testing.T) {
 		{
 			name: "Ok",
 			mock: func() {
-				rows := pgxmock.NewRows([]string{"id", "title", "description", "completed", "created_at"}).
-					AddRow(1, "title1", "description1", false, time.Now())
-				mockDB.ExpectQuery("SELECT \\* FROM todo WHERE (.+)").WithArgs(1).WillReturnRows(rows)
+				rows := pgxmock.NewRows(todoRowColumns).
+					AddRow(1, "title1", "description1", false, time.Now(), nil, nil, nil, nil, 0, nil, nil, nil, 1, 0, 0)
+				mockDB.ExpectQuery("SELECT (.+) FROM todo WHERE id = \\$1 AND owner_id = \\$2").WithArgs(1, testUserId).WillReturnRows(rows)
+				expectTodoTags(mockDB, pgxmock.NewRows(tagRowColumns), 1)
 			},
 			input: args{
 				id: 1,
@@ -110,7 +350,7 @@ func TestGetTodoById(t *testing.T) {
 		{
 			name: "Not Found",
 			mock: func() {
-				mockDB.ExpectQuery("SELECT \\* FROM todo WHERE (.+)").WithArgs(404).WillReturnError(pgx.ErrNoRows)
+				mockDB.ExpectQuery("SELECT (.+) FROM todo WHERE id = \\$1 AND owner_id = \\$2").WithArgs(404, testUserId).WillReturnError(pgx.ErrNoRows)
 			},
 			input: args{
 				id: 404,
@@ -120,7 +360,7 @@ func TestGetTodoById(t *testing.T) {
 		{
 			name: "Query Error",
 			mock: func() {
-				mockDB.ExpectQuery("SELECT \\* FROM todo WHERE (.+)").WithArgs(1).WillReturnError(errors.New("query error"))
+				mockDB.ExpectQuery("SELECT (.+) FROM todo WHERE id = \\$1 AND owner_id = \\$2").WithArgs(1, testUserId).WillReturnError(errors.New("query error"))
 			},
 			input: args{
 				id: 1,
@@ -131,7 +371,7 @@ func TestGetTodoById(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mock()
-			got, err := r.GetTodoById(context.Background(), tt.input.id)
+			got, err := r.GetTodoById(context.Background(), testUserId, tt.input.id)
 			if tt.wantErr {
 				assert.Error(t, err)
 			} else {
@@ -153,7 +393,7 @@ func TestCreateTodo(t *testing.T) {
 	}
 	defer mockDB.Close(context.Background())
 
-	r := NewTodoRepo(mockDB)
+	r := NewTodoRepo(NewDB(mockDB), testMaxDepth)
 
 	tests := []struct {
 		name    string
@@ -165,9 +405,9 @@ func TestCreateTodo(t *testing.T) {
 		{
 			name: "Ok",
 			mock: func() {
-				rows := pgxmock.NewRows([]string{"id", "created_at"}).AddRow(1, time.Now())
+				rows := pgxmock.NewRows([]string{"id", "created_at", "position", "version"}).AddRow(1, time.Now(), 0, 1)
 				mockDB.ExpectQuery("INSERT INTO todo").
-					WithArgs("title", "description", false).
+					WithArgs("title", "description", false, noTime, noTime, noProject, testUserId, noProject, noRule).
 					WillReturnRows(rows)
 			},
 			input: models.TodoModel{
@@ -200,7 +440,7 @@ func TestCreateTodo(t *testing.T) {
 			name: "Query Error",
 			mock: func() {
 				mockDB.ExpectQuery("INSERT INTO todo").
-					WithArgs("title", "description", false).WillReturnError(errors.New("query error"))
+					WithArgs("title", "description", false, noTime, noTime, noProject, testUserId, noProject, noRule).WillReturnError(errors.New("query error"))
 			},
 			input: models.TodoModel{
 				Title:       "title",
@@ -215,7 +455,7 @@ func TestCreateTodo(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mock()
 
-			got, err := r.CreateTodo(context.Background(), tt.input)
+			got, err := r.CreateTodo(context.Background(), testUserId, tt.input)
 			if tt.wantErr {
 				assert.Error(t, err)
 			} else {
@@ -238,7 +478,7 @@ func TestUpdateTodo(t *testing.T) {
 	}
 	defer mockDB.Close(context.Background())
 
-	r := NewTodoRepo(mockDB)
+	r := NewTodoRepo(NewDB(mockDB), testMaxDepth)
 
 	type args struct {
 		id    int
@@ -254,11 +494,12 @@ func TestUpdateTodo(t *testing.T) {
 		{
 			name: "Ok_AllFields",
 			mock: func() {
-				rows := pgxmock.NewRows([]string{"id", "title", "description", "completed", "created_at"}).
-					AddRow(1, "new title", "new description", false, time.Now())
-				mockDB.ExpectQuery("UPDATE todo SET title = \\$1, description = \\$2, completed = \\$3 WHERE id = \\$4 RETURNING id, title, description, completed, created_at").
-					WithArgs("new title", "new description", false, 1).
+				rows := pgxmock.NewRows(todoRowColumns).
+					AddRow(1, "new title", "new description", false, time.Now(), nil, nil, nil, nil, 0, nil, nil, nil, 1, 0, 0)
+				mockDB.ExpectQuery("UPDATE todo SET title = \\$1, description = \\$2, completed = \\$3, due_at = \\$4, remind_at = \\$5, project_id = \\$6, position = (.+), parent_id = \\$9, recurrence = \\$10, version = version \\+ 1 WHERE id = \\$7 AND owner_id = \\$8 AND deleted_at IS NULL RETURNING id, title, COALESCE\\(description, ''\\), completed, created_at, due_at, remind_at, project_id, parent_id, position, recurrence, series_id, deleted_at, version").
+					WithArgs("new title", "new description", false, noTime, noTime, noProject, 1, testUserId, noProject, noRule).
 					WillReturnRows(rows)
+				expectTodoTags(mockDB, pgxmock.NewRows(tagRowColumns), 1)
 			},
 			input: args{
 				id:    1,
@@ -276,8 +517,8 @@ func TestUpdateTodo(t *testing.T) {
 		{
 			name: "Not Found",
 			mock: func() {
-				mockDB.ExpectQuery("UPDATE todo SET title = \\$1, description = \\$2, completed = \\$3 WHERE id = \\$4 RETURNING id, title, description, completed, created_at").
-					WithArgs("new title", "new description", false, 404).
+				mockDB.ExpectQuery("UPDATE todo SET title = \\$1, description = \\$2, completed = \\$3, due_at = \\$4, remind_at = \\$5, project_id = \\$6, position = (.+), parent_id = \\$9, recurrence = \\$10, version = version \\+ 1 WHERE id = \\$7 AND owner_id = \\$8 AND deleted_at IS NULL RETURNING id, title, COALESCE\\(description, ''\\), completed, created_at, due_at, remind_at, project_id, parent_id, position, recurrence, series_id, deleted_at, version").
+					WithArgs("new title", "new description", false, noTime, noTime, noProject, 404, testUserId, noProject, noRule).
 					WillReturnError(pgx.ErrNoRows)
 			},
 			input: args{
@@ -301,8 +542,8 @@ func TestUpdateTodo(t *testing.T) {
 		{
 			name: "Query Error",
 			mock: func() {
-				mockDB.ExpectQuery("UPDATE todo SET title = \\$1, description = \\$2, completed = \\$3 WHERE id = \\$4 RETURNING id, title, description, completed, created_at").
-					WithArgs("new title", "new description", false, 1).
+				mockDB.ExpectQuery("UPDATE todo SET title = \\$1, description = \\$2, completed = \\$3, due_at = \\$4, remind_at = \\$5, project_id = \\$6, position = (.+), parent_id = \\$9, recurrence = \\$10, version = version \\+ 1 WHERE id = \\$7 AND owner_id = \\$8 AND deleted_at IS NULL RETURNING id, title, COALESCE\\(description, ''\\), completed, created_at, due_at, remind_at, project_id, parent_id, position, recurrence, series_id, deleted_at, version").
+					WithArgs("new title", "new description", false, noTime, noTime, noProject, 1, testUserId, noProject, noRule).
 					WillReturnError(errors.New("query error"))
 			},
 			input: args{
@@ -319,7 +560,7 @@ func TestUpdateTodo(t *testing.T) {
 				tt.mock()
 			}
 
-			got, err := r.UpdateTodo(context.Background(), tt.input.id, tt.input.input)
+			got, _, err := r.UpdateTodo(context.Background(), testUserId, tt.input.id, tt.input.input, nil, nil)
 			if tt.wantErr {
 				assert.Error(t, err)
 			} else {
@@ -342,7 +583,7 @@ func TestDeleteTodoById(t *testing.T) {
 	}
 	defer mockDB.Close(context.Background())
 
-	r := NewTodoRepo(mockDB)
+	r := NewTodoRepo(NewDB(mockDB), testMaxDepth)
 
 	type args struct {
 		id int
@@ -356,9 +597,9 @@ func TestDeleteTodoById(t *testing.T) {
 		{
 			name: "Ok",
 			mock: func() {
-				mockDB.ExpectExec("DELETE FROM todo WHERE id = \\$1").
-					WithArgs(1).
-					WillReturnResult(pgxmock.NewResult("DELETE", 1))
+				mockDB.ExpectExec("WITH RECURSIVE subtree AS \\( SELECT id FROM todo WHERE id = \\$1 AND owner_id = \\$2 AND deleted_at IS NULL (.+) \\) UPDATE todo SET deleted_at = NOW\\(\\) WHERE id IN \\(SELECT id FROM subtree\\)").
+					WithArgs(1, testUserId).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 3))
 			},
 			input: args{
 				id: 1,
@@ -368,9 +609,9 @@ func TestDeleteTodoById(t *testing.T) {
 		{
 			name: "Not Found",
 			mock: func() {
-				mockDB.ExpectExec("DELETE FROM todo WHERE id = \\$1").
-					WithArgs(404).
-					WillReturnResult(pgxmock.NewResult("DELETE", 0))
+				mockDB.ExpectExec("WITH RECURSIVE subtree AS \\( SELECT id FROM todo WHERE id = \\$1 AND owner_id = \\$2 AND deleted_at IS NULL (.+) \\) UPDATE todo SET deleted_at = NOW\\(\\) WHERE id IN \\(SELECT id FROM subtree\\)").
+					WithArgs(404, testUserId).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 0))
 			},
 			input: args{
 				id: 404,
@@ -380,8 +621,8 @@ func TestDeleteTodoById(t *testing.T) {
 		{
 			name: "Query Error",
 			mock: func() {
-				mockDB.ExpectExec("DELETE FROM todo WHERE id = \\$1").
-					WithArgs(1).
+				mockDB.ExpectExec("WITH RECURSIVE subtree AS \\( SELECT id FROM todo WHERE id = \\$1 AND owner_id = \\$2 AND deleted_at IS NULL (.+) \\) UPDATE todo SET deleted_at = NOW\\(\\) WHERE id IN \\(SELECT id FROM subtree\\)").
+					WithArgs(1, testUserId).
 					WillReturnError(errors.New("query error"))
 			},
 			input: args{
@@ -395,11 +636,102 @@ func TestDeleteTodoById(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mock()
 
-			err := r.DeleteTodoById(context.Background(), tt.input.id)
+			err := r.DeleteTodoById(context.Background(), testUserId, tt.input.id, nil)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mockDB.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestPatchTodo(t *testing.T) {
+	mockDB, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer mockDB.Close(context.Background())
+
+	r := NewTodoRepo(NewDB(mockDB), testMaxDepth)
+
+	type args struct {
+		id    int
+		patch models.TodoPatch
+	}
+	tests := []struct {
+		name    string
+		mock    func()
+		input   args
+		want    models.TodoModel
+		wantErr bool
+	}{
+		{
+			name: "Ok_Completed",
+			mock: func() {
+				rows := pgxmock.NewRows(todoRowColumns).
+					AddRow(1, "title", "description", true, time.Now(), nil, nil, nil, nil, 0, nil, nil, nil, 1, 0, 0)
+				mockDB.ExpectQuery("UPDATE todo SET completed = \\$1, version = version \\+ 1 WHERE id = \\$2 AND owner_id = \\$3 AND deleted_at IS NULL RETURNING (.+)").
+					WithArgs(true, 1, testUserId).
+					WillReturnRows(rows)
+				expectTodoTags(mockDB, pgxmock.NewRows(tagRowColumns), 1)
+			},
+			input: args{
+				id:    1,
+				patch: models.TodoPatch{Completed: models.PatchField[bool]{Set: true, Value: true}},
+			},
+			want:    models.TodoModel{Id: 1, Title: "title", Description: "description", Completed: true, CreatedAt: time.Now()},
+			wantErr: false,
+		},
+		{
+			name: "Ok_ClearDescription",
+			mock: func() {
+				rows := pgxmock.NewRows(todoRowColumns).
+					AddRow(1, "new title", "", false, time.Now(), nil, nil, nil, nil, 0, nil, nil, nil, 1, 0, 0)
+				mockDB.ExpectQuery("UPDATE todo SET title = \\$1, description = \\$2, version = version \\+ 1 WHERE id = \\$3 AND owner_id = \\$4 AND deleted_at IS NULL RETURNING (.+)").
+					WithArgs("new title", nil, 1, testUserId).
+					WillReturnRows(rows)
+				expectTodoTags(mockDB, pgxmock.NewRows(tagRowColumns), 1)
+			},
+			input: args{
+				id: 1,
+				patch: models.TodoPatch{
+					Title:       models.PatchField[string]{Set: true, Value: "new title"},
+					Description: models.PatchField[string]{Set: true, Null: true},
+				},
+			},
+			want:    models.TodoModel{Id: 1, Title: "new title", Description: "", Completed: false, CreatedAt: time.Now()},
+			wantErr: false,
+		},
+		{
+			name: "Not Found",
+			mock: func() {
+				mockDB.ExpectQuery("UPDATE todo SET completed = \\$1, version = version \\+ 1 WHERE id = \\$2 AND owner_id = \\$3 AND deleted_at IS NULL RETURNING (.+)").
+					WithArgs(true, 404, testUserId).
+					WillReturnError(pgx.ErrNoRows)
+			},
+			input: args{
+				id:    404,
+				patch: models.TodoPatch{Completed: models.PatchField[bool]{Set: true, Value: true}},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			got, _, err := r.PatchTodo(context.Background(), testUserId, tt.input.id, tt.input.patch, nil, nil)
 			if tt.wantErr {
 				assert.Error(t, err)
 			} else {
 				assert.NoError(t, err)
+				assert.Equal(t, tt.want.Id, got.Id)
+				assert.Equal(t, tt.want.Title, got.Title)
+				assert.Equal(t, tt.want.Description, got.Description)
+				assert.Equal(t, tt.want.Completed, got.Completed)
+				assert.WithinDuration(t, tt.want.CreatedAt, got.CreatedAt, time.Second)
 			}
 			assert.NoError(t, mockDB.ExpectationsWereMet())
 		})
@@ -0,0 +1,24 @@
+package repos
+
+import (
+	"context"
+	"github.com/cherrycutter/todo_app/internal/models"
+)
+
+type StatsRepositoryImpl struct {
+	db PgxConnIface
+}
+
+func NewStatsRepo(db PgxConnIface) StatsRepository {
+	return &StatsRepositoryImpl{db: db}
+}
+
+// CountAllTodos counts the todos of every user that are not in the trash, open ones are not completed
+func (r *StatsRepositoryImpl) CountAllTodos(ctx context.Context) (models.TodoCounts, error) {
+	var counts models.TodoCounts
+	query := "SELECT COUNT(*), COUNT(*) FILTER (WHERE NOT completed) FROM todo WHERE deleted_at IS NULL"
+	if err := r.db.QueryRow(ctx, query).Scan(&counts.Total, &counts.Open); err != nil {
+		return models.TodoCounts{}, err
+	}
+	return counts, nil
+}
@@ -0,0 +1,27 @@
+package repos
+
+import (
+	"context"
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCountAllTodos(t *testing.T) {
+	mockDB, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer mockDB.Close(context.Background())
+
+	r := NewStatsRepo(NewDB(mockDB))
+
+	mockDB.ExpectQuery("SELECT COUNT\\(\\*\\), COUNT\\(\\*\\) FILTER \\(WHERE NOT completed\\) FROM todo WHERE deleted_at IS NULL").
+		WillReturnRows(pgxmock.NewRows([]string{"count", "open"}).AddRow(int64(12), int64(5)))
+
+	counts, err := r.CountAllTodos(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, models.TodoCounts{Total: 12, Open: 5}, counts)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
@@ -0,0 +1,211 @@
+package repos
+
+import (
+	"context"
+	"errors"
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+type TagRepositoryImpl struct {
+	db PgxConnIface
+}
+
+func NewTagRepo(db PgxConnIface) TagRepository {
+	return &TagRepositoryImpl{db: db}
+}
+
+var (
+	ErrTagNotFound = errors.New("tag not found")
+	ErrTagExists   = errors.New("tag with this name already exists")
+)
+
+// tagColumns is the column list scanned by scanTag
+const tagColumns = "id, name, COALESCE(color, ''), COALESCE(description, ''), created_at"
+
+// scanTag scans a row selected with tagColumns
+func scanTag(row pgx.Row, tag *models.TagModel) error {
+	return row.Scan(&tag.Id, &tag.Name, &tag.Color, &tag.Description, &tag.CreatedAt)
+}
+
+func (r *TagRepositoryImpl) GetAllTags(ctx context.Context, ownerId int) ([]models.TagModel, error) {
+	rows, err := r.db.Query(ctx, "SELECT "+tagColumns+" FROM tag WHERE owner_id = $1 ORDER BY name", ownerId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []models.TagModel{}
+	for rows.Next() {
+		var tag models.TagModel
+		if err = scanTag(rows, &tag); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+func (r *TagRepositoryImpl) GetTagById(ctx context.Context, ownerId, id int) (models.TagModel, error) {
+	var tag models.TagModel
+	err := scanTag(r.db.QueryRow(ctx, "SELECT "+tagColumns+" FROM tag WHERE id = $1 AND owner_id = $2", id, ownerId), &tag)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.TagModel{}, ErrTagNotFound
+		}
+		return models.TagModel{}, err
+	}
+	return tag, nil
+}
+
+func (r *TagRepositoryImpl) CreateTag(ctx context.Context, ownerId int, tag models.TagModel) (models.TagModel, error) {
+	query := `
+		INSERT INTO tag (name, color, description, owner_id)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`
+	err := r.db.QueryRow(ctx, query, tag.Name, tag.Color, tag.Description, ownerId).Scan(&tag.Id, &tag.CreatedAt)
+	if err != nil {
+		if isPgError(err, pgUniqueViolation) {
+			return models.TagModel{}, ErrTagExists
+		}
+		return models.TagModel{}, err
+	}
+	return tag, nil
+}
+
+// touchTaggedTodos gives a new version to the todos carrying the tag $1 of the owner $2, their tags are part of them
+const touchTaggedTodos = `
+		WITH touched AS (
+			UPDATE todo SET version = version + 1
+			WHERE id IN (SELECT tt.todo_id FROM todo_tag tt JOIN tag t ON t.id = tt.tag_id WHERE t.id = $1 AND t.owner_id = $2)
+		)`
+
+// UpdateTag replaces a tag, the todos carrying it get a new version
+func (r *TagRepositoryImpl) UpdateTag(ctx context.Context, ownerId, id int, tag models.TagModel) (models.TagModel, error) {
+	query := touchTaggedTodos + `
+		UPDATE tag
+		SET name = $3, color = $4, description = $5
+		WHERE id = $1 AND owner_id = $2
+		RETURNING ` + tagColumns
+	var updatedTag models.TagModel
+	err := scanTag(r.db.QueryRow(ctx, query, id, ownerId, tag.Name, tag.Color, tag.Description), &updatedTag)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.TagModel{}, ErrTagNotFound
+		}
+		if isPgError(err, pgUniqueViolation) {
+			return models.TagModel{}, ErrTagExists
+		}
+		return models.TagModel{}, err
+	}
+	return updatedTag, nil
+}
+
+// DeleteTagById deletes a tag, the todos that carried it get a new version
+func (r *TagRepositoryImpl) DeleteTagById(ctx context.Context, ownerId, id int) error {
+	cmdTag, err := r.db.Exec(ctx, touchTaggedTodos+" DELETE FROM tag WHERE id = $1 AND owner_id = $2", id, ownerId)
+	if err != nil {
+		return err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return ErrTagNotFound
+	}
+	return nil
+}
+
+// AttachTag links a tag to a todo when both belong to the owner, attaching a tag twice is a no-op
+func (r *TagRepositoryImpl) AttachTag(ctx context.Context, ownerId, todoId, tagId int) error {
+	query := `
+		WITH owned_todo AS (
+			SELECT id FROM todo WHERE id = $1 AND owner_id = $3 AND deleted_at IS NULL
+		), owned_tag AS (
+			SELECT id FROM tag WHERE id = $2 AND owner_id = $3
+		), attached AS (
+			INSERT INTO todo_tag (todo_id, tag_id)
+			SELECT owned_todo.id, owned_tag.id FROM owned_todo, owned_tag
+			ON CONFLICT DO NOTHING
+			RETURNING todo_id
+		), touched AS (
+			UPDATE todo SET version = version + 1 WHERE id IN (SELECT todo_id FROM attached)
+		)
+		SELECT EXISTS (SELECT 1 FROM owned_todo), EXISTS (SELECT 1 FROM owned_tag)
+	`
+	var todoFound, tagFound bool
+	if err := r.db.QueryRow(ctx, query, todoId, tagId, ownerId).Scan(&todoFound, &tagFound); err != nil {
+		return err
+	}
+	if !todoFound {
+		return ErrTodoNotFound
+	}
+	if !tagFound {
+		return ErrTagNotFound
+	}
+	return nil
+}
+
+// DetachTag removes the link between a tag and a todo of the owner, ErrTagNotFound is returned when the todo
+// does not carry the tag
+func (r *TagRepositoryImpl) DetachTag(ctx context.Context, ownerId, todoId, tagId int) error {
+	query := `
+		WITH detached AS (
+			DELETE FROM todo_tag tt
+			USING todo t
+			WHERE t.id = tt.todo_id AND tt.todo_id = $1 AND tt.tag_id = $2 AND t.owner_id = $3
+			RETURNING tt.todo_id
+		)
+		UPDATE todo SET version = version + 1 WHERE id IN (SELECT todo_id FROM detached)
+	`
+	cmdTag, err := r.db.Exec(ctx, query, todoId, tagId, ownerId)
+	if err != nil {
+		return err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return ErrTagNotFound
+	}
+	return nil
+}
+
+// loadTodoTags fills the tags of all given todos with a single query
+func loadTodoTags(ctx context.Context, db PgxConnIface, todos []models.TodoModel) error {
+	if len(todos) == 0 {
+		return nil
+	}
+	ids := make([]int, 0, len(todos))
+	index := make(map[int]int, len(todos))
+	for i := range todos {
+		todos[i].Tags = []models.TagModel{}
+		ids = append(ids, todos[i].Id)
+		index[todos[i].Id] = i
+	}
+
+	query := `
+		SELECT tt.todo_id, t.id, t.name, COALESCE(t.color, ''), COALESCE(t.description, ''), t.created_at
+		FROM todo_tag tt
+		JOIN tag t ON t.id = tt.tag_id
+		WHERE tt.todo_id = ANY($1)
+		ORDER BY t.name
+	`
+	rows, err := db.Query(ctx, query, ids)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var todoId int
+		var tag models.TagModel
+		if err = rows.Scan(&todoId, &tag.Id, &tag.Name, &tag.Color, &tag.Description, &tag.CreatedAt); err != nil {
+			return err
+		}
+		if i, ok := index[todoId]; ok {
+			todos[i].Tags = append(todos[i].Tags, tag)
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,281 @@
+package repos
+
+import (
+	"context"
+	"errors"
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestCreateTag(t *testing.T) {
+	mockDB, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer mockDB.Close(context.Background())
+
+	r := NewTagRepo(NewDB(mockDB))
+
+	tests := []struct {
+		name    string
+		mock    func()
+		input   models.TagModel
+		want    models.TagModel
+		wantErr error
+	}{
+		{
+			name: "Ok",
+			mock: func() {
+				rows := pgxmock.NewRows([]string{"id", "created_at"}).AddRow(1, time.Now())
+				mockDB.ExpectQuery("INSERT INTO tag").
+					WithArgs("backend", "#1e90ff", "", testUserId).
+					WillReturnRows(rows)
+			},
+			input: models.TagModel{Name: "backend", Color: "#1e90ff"},
+			want:  models.TagModel{Id: 1, Name: "backend", Color: "#1e90ff"},
+		},
+		{
+			name: "Duplicate Name",
+			mock: func() {
+				mockDB.ExpectQuery("INSERT INTO tag").
+					WithArgs("backend", "", "", testUserId).
+					WillReturnError(&pgconn.PgError{Code: pgUniqueViolation})
+			},
+			input:   models.TagModel{Name: "backend"},
+			wantErr: ErrTagExists,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			got, err := r.CreateTag(context.Background(), testUserId, tt.input)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want.Id, got.Id)
+				assert.Equal(t, tt.want.Name, got.Name)
+				assert.Equal(t, tt.want.Color, got.Color)
+			}
+			assert.NoError(t, mockDB.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestUpdateTag(t *testing.T) {
+	mockDB, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer mockDB.Close(context.Background())
+
+	r := NewTagRepo(NewDB(mockDB))
+	query := "WITH touched AS \\( UPDATE todo SET version = version \\+ 1 WHERE id IN \\(SELECT tt.todo_id FROM todo_tag tt (.+)\\) UPDATE tag SET name = \\$3"
+
+	tests := []struct {
+		name    string
+		mock    func()
+		wantErr error
+	}{
+		{
+			name: "Ok",
+			mock: func() {
+				rows := pgxmock.NewRows([]string{"id", "name", "color", "description", "created_at"}).AddRow(2, "frontend", "", "", time.Now())
+				mockDB.ExpectQuery(query).
+					WithArgs(2, testUserId, "frontend", "", "").
+					WillReturnRows(rows)
+			},
+		},
+		{
+			name: "Not Found",
+			mock: func() {
+				mockDB.ExpectQuery(query).
+					WithArgs(2, testUserId, "frontend", "", "").
+					WillReturnRows(pgxmock.NewRows([]string{"id", "name", "color", "description", "created_at"}))
+			},
+			wantErr: ErrTagNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			got, err := r.UpdateTag(context.Background(), testUserId, 2, models.TagModel{Name: "frontend"})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, "frontend", got.Name)
+			}
+			assert.NoError(t, mockDB.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestDeleteTagById(t *testing.T) {
+	mockDB, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer mockDB.Close(context.Background())
+
+	r := NewTagRepo(NewDB(mockDB))
+	query := "WITH touched AS \\( UPDATE todo SET version = version \\+ 1 (.+)\\) DELETE FROM tag WHERE id = \\$1 AND owner_id = \\$2"
+
+	tests := []struct {
+		name    string
+		mock    func()
+		wantErr error
+	}{
+		{
+			name: "Ok",
+			mock: func() {
+				mockDB.ExpectExec(query).
+					WithArgs(2, testUserId).
+					WillReturnResult(pgxmock.NewResult("DELETE", 1))
+			},
+		},
+		{
+			name: "Not Found",
+			mock: func() {
+				mockDB.ExpectExec(query).
+					WithArgs(2, testUserId).
+					WillReturnResult(pgxmock.NewResult("DELETE", 0))
+			},
+			wantErr: ErrTagNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			err := r.DeleteTagById(context.Background(), testUserId, 2)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mockDB.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestAttachTag(t *testing.T) {
+	mockDB, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer mockDB.Close(context.Background())
+
+	r := NewTagRepo(NewDB(mockDB))
+
+	tests := []struct {
+		name    string
+		mock    func()
+		wantErr error
+	}{
+		{
+			name: "Ok",
+			mock: func() {
+				rows := pgxmock.NewRows([]string{"todo_found", "tag_found"}).AddRow(true, true)
+				mockDB.ExpectQuery("WITH owned_todo AS \\( SELECT id FROM todo WHERE id = \\$1 AND owner_id = \\$3 AND deleted_at IS NULL \\), owned_tag AS \\( SELECT id FROM tag WHERE id = \\$2 AND owner_id = \\$3 \\), attached AS \\( INSERT INTO todo_tag (.+) ON CONFLICT DO NOTHING RETURNING todo_id \\), touched AS \\( UPDATE todo SET version = version \\+ 1 WHERE id IN \\(SELECT todo_id FROM attached\\) \\)").
+					WithArgs(1, 2, testUserId).
+					WillReturnRows(rows)
+			},
+		},
+		{
+			name: "Todo Not Found",
+			mock: func() {
+				rows := pgxmock.NewRows([]string{"todo_found", "tag_found"}).AddRow(false, true)
+				mockDB.ExpectQuery("INSERT INTO todo_tag").
+					WithArgs(1, 2, testUserId).
+					WillReturnRows(rows)
+			},
+			wantErr: ErrTodoNotFound,
+		},
+		{
+			name: "Tag Not Found",
+			mock: func() {
+				rows := pgxmock.NewRows([]string{"todo_found", "tag_found"}).AddRow(true, false)
+				mockDB.ExpectQuery("INSERT INTO todo_tag").
+					WithArgs(1, 2, testUserId).
+					WillReturnRows(rows)
+			},
+			wantErr: ErrTagNotFound,
+		},
+		{
+			name: "Query Error",
+			mock: func() {
+				mockDB.ExpectQuery("INSERT INTO todo_tag").
+					WithArgs(1, 2, testUserId).
+					WillReturnError(errors.New("query error"))
+			},
+			wantErr: errors.New("query error"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			err := r.AttachTag(context.Background(), testUserId, 1, 2)
+			if tt.wantErr != nil {
+				assert.EqualError(t, err, tt.wantErr.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mockDB.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestDetachTag(t *testing.T) {
+	mockDB, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer mockDB.Close(context.Background())
+
+	r := NewTagRepo(NewDB(mockDB))
+
+	tests := []struct {
+		name    string
+		mock    func()
+		wantErr bool
+	}{
+		{
+			name: "Ok",
+			mock: func() {
+				mockDB.ExpectExec("DELETE FROM todo_tag tt USING todo t WHERE t.id = tt.todo_id AND tt.todo_id = \\$1 AND tt.tag_id = \\$2 AND t.owner_id = \\$3").
+					WithArgs(1, 2, testUserId).
+					WillReturnResult(pgxmock.NewResult("DELETE", 1))
+			},
+		},
+		{
+			name: "Not Attached",
+			mock: func() {
+				mockDB.ExpectExec("DELETE FROM todo_tag tt USING todo t WHERE t.id = tt.todo_id AND tt.todo_id = \\$1 AND tt.tag_id = \\$2 AND t.owner_id = \\$3").
+					WithArgs(1, 2, testUserId).
+					WillReturnResult(pgxmock.NewResult("DELETE", 0))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			err := r.DetachTag(context.Background(), testUserId, 1, 2)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrTagNotFound)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mockDB.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package repos
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// LockTodo returns a todo and locks it until the end of the transaction, so that the todo written next
+// can be compared with it
+func (r *TodoRepositoryImpl) LockTodo(ctx context.Context, ownerId, id int) (models.TodoModel, error) {
+	var todo models.TodoModel
+	query := "SELECT " + todoColumns + " FROM todo WHERE id = $1 AND owner_id = $2 AND deleted_at IS NULL FOR UPDATE"
+	if err := scanTodo(r.db.QueryRow(ctx, query, id, ownerId), &todo); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.TodoModel{}, ErrTodoNotFound
+		}
+		return models.TodoModel{}, err
+	}
+	return r.withTags(ctx, todo)
+}
+
+// LockSubtree returns a todo of the owner with its subtasks at any depth, in the trash or not, ordered by id.
+// They stay locked until the end of the transaction, so that the todos written next can be compared with them
+func (r *TodoRepositoryImpl) LockSubtree(ctx context.Context, ownerId, id int) ([]models.TodoModel, error) {
+	query := `
+		WITH RECURSIVE subtree AS (
+			SELECT id FROM todo WHERE id = $1 AND owner_id = $2
+			UNION ALL
+			SELECT t.id FROM todo t JOIN subtree s ON t.parent_id = s.id
+		)
+		SELECT ` + todoColumns + ` FROM todo WHERE id IN (SELECT id FROM subtree) ORDER BY id FOR UPDATE
+	`
+	todos, err := r.lockTodos(ctx, query, id, ownerId)
+	if err != nil {
+		return nil, err
+	}
+	if len(todos) == 0 {
+		return nil, ErrTodoNotFound
+	}
+	return todos, nil
+}
+
+// LockProjectTodos returns the todos of a project of the owner, in the trash or not, with the subtasks at any depth
+// of those outside the trash, wherever the subtasks live. They are ordered by id and locked like LockSubtree
+func (r *TodoRepositoryImpl) LockProjectTodos(ctx context.Context, ownerId, projectId int) ([]models.TodoModel, error) {
+	query := `
+		WITH RECURSIVE affected AS (
+			SELECT id, deleted_at FROM todo WHERE project_id = $1 AND owner_id = $2
+			UNION ALL
+			SELECT t.id, t.deleted_at FROM todo t JOIN affected a ON t.parent_id = a.id
+			WHERE a.deleted_at IS NULL AND t.deleted_at IS NULL
+		)
+		SELECT ` + todoColumns + ` FROM todo WHERE id IN (SELECT id FROM affected) ORDER BY id FOR UPDATE
+	`
+	return r.lockTodos(ctx, query, projectId, ownerId)
+}
+
+// LockTaggedTodos returns the todos of the owner carrying a tag, in the trash or not, ordered by id and locked
+// like LockSubtree
+func (r *TodoRepositoryImpl) LockTaggedTodos(ctx context.Context, ownerId, tagId int) ([]models.TodoModel, error) {
+	query := `
+		SELECT ` + todoColumns + ` FROM todo
+		WHERE id IN (SELECT todo_id FROM todo_tag WHERE tag_id = $1) AND owner_id = $2
+		ORDER BY id FOR UPDATE
+	`
+	return r.lockTodos(ctx, query, tagId, ownerId)
+}
+
+// LockTodos returns the todos of the owner with the given ids, in the trash or not, ordered by id and locked
+// like LockSubtree. Ids of other owners or of deleted todos are left out
+func (r *TodoRepositoryImpl) LockTodos(ctx context.Context, ownerId int, ids []int) ([]models.TodoModel, error) {
+	query := "SELECT " + todoColumns + " FROM todo WHERE id = ANY($1) AND owner_id = $2 ORDER BY id FOR UPDATE"
+	return r.lockTodos(ctx, query, ids, ownerId)
+}
+
+// lockTodos runs a query selecting todoColumns and returns its todos with their tags
+func (r *TodoRepositoryImpl) lockTodos(ctx context.Context, query string, args ...interface{}) ([]models.TodoModel, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var todos []models.TodoModel
+	for rows.Next() {
+		var todo models.TodoModel
+		if err = scanTodo(rows, &todo); err != nil {
+			return nil, err
+		}
+		todos = append(todos, todo)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	if err = loadTodoTags(ctx, r.db, todos); err != nil {
+		return nil, err
+	}
+	return todos, nil
+}
+
+// Projects returns the project repository on the connection of the todo repository, within InTx its statements
+// belong to the transaction
+func (r *TodoRepositoryImpl) Projects() ProjectRepository {
+	return NewProjectRepo(r.db)
+}
+
+// Tags returns the tag repository on the connection of the todo repository, like Projects
+func (r *TodoRepositoryImpl) Tags() TagRepository {
+	return NewTagRepo(r.db)
+}
+
+// AddAuditEntries records changes of todos of the owner in a single statement
+func (r *TodoRepositoryImpl) AddAuditEntries(ctx context.Context, ownerId int, entries []models.AuditEntry) error {
+	todoIds := make([]int, len(entries))
+	actorIds := make([]int, len(entries))
+	operations := make([]string, len(entries))
+	changes := make([]string, len(entries))
+	for i, entry := range entries {
+		raw, err := json.Marshal(entry.Changes)
+		if err != nil {
+			return err
+		}
+		todoIds[i], actorIds[i], operations[i], changes[i] = entry.TodoId, entry.ActorId, string(entry.Operation), string(raw)
+	}
+	query := `
+		INSERT INTO todo_audit (owner_id, todo_id, actor_id, operation, changes)
+		SELECT $1, e.todo_id, e.actor_id, e.operation, e.changes::jsonb
+		FROM unnest($2::int[], $3::int[], $4::text[], $5::text[]) AS e(todo_id, actor_id, operation, changes)
+	`
+	_, err := r.db.Exec(ctx, query, ownerId, todoIds, actorIds, operations, changes)
+	return err
+}
+
+// GetAuditEntries returns the audit entries of the owner newest first, continuing after the cursor when given
+func (r *TodoRepositoryImpl) GetAuditEntries(ctx context.Context, ownerId int, filter models.AuditFilter, limit int, after *models.AuditCursor) ([]models.AuditEntry, error) {
+	var qb queryBuilder
+	qb.where("owner_id = " + qb.arg(ownerId))
+	if filter.TodoId != nil {
+		qb.where("todo_id = " + qb.arg(*filter.TodoId))
+	}
+	if filter.ActorId != nil {
+		qb.where("actor_id = " + qb.arg(*filter.ActorId))
+	}
+	if filter.Operation != nil {
+		qb.where("operation = " + qb.arg(string(*filter.Operation)))
+	}
+	if filter.From != nil {
+		qb.where("created_at >= " + qb.arg(*filter.From))
+	}
+	if filter.To != nil {
+		qb.where("created_at < " + qb.arg(*filter.To))
+	}
+	if after != nil {
+		qb.where("id < " + qb.arg(after.Id))
+	}
+	query := "SELECT id, todo_id, actor_id, operation, changes, created_at FROM todo_audit" + qb.whereClause() +
+		" ORDER BY id DESC LIMIT " + qb.arg(limit)
+
+	rows, err := r.db.Query(ctx, query, qb.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []models.AuditEntry{}
+	for rows.Next() {
+		var entry models.AuditEntry
+		if err = rows.Scan(&entry.Id, &entry.TodoId, &entry.ActorId, &entry.Operation, &entry.Changes, &entry.CreatedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
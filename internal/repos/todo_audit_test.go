@@ -0,0 +1,293 @@
+package repos
+
+import (
+	"context"
+	"errors"
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestLockTodo(t *testing.T) {
+	mockDB, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer mockDB.Close(context.Background())
+
+	r := NewTodoRepo(NewDB(mockDB), testMaxDepth)
+
+	now := time.Now()
+	lockQuery := "SELECT (.+) FROM todo WHERE id = \\$1 AND owner_id = \\$2 AND deleted_at IS NULL FOR UPDATE"
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    models.TodoModel
+		wantErr error
+	}{
+		{
+			name: "Ok",
+			mock: func() {
+				rows := pgxmock.NewRows(todoRowColumns).
+					AddRow(1, "title", "", false, now, noTime, noTime, noProject, nil, 0, nil, nil, nil, 1, 0, 0)
+				mockDB.ExpectQuery(lockQuery).
+					WithArgs(1, testUserId).
+					WillReturnRows(rows)
+				expectTodoTags(mockDB, pgxmock.NewRows(tagRowColumns), 1)
+			},
+			want: models.TodoModel{Id: 1, Title: "title", CreatedAt: now, Version: 1, Tags: []models.TagModel{}},
+		},
+		{
+			name: "Not Found",
+			mock: func() {
+				mockDB.ExpectQuery(lockQuery).
+					WithArgs(1, testUserId).
+					WillReturnError(pgx.ErrNoRows)
+			},
+			wantErr: ErrTodoNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			got, err := r.LockTodo(context.Background(), testUserId, 1)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+			assert.NoError(t, mockDB.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestLockSubtree(t *testing.T) {
+	mockDB, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer mockDB.Close(context.Background())
+
+	r := NewTodoRepo(NewDB(mockDB), testMaxDepth)
+
+	now := time.Now()
+	parentId := 1
+	lockQuery := "WITH RECURSIVE subtree AS \\( SELECT id FROM todo WHERE id = \\$1 AND owner_id = \\$2 UNION ALL (.+) \\) " +
+		"SELECT (.+) FROM todo WHERE id IN \\(SELECT id FROM subtree\\) ORDER BY id FOR UPDATE"
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    []models.TodoModel
+		wantErr error
+	}{
+		{
+			name: "Ok",
+			mock: func() {
+				rows := pgxmock.NewRows(todoRowColumns).
+					AddRow(1, "parent", "", true, now, noTime, noTime, noProject, nil, 0, nil, nil, &now, 2, 1, 1).
+					AddRow(2, "subtask", "", true, now, noTime, noTime, noProject, &parentId, 0, nil, nil, &now, 3, 0, 0)
+				mockDB.ExpectQuery(lockQuery).
+					WithArgs(1, testUserId).
+					WillReturnRows(rows)
+				expectTodoTags(mockDB, pgxmock.NewRows(tagRowColumns), 1, 2)
+			},
+			want: []models.TodoModel{
+				{Id: 1, Title: "parent", Completed: true, CreatedAt: now, DeletedAt: &now, Version: 2, Progress: &models.TodoProgress{Done: 1, Total: 1}, Tags: []models.TagModel{}},
+				{Id: 2, Title: "subtask", Completed: true, CreatedAt: now, ParentId: &parentId, DeletedAt: &now, Version: 3, Tags: []models.TagModel{}},
+			},
+		},
+		{
+			name: "Not Found",
+			mock: func() {
+				mockDB.ExpectQuery(lockQuery).
+					WithArgs(1, testUserId).
+					WillReturnRows(pgxmock.NewRows(todoRowColumns))
+			},
+			wantErr: ErrTodoNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			got, err := r.LockSubtree(context.Background(), testUserId, 1)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+			assert.NoError(t, mockDB.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestLockAffectedTodos(t *testing.T) {
+	mockDB, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer mockDB.Close(context.Background())
+
+	r := NewTodoRepo(NewDB(mockDB), testMaxDepth)
+
+	now := time.Now()
+	projectId := 2
+	tests := []struct {
+		name  string
+		query string
+		arg   interface{}
+		lock  func() ([]models.TodoModel, error)
+	}{
+		{
+			name: "Project",
+			query: "WITH RECURSIVE affected AS \\( SELECT id, deleted_at FROM todo WHERE project_id = \\$1 AND owner_id = \\$2 (.+) \\) " +
+				"SELECT (.+) FROM todo WHERE id IN \\(SELECT id FROM affected\\) ORDER BY id FOR UPDATE",
+			arg: 2,
+			lock: func() ([]models.TodoModel, error) {
+				return r.LockProjectTodos(context.Background(), testUserId, 2)
+			},
+		},
+		{
+			name:  "Tagged",
+			query: "SELECT (.+) FROM todo WHERE id IN \\(SELECT todo_id FROM todo_tag WHERE tag_id = \\$1\\) AND owner_id = \\$2 ORDER BY id FOR UPDATE",
+			arg:   3,
+			lock: func() ([]models.TodoModel, error) {
+				return r.LockTaggedTodos(context.Background(), testUserId, 3)
+			},
+		},
+		{
+			name:  "Ids",
+			query: "SELECT (.+) FROM todo WHERE id = ANY\\(\\$1\\) AND owner_id = \\$2 ORDER BY id FOR UPDATE",
+			arg:   []int{1},
+			lock: func() ([]models.TodoModel, error) {
+				return r.LockTodos(context.Background(), testUserId, []int{1})
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows := pgxmock.NewRows(todoRowColumns).
+				AddRow(1, "todo", "", false, now, noTime, noTime, &projectId, nil, 0, nil, nil, nil, 4, 0, 0)
+			mockDB.ExpectQuery(tt.query).
+				WithArgs(tt.arg, testUserId).
+				WillReturnRows(rows)
+			expectTodoTags(mockDB, pgxmock.NewRows(tagRowColumns).AddRow(1, 3, "home", "", "", now), 1)
+
+			got, err := tt.lock()
+			assert.NoError(t, err)
+			if assert.Len(t, got, 1) {
+				assert.Equal(t, 4, got[0].Version)
+				assert.Equal(t, []models.TagModel{{Id: 3, Name: "home", CreatedAt: now}}, got[0].Tags)
+			}
+			assert.NoError(t, mockDB.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestAddAuditEntries(t *testing.T) {
+	mockDB, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer mockDB.Close(context.Background())
+
+	r := NewTodoRepo(NewDB(mockDB), testMaxDepth)
+
+	entries := []models.AuditEntry{
+		{TodoId: 1, ActorId: testUserId, Operation: models.AuditCreate, Changes: map[string]models.AuditChange{"title": {New: "title"}}},
+		{TodoId: 2, ActorId: testUserId, Operation: models.AuditDelete, Changes: map[string]models.AuditChange{}},
+	}
+	mockDB.ExpectExec("INSERT INTO todo_audit \\(owner_id, todo_id, actor_id, operation, changes\\) SELECT (.+) FROM unnest\\(\\$2::int\\[\\], \\$3::int\\[\\], \\$4::text\\[\\], \\$5::text\\[\\]\\)").
+		WithArgs(testUserId, []int{1, 2}, []int{testUserId, testUserId}, []string{"create", "delete"},
+			[]string{`{"title":{"old":null,"new":"title"}}`, `{}`}).
+		WillReturnResult(pgxmock.NewResult("INSERT", 2))
+
+	assert.NoError(t, r.AddAuditEntries(context.Background(), testUserId, entries))
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestGetAuditEntries(t *testing.T) {
+	mockDB, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer mockDB.Close(context.Background())
+
+	r := NewTodoRepo(NewDB(mockDB), testMaxDepth)
+
+	now := time.Now()
+	to := now.Add(time.Hour)
+	todoId := 1
+	operation := models.AuditUpdate
+	columns := []string{"id", "todo_id", "actor_id", "operation", "changes", "created_at"}
+	changes := map[string]models.AuditChange{"completed": {Old: false, New: true}}
+
+	type args struct {
+		filter models.AuditFilter
+		after  *models.AuditCursor
+	}
+	tests := []struct {
+		name    string
+		mock    func()
+		input   args
+		want    []models.AuditEntry
+		wantErr error
+	}{
+		{
+			name: "Ok",
+			mock: func() {
+				rows := pgxmock.NewRows(columns).AddRow(int64(2), 1, testUserId, models.AuditUpdate, changes, now)
+				mockDB.ExpectQuery("SELECT id, todo_id, actor_id, operation, changes, created_at FROM todo_audit WHERE owner_id = \\$1 ORDER BY id DESC LIMIT \\$2").
+					WithArgs(testUserId, 10).
+					WillReturnRows(rows)
+			},
+			want: []models.AuditEntry{
+				{Id: 2, TodoId: 1, ActorId: testUserId, Operation: models.AuditUpdate, Changes: changes, CreatedAt: now},
+			},
+		},
+		{
+			name: "Filtered After Cursor",
+			mock: func() {
+				mockDB.ExpectQuery("FROM todo_audit WHERE owner_id = \\$1 AND todo_id = \\$2 AND operation = \\$3 AND created_at >= \\$4 AND created_at < \\$5 AND id < \\$6 ORDER BY id DESC LIMIT \\$7").
+					WithArgs(testUserId, todoId, "update", now, to, int64(5), 10).
+					WillReturnRows(pgxmock.NewRows(columns))
+			},
+			input: args{
+				filter: models.AuditFilter{TodoId: &todoId, Operation: &operation, From: &now, To: &to},
+				after:  &models.AuditCursor{Id: 5},
+			},
+			want: []models.AuditEntry{},
+		},
+		{
+			name: "Query Error",
+			mock: func() {
+				mockDB.ExpectQuery("FROM todo_audit").
+					WithArgs(testUserId, 10).
+					WillReturnError(errors.New("query error"))
+			},
+			wantErr: errors.New("query error"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			got, err := r.GetAuditEntries(context.Background(), testUserId, tt.input.filter, 10, tt.input.after)
+			if tt.wantErr != nil {
+				assert.EqualError(t, err, tt.wantErr.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+			assert.NoError(t, mockDB.ExpectationsWereMet())
+		})
+	}
+}
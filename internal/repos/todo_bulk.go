@@ -0,0 +1,77 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/jackc/pgx/v5"
+	"slices"
+)
+
+// BatchError is returned by CreateTodos when the todo at Index could not be created, none of the todos were
+type BatchError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("todo %d: %s", e.Index, e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// CreateTodos inserts todos in a single batch, which postgres runs as one transaction unless the repository
+// already belongs to one. When subtasks are among them, their parents are checked and the batch sent within a
+// transaction that keeps the parents locked
+func (r *TodoRepositoryImpl) CreateTodos(ctx context.Context, ownerId int, todos []models.TodoModel) ([]models.TodoModel, error) {
+	if !slices.ContainsFunc(todos, func(todo models.TodoModel) bool { return todo.ParentId != nil }) {
+		return r.createTodos(ctx, ownerId, todos)
+	}
+	var created []models.TodoModel
+	err := r.db.WithTx(ctx, func(tx PgxConnIface) error {
+		txRepo := &TodoRepositoryImpl{db: tx, maxDepth: r.maxDepth}
+		for i, todo := range todos {
+			if todo.ParentId != nil {
+				if err := txRepo.checkParent(ctx, ownerId, 0, *todo.ParentId); err != nil {
+					return &BatchError{Index: i, Err: err}
+				}
+			}
+		}
+		var err error
+		created, err = txRepo.createTodos(ctx, ownerId, todos)
+		return err
+	})
+	return created, err
+}
+
+func (r *TodoRepositoryImpl) createTodos(ctx context.Context, ownerId int, todos []models.TodoModel) ([]models.TodoModel, error) {
+	batch := &pgx.Batch{}
+	for _, todo := range todos {
+		batch.Queue(createTodoQuery, createTodoArgs(ownerId, todo)...)
+	}
+	results := r.db.SendBatch(ctx, batch)
+	defer results.Close()
+
+	created := make([]models.TodoModel, 0, len(todos))
+	for i, todo := range todos {
+		createdTodo, err := scanCreatedTodo(results.QueryRow(), todo)
+		if err != nil {
+			return nil, &BatchError{Index: i, Err: err}
+		}
+		created = append(created, createdTodo)
+	}
+	if err := results.Close(); err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// InTx runs fn with a repository whose statements all belong to one transaction, the transaction is committed
+// when fn returns nil and rolled back otherwise
+func (r *TodoRepositoryImpl) InTx(ctx context.Context, fn func(repo TodoRepository) error) error {
+	return r.db.WithTx(ctx, func(tx PgxConnIface) error {
+		return fn(&TodoRepositoryImpl{db: tx, maxDepth: r.maxDepth})
+	})
+}
@@ -0,0 +1,144 @@
+package repos
+
+import (
+	"context"
+	"errors"
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestCreateTodos(t *testing.T) {
+	mockDB, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer mockDB.Close(context.Background())
+
+	r := NewTodoRepo(NewDB(mockDB), testMaxDepth)
+
+	now := time.Now()
+	projectId := 9
+	input := []models.TodoModel{{Title: "first"}, {Title: "second", ProjectId: &projectId}}
+	createdColumns := []string{"id", "created_at", "position", "version"}
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    []models.TodoModel
+		wantErr error
+	}{
+		{
+			name: "Ok",
+			mock: func() {
+				batch := mockDB.ExpectBatch()
+				batch.ExpectQuery("INSERT INTO todo").
+					WithArgs("first", "", false, noTime, noTime, noProject, testUserId, noProject, noRule).
+					WillReturnRows(pgxmock.NewRows(createdColumns).AddRow(1, now, 0, 1))
+				batch.ExpectQuery("INSERT INTO todo").
+					WithArgs("second", "", false, noTime, noTime, &projectId, testUserId, noProject, noRule).
+					WillReturnRows(pgxmock.NewRows(createdColumns).AddRow(2, now, 0, 1))
+			},
+			want: []models.TodoModel{
+				{Id: 1, Title: "first", CreatedAt: now, Version: 1, Tags: []models.TagModel{}},
+				{Id: 2, Title: "second", CreatedAt: now, ProjectId: &projectId, Version: 1, Tags: []models.TagModel{}},
+			},
+		},
+		{
+			name: "Project Not Found",
+			mock: func() {
+				batch := mockDB.ExpectBatch()
+				batch.ExpectQuery("INSERT INTO todo").
+					WithArgs("first", "", false, noTime, noTime, noProject, testUserId, noProject, noRule).
+					WillReturnRows(pgxmock.NewRows(createdColumns).AddRow(1, now, 0, 1))
+				batch.ExpectQuery("INSERT INTO todo").
+					WithArgs("second", "", false, noTime, noTime, &projectId, testUserId, noProject, noRule).
+					WillReturnError(&pgconn.PgError{Code: pgForeignKeyViolation})
+			},
+			wantErr: &BatchError{Index: 1, Err: ErrProjectNotFound},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			got, err := r.CreateTodos(context.Background(), testUserId, input)
+			if tt.wantErr != nil {
+				assert.Equal(t, tt.wantErr, err)
+				assert.ErrorIs(t, err, ErrProjectNotFound)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+			assert.NoError(t, mockDB.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestInTx(t *testing.T) {
+	mockDB, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer mockDB.Close(context.Background())
+
+	r := NewTodoRepo(NewDB(mockDB), testMaxDepth)
+
+	tests := []struct {
+		name    string
+		mock    func()
+		fnErr   error
+		wantErr error
+	}{
+		{
+			name: "Commit",
+			mock: func() {
+				mockDB.ExpectBegin()
+				mockDB.ExpectExec("WITH RECURSIVE subtree AS").
+					WithArgs(1, testUserId).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				mockDB.ExpectCommit()
+			},
+		},
+		{
+			name: "Rollback",
+			mock: func() {
+				mockDB.ExpectBegin()
+				mockDB.ExpectExec("WITH RECURSIVE subtree AS").
+					WithArgs(1, testUserId).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				mockDB.ExpectRollback()
+			},
+			fnErr:   errors.New("operation failed"),
+			wantErr: errors.New("operation failed"),
+		},
+		{
+			name: "Begin Error",
+			mock: func() {
+				mockDB.ExpectBegin().WillReturnError(errors.New("begin error"))
+			},
+			wantErr: errors.New("begin error"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			err := r.InTx(context.Background(), func(repo TodoRepository) error {
+				if err := repo.DeleteTodoById(context.Background(), testUserId, 1, nil); err != nil {
+					return err
+				}
+				return tt.fnErr
+			})
+			if tt.wantErr != nil {
+				assert.EqualError(t, err, tt.wantErr.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mockDB.ExpectationsWereMet())
+		})
+	}
+}
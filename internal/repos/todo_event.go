@@ -0,0 +1,45 @@
+package repos
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// TodoEventsChannel is notified of every new todo event with the payload "<owner id>:<event id>"
+const TodoEventsChannel = "todo_events"
+
+// AddTodoEvents appends changes of todos of the owner to the event log. The subscribers are notified when
+// the transaction commits, so they never see a change that was rolled back. It has to run in the transaction
+// of the changes: the events of an owner are written under a lock held until the commit, so that their ids
+// follow the order of the commits and a stream resumed after an id cannot miss an event committed later
+func (r *TodoRepositoryImpl) AddTodoEvents(ctx context.Context, ownerId int, events []models.TodoEvent) error {
+	todoIds := make([]int, len(events))
+	types := make([]string, len(events))
+	todos := make([]pgtype.Text, len(events))
+	for i, event := range events {
+		todoIds[i], types[i] = event.TodoId, string(event.Type)
+		if event.Todo != nil {
+			raw, err := json.Marshal(event.Todo)
+			if err != nil {
+				return err
+			}
+			todos[i] = pgtype.Text{String: string(raw), Valid: true}
+		}
+	}
+	if _, err := r.db.Exec(ctx, "SELECT pg_advisory_xact_lock(hashtext('"+TodoEventsChannel+"'), $1)", ownerId); err != nil {
+		return err
+	}
+	query := `
+		WITH inserted AS (
+			INSERT INTO todo_event (owner_id, todo_id, type, todo)
+			SELECT $1::int, e.todo_id, e.type, e.todo::jsonb
+			FROM unnest($2::int[], $3::text[], $4::text[]) AS e(todo_id, type, todo)
+			RETURNING id
+		)
+		SELECT pg_notify('` + TodoEventsChannel + `', concat($1::int, ':', id)) FROM inserted
+	`
+	_, err := r.db.Exec(ctx, query, ownerId, todoIds, types, todos)
+	return err
+}
@@ -0,0 +1,117 @@
+package repos
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestAddTodoEvents(t *testing.T) {
+	mockDB, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer mockDB.Close(context.Background())
+
+	r := NewTodoRepo(NewDB(mockDB), testMaxDepth)
+
+	now := time.Date(2024, 6, 1, 8, 0, 0, 0, time.UTC)
+	events := []models.TodoEvent{
+		{Type: models.TodoCreated, TodoId: 1, Todo: &models.TodoModel{Id: 1, Title: "title", CreatedAt: now, Version: 1}},
+		{Type: models.TodoDeleted, TodoId: 2},
+	}
+	todo, err := json.Marshal(events[0].Todo)
+	if err != nil {
+		t.Fatalf("failed to marshal todo: %v", err)
+	}
+	mockDB.ExpectExec("SELECT pg_advisory_xact_lock\\(hashtext\\('todo_events'\\), \\$1\\)").
+		WithArgs(testUserId).
+		WillReturnResult(pgxmock.NewResult("SELECT", 1))
+	mockDB.ExpectExec("WITH inserted AS \\( INSERT INTO todo_event \\(owner_id, todo_id, type, todo\\) (.+) RETURNING id \\) SELECT pg_notify\\('todo_events', concat\\(\\$1::int, ':', id\\)\\) FROM inserted").
+		WithArgs(testUserId, []int{1, 2}, []string{"created", "deleted"}, []pgtype.Text{{String: string(todo), Valid: true}, {}}).
+		WillReturnResult(pgxmock.NewResult("SELECT", 2))
+
+	assert.NoError(t, r.AddTodoEvents(context.Background(), testUserId, events))
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestGetEventsAfter(t *testing.T) {
+	mockDB, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer mockDB.Close(context.Background())
+
+	r := NewEventRepo(NewDB(mockDB))
+
+	now := time.Now()
+	columns := []string{"id", "type", "todo_id", "todo", "created_at"}
+	query := "SELECT id, type, todo_id, todo, created_at FROM todo_event WHERE owner_id = \\$1 AND id >= \\$2 ORDER BY id LIMIT \\$3"
+	todo := &models.TodoModel{Id: 1, Title: "title"}
+
+	tests := []struct {
+		name      string
+		mock      func()
+		limit     int
+		want      []models.TodoEvent
+		wantFound bool
+	}{
+		{
+			name: "Found",
+			mock: func() {
+				rows := pgxmock.NewRows(columns).
+					AddRow(int64(5), models.TodoCreated, 1, todo, now).
+					AddRow(int64(8), models.TodoUpdated, 1, todo, now).
+					AddRow(int64(9), models.TodoDeleted, 1, nil, now)
+				mockDB.ExpectQuery(query).WithArgs(testUserId, int64(5), 11).WillReturnRows(rows)
+			},
+			limit: 10,
+			want: []models.TodoEvent{
+				{Id: 8, Type: models.TodoUpdated, TodoId: 1, Todo: todo, CreatedAt: now},
+				{Id: 9, Type: models.TodoDeleted, TodoId: 1, CreatedAt: now},
+			},
+			wantFound: true,
+		},
+		{
+			name: "Purged",
+			mock: func() {
+				rows := pgxmock.NewRows(columns).
+					AddRow(int64(8), models.TodoUpdated, 1, todo, now).
+					AddRow(int64(9), models.TodoDeleted, 1, nil, now)
+				mockDB.ExpectQuery(query).WithArgs(testUserId, int64(5), 2).WillReturnRows(rows)
+			},
+			limit: 1,
+			want: []models.TodoEvent{
+				{Id: 8, Type: models.TodoUpdated, TodoId: 1, Todo: todo, CreatedAt: now},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			got, found, err := r.GetEventsAfter(context.Background(), testUserId, 5, tt.limit)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantFound, found)
+			assert.NoError(t, mockDB.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestParseEventNotification(t *testing.T) {
+	ownerId, eventId, ok := parseEventNotification("7:42")
+	assert.True(t, ok)
+	assert.Equal(t, 7, ownerId)
+	assert.Equal(t, int64(42), eventId)
+
+	for _, payload := range []string{"", "42", "7:", "x:42"} {
+		_, _, ok = parseEventNotification(payload)
+		assert.False(t, ok, payload)
+	}
+}
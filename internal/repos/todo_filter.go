@@ -0,0 +1,177 @@
+package repos
+
+import (
+	"fmt"
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/jackc/pgx/v5/pgtype"
+	"strings"
+	"time"
+)
+
+// FilterError reports a list query parameter the repository refuses to turn into SQL
+type FilterError struct {
+	Param string
+	Value string
+}
+
+func (e *FilterError) Error() string {
+	return fmt.Sprintf("invalid %s param: %q is not supported", e.Param, e.Value)
+}
+
+// todoSortColumns is the whitelist of fields todos can be sorted by, mapped to their column
+// and to the accessor of the matching cursor value. Nullable columns are wrapped so that keyset
+// comparisons never see a NULL, todos without a due date sort last
+var todoSortColumns = map[string]struct {
+	column string
+	value  func(c *models.TodoCursor) interface{}
+}{
+	"id":         {"id", func(c *models.TodoCursor) interface{} { return c.Id }},
+	"title":      {"title", func(c *models.TodoCursor) interface{} { return c.Title }},
+	"completed":  {"completed", func(c *models.TodoCursor) interface{} { return c.Completed }},
+	"created_at": {"created_at", func(c *models.TodoCursor) interface{} { return c.CreatedAt }},
+	"due_at":     {"COALESCE(due_at, 'infinity')", func(c *models.TodoCursor) interface{} { return infinityIfNil(c.DueAt) }},
+}
+
+var defaultTodoSort = []models.SortField{{Field: "created_at"}}
+
+// applyTodoFilter adds the conditions of the filter to the query
+func applyTodoFilter(qb *queryBuilder, filter models.TodoFilter) {
+	if filter.Trashed {
+		qb.where("deleted_at IS NOT NULL")
+	} else {
+		qb.where("deleted_at IS NULL")
+	}
+	if filter.Completed != nil {
+		qb.where("completed = " + qb.arg(*filter.Completed))
+	}
+	if filter.CreatedAfter != nil {
+		qb.where("created_at > " + qb.arg(*filter.CreatedAfter))
+	}
+	if filter.CreatedBefore != nil {
+		qb.where("created_at < " + qb.arg(*filter.CreatedBefore))
+	}
+	if filter.TitleContains != "" {
+		qb.where("title ILIKE " + qb.arg("%"+escapeLike(filter.TitleContains)+"%"))
+	}
+	if filter.DueFrom != nil {
+		qb.where("due_at >= " + qb.arg(*filter.DueFrom))
+	}
+	if filter.DueTo != nil {
+		qb.where("due_at < " + qb.arg(*filter.DueTo))
+	}
+	if filter.Overdue {
+		qb.where("due_at < NOW() AND NOT completed")
+	}
+	if filter.ProjectId != nil {
+		qb.where("project_id = " + qb.arg(*filter.ProjectId))
+	}
+	if filter.SeriesId != nil {
+		qb.where("series_id = " + qb.arg(*filter.SeriesId))
+	}
+	if len(filter.Tags) > 0 {
+		tagged := "SELECT tt.todo_id FROM todo_tag tt JOIN tag t ON t.id = tt.tag_id WHERE t.name = ANY(" + qb.arg(filter.Tags) + ")"
+		if filter.TagMatchAll {
+			tagged += " GROUP BY tt.todo_id HAVING COUNT(DISTINCT t.id) = " + qb.arg(countDistinct(filter.Tags))
+		}
+		qb.where("id IN (" + tagged + ")")
+	}
+}
+
+// countDistinct returns the number of distinct values
+func countDistinct(values []string) int {
+	seen := make(map[string]bool, len(values))
+	for _, v := range values {
+		seen[v] = true
+	}
+	return len(seen)
+}
+
+// todoOrder resolves the requested sort fields into whitelisted keys, the id is always appended
+// as the last key so that the ordering is stable
+func todoOrder(sort []models.SortField) ([]models.SortField, error) {
+	if len(sort) == 0 {
+		sort = defaultTodoSort
+	}
+	keys := make([]models.SortField, 0, len(sort)+1)
+	hasId := false
+	for _, f := range sort {
+		if _, ok := todoSortColumns[f.Field]; !ok {
+			return nil, &FilterError{Param: "sort", Value: f.Field}
+		}
+		hasId = hasId || f.Field == "id"
+		keys = append(keys, f)
+	}
+	if !hasId {
+		keys = append(keys, models.SortField{Field: "id"})
+	}
+	return keys, nil
+}
+
+// orderByClause renders the ORDER BY clause of the given keys
+func orderByClause(keys []models.SortField) string {
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		part := todoSortColumns[k.Field].column
+		if k.Desc {
+			part += " DESC"
+		}
+		parts = append(parts, part)
+	}
+	return " ORDER BY " + strings.Join(parts, ", ")
+}
+
+// applyKeyset adds the condition selecting the rows that come after the cursor in the given ordering
+func applyKeyset(qb *queryBuilder, keys []models.SortField, after *models.TodoCursor) {
+	sameDirection := true
+	for _, k := range keys {
+		sameDirection = sameDirection && k.Desc == keys[0].Desc
+	}
+
+	// a row comparison is what the (created_at, id) index can serve directly
+	if sameDirection {
+		columns := make([]string, 0, len(keys))
+		values := make([]string, 0, len(keys))
+		for _, k := range keys {
+			col := todoSortColumns[k.Field]
+			columns = append(columns, col.column)
+			values = append(values, qb.arg(col.value(after)))
+		}
+		op := ">"
+		if keys[0].Desc {
+			op = "<"
+		}
+		qb.where(fmt.Sprintf("(%s) %s (%s)", strings.Join(columns, ", "), op, strings.Join(values, ", ")))
+		return
+	}
+
+	// mixed directions: (a > x) OR (a = x AND b < y) OR (a = x AND b = y AND id > z)
+	alternatives := make([]string, 0, len(keys))
+	for i, k := range keys {
+		conds := make([]string, 0, i+1)
+		for _, prev := range keys[:i] {
+			col := todoSortColumns[prev.Field]
+			conds = append(conds, col.column+" = "+qb.arg(col.value(after)))
+		}
+		col := todoSortColumns[k.Field]
+		op := " > "
+		if k.Desc {
+			op = " < "
+		}
+		conds = append(conds, col.column+op+qb.arg(col.value(after)))
+		alternatives = append(alternatives, "("+strings.Join(conds, " AND ")+")")
+	}
+	qb.where("(" + strings.Join(alternatives, " OR ") + ")")
+}
+
+// infinityIfNil returns the timestamp matching COALESCE(column, 'infinity') for a nullable time
+func infinityIfNil(t *time.Time) pgtype.Timestamptz {
+	if t == nil {
+		return pgtype.Timestamptz{InfinityModifier: pgtype.Infinity, Valid: true}
+	}
+	return pgtype.Timestamptz{Time: *t, Valid: true}
+}
+
+// escapeLike escapes the wildcard characters of a LIKE pattern
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,167 @@
+package repos
+
+import (
+	"context"
+	"github.com/cherrycutter/todo_app/internal/models"
+	"time"
+)
+
+// QueryObserver records how long a method of a repository took and whether it failed
+type QueryObserver func(method string, duration time.Duration, err error)
+
+// instrumentedTodoRepo passes the duration of every method of a TodoRepository to an observer
+type instrumentedTodoRepo struct {
+	repo     TodoRepository
+	observer QueryObserver
+}
+
+// NewInstrumentedTodoRepo wraps a repository so that observer sees every call, also within its transactions
+func NewInstrumentedTodoRepo(repo TodoRepository, observer QueryObserver) TodoRepository {
+	return &instrumentedTodoRepo{repo: repo, observer: observer}
+}
+
+func (r *instrumentedTodoRepo) observe(method string, start time.Time, err *error) {
+	r.observer(method, time.Since(start), *err)
+}
+
+// InTx observes the whole transaction as well as every call made within it
+func (r *instrumentedTodoRepo) InTx(ctx context.Context, fn func(repo TodoRepository) error) (err error) {
+	defer r.observe("InTx", time.Now(), &err)
+	return r.repo.InTx(ctx, func(repo TodoRepository) error {
+		return fn(&instrumentedTodoRepo{repo: repo, observer: r.observer})
+	})
+}
+
+func (r *instrumentedTodoRepo) GetAllTodos(ctx context.Context, ownerId int, filter models.TodoFilter, limit int, after *models.TodoCursor) (items []models.TodoModel, err error) {
+	defer r.observe("GetAllTodos", time.Now(), &err)
+	return r.repo.GetAllTodos(ctx, ownerId, filter, limit, after)
+}
+
+func (r *instrumentedTodoRepo) CountTodos(ctx context.Context, ownerId int, filter models.TodoFilter) (n int, err error) {
+	defer r.observe("CountTodos", time.Now(), &err)
+	return r.repo.CountTodos(ctx, ownerId, filter)
+}
+
+func (r *instrumentedTodoRepo) GetTodoById(ctx context.Context, ownerId, id int) (result models.TodoModel, err error) {
+	defer r.observe("GetTodoById", time.Now(), &err)
+	return r.repo.GetTodoById(ctx, ownerId, id)
+}
+
+func (r *instrumentedTodoRepo) CreateTodo(ctx context.Context, ownerId int, todo models.TodoModel) (result models.TodoModel, err error) {
+	defer r.observe("CreateTodo", time.Now(), &err)
+	return r.repo.CreateTodo(ctx, ownerId, todo)
+}
+
+func (r *instrumentedTodoRepo) UpdateTodo(ctx context.Context, ownerId, id int, todo models.TodoModel, next *models.TodoOccurrence, ifMatch *int) (result models.TodoModel, spawned *models.TodoModel, err error) {
+	defer r.observe("UpdateTodo", time.Now(), &err)
+	return r.repo.UpdateTodo(ctx, ownerId, id, todo, next, ifMatch)
+}
+
+func (r *instrumentedTodoRepo) PatchTodo(ctx context.Context, ownerId, id int, patch models.TodoPatch, next *models.TodoOccurrence, ifMatch *int) (result models.TodoModel, spawned *models.TodoModel, err error) {
+	defer r.observe("PatchTodo", time.Now(), &err)
+	return r.repo.PatchTodo(ctx, ownerId, id, patch, next, ifMatch)
+}
+
+func (r *instrumentedTodoRepo) DeleteTodoById(ctx context.Context, ownerId, id int, ifMatch *int) (err error) {
+	defer r.observe("DeleteTodoById", time.Now(), &err)
+	return r.repo.DeleteTodoById(ctx, ownerId, id, ifMatch)
+}
+
+func (r *instrumentedTodoRepo) SearchTodos(ctx context.Context, ownerId int, search models.TodoSearch, limit int) (items []models.TodoSearchHit, err error) {
+	defer r.observe("SearchTodos", time.Now(), &err)
+	return r.repo.SearchTodos(ctx, ownerId, search, limit)
+}
+
+func (r *instrumentedTodoRepo) GetSubtasks(ctx context.Context, ownerId, parentId int) (items []models.TodoModel, err error) {
+	defer r.observe("GetSubtasks", time.Now(), &err)
+	return r.repo.GetSubtasks(ctx, ownerId, parentId)
+}
+
+func (r *instrumentedTodoRepo) ReorderSubtasks(ctx context.Context, ownerId, parentId int, ids []int) (err error) {
+	defer r.observe("ReorderSubtasks", time.Now(), &err)
+	return r.repo.ReorderSubtasks(ctx, ownerId, parentId, ids)
+}
+
+func (r *instrumentedTodoRepo) ToggleSubtask(ctx context.Context, ownerId, parentId, id int) (result models.TodoModel, err error) {
+	defer r.observe("ToggleSubtask", time.Now(), &err)
+	return r.repo.ToggleSubtask(ctx, ownerId, parentId, id)
+}
+
+func (r *instrumentedTodoRepo) CompleteSubtasks(ctx context.Context, ownerId, id int) (err error) {
+	defer r.observe("CompleteSubtasks", time.Now(), &err)
+	return r.repo.CompleteSubtasks(ctx, ownerId, id)
+}
+
+func (r *instrumentedTodoRepo) RestoreTodo(ctx context.Context, ownerId, id int) (err error) {
+	defer r.observe("RestoreTodo", time.Now(), &err)
+	return r.repo.RestoreTodo(ctx, ownerId, id)
+}
+
+func (r *instrumentedTodoRepo) DeleteTrashedTodo(ctx context.Context, ownerId, id int) (err error) {
+	defer r.observe("DeleteTrashedTodo", time.Now(), &err)
+	return r.repo.DeleteTrashedTodo(ctx, ownerId, id)
+}
+
+func (r *instrumentedTodoRepo) PurgeTrash(ctx context.Context, before time.Time) (n int64, err error) {
+	defer r.observe("PurgeTrash", time.Now(), &err)
+	return r.repo.PurgeTrash(ctx, before)
+}
+
+func (r *instrumentedTodoRepo) CreateTodos(ctx context.Context, ownerId int, todos []models.TodoModel) (items []models.TodoModel, err error) {
+	defer r.observe("CreateTodos", time.Now(), &err)
+	return r.repo.CreateTodos(ctx, ownerId, todos)
+}
+
+func (r *instrumentedTodoRepo) LockTodo(ctx context.Context, ownerId, id int) (result models.TodoModel, err error) {
+	defer r.observe("LockTodo", time.Now(), &err)
+	return r.repo.LockTodo(ctx, ownerId, id)
+}
+
+func (r *instrumentedTodoRepo) LockSubtree(ctx context.Context, ownerId, id int) (items []models.TodoModel, err error) {
+	defer r.observe("LockSubtree", time.Now(), &err)
+	return r.repo.LockSubtree(ctx, ownerId, id)
+}
+
+func (r *instrumentedTodoRepo) LockProjectTodos(ctx context.Context, ownerId, projectId int) (items []models.TodoModel, err error) {
+	defer r.observe("LockProjectTodos", time.Now(), &err)
+	return r.repo.LockProjectTodos(ctx, ownerId, projectId)
+}
+
+func (r *instrumentedTodoRepo) LockTaggedTodos(ctx context.Context, ownerId, tagId int) (items []models.TodoModel, err error) {
+	defer r.observe("LockTaggedTodos", time.Now(), &err)
+	return r.repo.LockTaggedTodos(ctx, ownerId, tagId)
+}
+
+func (r *instrumentedTodoRepo) LockTodos(ctx context.Context, ownerId int, ids []int) (items []models.TodoModel, err error) {
+	defer r.observe("LockTodos", time.Now(), &err)
+	return r.repo.LockTodos(ctx, ownerId, ids)
+}
+
+// Projects and Tags are not observed, the observer covers the todo repository only
+func (r *instrumentedTodoRepo) Projects() ProjectRepository {
+	return r.repo.Projects()
+}
+
+func (r *instrumentedTodoRepo) Tags() TagRepository {
+	return r.repo.Tags()
+}
+
+func (r *instrumentedTodoRepo) AddAuditEntries(ctx context.Context, ownerId int, entries []models.AuditEntry) (err error) {
+	defer r.observe("AddAuditEntries", time.Now(), &err)
+	return r.repo.AddAuditEntries(ctx, ownerId, entries)
+}
+
+func (r *instrumentedTodoRepo) GetAuditEntries(ctx context.Context, ownerId int, filter models.AuditFilter, limit int, after *models.AuditCursor) (items []models.AuditEntry, err error) {
+	defer r.observe("GetAuditEntries", time.Now(), &err)
+	return r.repo.GetAuditEntries(ctx, ownerId, filter, limit, after)
+}
+
+func (r *instrumentedTodoRepo) AddTodoEvents(ctx context.Context, ownerId int, events []models.TodoEvent) (err error) {
+	defer r.observe("AddTodoEvents", time.Now(), &err)
+	return r.repo.AddTodoEvents(ctx, ownerId, events)
+}
+
+func (r *instrumentedTodoRepo) AddWebhookDeliveries(ctx context.Context, ownerId int, deliveries []models.WebhookDelivery) (err error) {
+	defer r.observe("AddWebhookDeliveries", time.Now(), &err)
+	return r.repo.AddWebhookDeliveries(ctx, ownerId, deliveries)
+}
@@ -0,0 +1,52 @@
+package repos
+
+import (
+	"context"
+	"errors"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+type observedCall struct {
+	method string
+	err    error
+}
+
+func TestInstrumentedTodoRepo(t *testing.T) {
+	mockDB, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer mockDB.Close(context.Background())
+
+	var calls []observedCall
+	r := NewInstrumentedTodoRepo(NewTodoRepo(NewDB(mockDB), testMaxDepth), func(method string, duration time.Duration, err error) {
+		assert.GreaterOrEqual(t, duration, time.Duration(0))
+		calls = append(calls, observedCall{method: method, err: err})
+	})
+	before := time.Now()
+	query := "DELETE FROM todo WHERE deleted_at < \\$1"
+	dbErr := errors.New("db error")
+
+	mockDB.ExpectExec(query).WithArgs(before).WillReturnResult(pgxmock.NewResult("DELETE", 4))
+	mockDB.ExpectBegin()
+	mockDB.ExpectExec(query).WithArgs(before).WillReturnError(dbErr)
+	mockDB.ExpectRollback()
+
+	_, err = r.PurgeTrash(context.Background(), before)
+	assert.NoError(t, err)
+	err = r.InTx(context.Background(), func(repo TodoRepository) error {
+		_, err := repo.PurgeTrash(context.Background(), before)
+		return err
+	})
+	assert.ErrorIs(t, err, dbErr)
+
+	assert.Equal(t, []observedCall{
+		{method: "PurgeTrash"},
+		{method: "PurgeTrash", err: dbErr},
+		{method: "InTx", err: dbErr},
+	}, calls)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
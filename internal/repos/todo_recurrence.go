@@ -0,0 +1,71 @@
+package repos
+
+import (
+	"context"
+	"errors"
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// todoUpdate is a todo as written by an update together with the next instance of its series the update created
+type todoUpdate struct {
+	todo    models.TodoModel
+	spawned *models.TodoModel
+}
+
+// updateTodoQuery returns the statement applying set to the todo matched by where and returning it.
+// With next the same statement inserts the next instance of the series, together with the tags of the todo,
+// when the update completes an open todo, the id of that instance follows the columns of the todo. The row lock
+// taken on the old state keeps concurrent completions from creating the instance twice
+func updateTodoQuery(qb *queryBuilder, set, where string, next *models.TodoOccurrence) string {
+	if next == nil {
+		return "UPDATE todo SET " + set + where + " RETURNING " + todoColumns
+	}
+	return `
+		WITH old AS (
+			SELECT completed FROM todo` + where + ` FOR UPDATE
+		), updated AS (
+			UPDATE todo SET ` + set + `, series_id = CASE WHEN completed THEN series_id ELSE COALESCE(series_id, id) END` + where + `
+			RETURNING *
+		), next AS (
+			INSERT INTO todo (title, description, completed, created_at, due_at, remind_at, project_id, owner_id, parent_id, position, recurrence, series_id)
+			SELECT u.title, u.description, FALSE, NOW(), ` + qb.arg(next.DueAt) + `, ` + qb.arg(next.RemindAt) + `, u.project_id, u.owner_id, u.parent_id,
+				` + nextPosition("u.parent_id") + `, ` + qb.arg(next.Recurrence) + `, u.series_id
+			FROM updated u, old o
+			WHERE u.completed AND NOT o.completed
+			RETURNING id
+		), next_tags AS (
+			INSERT INTO todo_tag (todo_id, tag_id)
+			SELECT n.id, tt.tag_id FROM next n, todo_tag tt WHERE tt.todo_id IN (SELECT id FROM updated)
+		)
+		SELECT ` + todoColumns + `, (SELECT id FROM next) FROM updated todo`
+}
+
+// runUpdateTodoQuery runs a statement of updateTodoQuery and returns the updated todo together with the instance
+// it created, both with their tags
+func (r *TodoRepositoryImpl) runUpdateTodoQuery(ctx context.Context, ownerId, id int, query string, args []interface{}, next *models.TodoOccurrence, ifMatch *int) (todoUpdate, error) {
+	var updatedTodo models.TodoModel
+	var spawnedId *int
+	var extra []interface{}
+	if next != nil {
+		extra = append(extra, &spawnedId)
+	}
+	if err := scanTodo(r.db.QueryRow(ctx, query, args...), &updatedTodo, extra...); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return todoUpdate{}, r.missingTodoError(ctx, ownerId, id, ifMatch)
+		}
+		if isPgError(err, pgForeignKeyViolation) {
+			return todoUpdate{}, ErrProjectNotFound
+		}
+		return todoUpdate{}, err
+	}
+	updatedTodo, err := r.withTags(ctx, updatedTodo)
+	if err != nil || spawnedId == nil {
+		return todoUpdate{todo: updatedTodo}, err
+	}
+	spawned, err := r.GetTodoById(ctx, ownerId, *spawnedId)
+	if err != nil {
+		return todoUpdate{}, err
+	}
+	return todoUpdate{todo: updatedTodo, spawned: &spawned}, nil
+}
@@ -0,0 +1,161 @@
+package repos
+
+import (
+	"context"
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// spawnQuery matches the update statement that also inserts the next instance of a series
+const spawnQuery = "WITH old AS \\( SELECT completed FROM todo WHERE (.+) FOR UPDATE \\), " +
+	"updated AS \\( UPDATE todo SET (.+), series_id = CASE WHEN completed THEN series_id ELSE COALESCE\\(series_id, id\\) END WHERE (.+) RETURNING \\* \\), " +
+	"next AS \\( INSERT INTO todo (.+) FROM updated u, old o WHERE u.completed AND NOT o.completed RETURNING id \\), " +
+	"next_tags AS \\( INSERT INTO todo_tag (.+) \\) " +
+	"SELECT (.+), \\(SELECT id FROM next\\) FROM updated todo"
+
+// spawnRowColumns are the columns of the todo updated by spawnQuery followed by the id of the instance it created
+var spawnRowColumns = append(append([]string{}, todoRowColumns...), "next_id")
+
+func TestUpdateTodoNextOccurrence(t *testing.T) {
+	mockDB, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer mockDB.Close(context.Background())
+
+	r := NewTodoRepo(NewDB(mockDB), testMaxDepth)
+	now := time.Now()
+	due := time.Date(2024, 1, 9, 10, 0, 0, 0, time.UTC)
+	rule := "FREQ=WEEKLY;BYDAY=TU;COUNT=3"
+	seriesId := 1
+	spawnedId := 2
+	next := &models.TodoOccurrence{DueAt: due.AddDate(0, 0, 7), Recurrence: "FREQ=WEEKLY;BYDAY=TU;COUNT=2"}
+	todo := models.TodoModel{Title: "Patch Tuesday", Completed: true, DueAt: &due, Recurrence: &rule}
+
+	tests := []struct {
+		name        string
+		mock        func()
+		want        models.TodoModel
+		wantSpawned *models.TodoModel
+		wantErr     error
+	}{
+		{
+			name: "Ok",
+			mock: func() {
+				rows := pgxmock.NewRows(spawnRowColumns).
+					AddRow(1, "Patch Tuesday", "", true, now, &due, noTime, noProject, nil, 0, &rule, &seriesId, nil, 1, 0, 0, &spawnedId)
+				mockDB.ExpectQuery(spawnQuery).
+					WithArgs("Patch Tuesday", "", true, &due, noTime, noProject, 1, testUserId, noProject, &rule, next.DueAt, noTime, next.Recurrence).
+					WillReturnRows(rows)
+				expectTodoTags(mockDB, pgxmock.NewRows(tagRowColumns), 1)
+				spawnedRows := pgxmock.NewRows(todoRowColumns).
+					AddRow(2, "Patch Tuesday", "", false, now, &next.DueAt, noTime, noProject, nil, 1, &next.Recurrence, &seriesId, nil, 1, 0, 0)
+				mockDB.ExpectQuery("SELECT (.+) FROM todo WHERE id = \\$1 AND owner_id = \\$2 AND deleted_at IS NULL").
+					WithArgs(2, testUserId).
+					WillReturnRows(spawnedRows)
+				expectTodoTags(mockDB, pgxmock.NewRows(tagRowColumns), 2)
+			},
+			want: models.TodoModel{
+				Id:         1,
+				Title:      "Patch Tuesday",
+				Completed:  true,
+				CreatedAt:  now,
+				DueAt:      &due,
+				Recurrence: &rule,
+				SeriesId:   &seriesId,
+				Version:    1,
+				Tags:       []models.TagModel{},
+			},
+			wantSpawned: &models.TodoModel{
+				Id:         2,
+				Title:      "Patch Tuesday",
+				CreatedAt:  now,
+				DueAt:      &next.DueAt,
+				Position:   1,
+				Recurrence: &next.Recurrence,
+				SeriesId:   &seriesId,
+				Version:    1,
+				Tags:       []models.TagModel{},
+			},
+		},
+		{
+			name: "Already Completed",
+			mock: func() {
+				rows := pgxmock.NewRows(spawnRowColumns).
+					AddRow(1, "Patch Tuesday", "", true, now, &due, noTime, noProject, nil, 0, &rule, &seriesId, nil, 2, 0, 0, nil)
+				mockDB.ExpectQuery(spawnQuery).
+					WithArgs("Patch Tuesday", "", true, &due, noTime, noProject, 1, testUserId, noProject, &rule, next.DueAt, noTime, next.Recurrence).
+					WillReturnRows(rows)
+				expectTodoTags(mockDB, pgxmock.NewRows(tagRowColumns), 1)
+			},
+			want: models.TodoModel{
+				Id:         1,
+				Title:      "Patch Tuesday",
+				Completed:  true,
+				CreatedAt:  now,
+				DueAt:      &due,
+				Recurrence: &rule,
+				SeriesId:   &seriesId,
+				Version:    2,
+				Tags:       []models.TagModel{},
+			},
+		},
+		{
+			name: "Not Found",
+			mock: func() {
+				mockDB.ExpectQuery(spawnQuery).
+					WithArgs("Patch Tuesday", "", true, &due, noTime, noProject, 1, testUserId, noProject, &rule, next.DueAt, noTime, next.Recurrence).
+					WillReturnRows(pgxmock.NewRows(spawnRowColumns))
+			},
+			wantErr: ErrTodoNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			got, spawned, err := r.UpdateTodo(context.Background(), testUserId, 1, todo, next, nil)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+				assert.Equal(t, tt.wantSpawned, spawned)
+			}
+			assert.NoError(t, mockDB.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestPatchTodoNextOccurrence(t *testing.T) {
+	mockDB, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer mockDB.Close(context.Background())
+
+	r := NewTodoRepo(NewDB(mockDB), testMaxDepth)
+	now := time.Now()
+	due := time.Date(2024, 1, 9, 10, 0, 0, 0, time.UTC)
+	remind := due.Add(-time.Hour)
+	nextRemind := remind.AddDate(0, 0, 1)
+	rule := "FREQ=DAILY"
+	next := &models.TodoOccurrence{DueAt: due.AddDate(0, 0, 1), RemindAt: &nextRemind, Recurrence: rule}
+
+	rows := pgxmock.NewRows(spawnRowColumns).
+		AddRow(1, "Rotate on-call", "", true, now, &due, &remind, noProject, nil, 0, &rule, nil, nil, 1, 0, 0, nil)
+	mockDB.ExpectQuery(spawnQuery).
+		WithArgs(true, 1, testUserId, next.DueAt, next.RemindAt, rule).
+		WillReturnRows(rows)
+	expectTodoTags(mockDB, pgxmock.NewRows(tagRowColumns), 1)
+
+	got, spawned, err := r.PatchTodo(context.Background(), testUserId, 1, models.TodoPatch{Completed: models.PatchField[bool]{Set: true, Value: true}}, next, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, spawned)
+	assert.True(t, got.Completed)
+	assert.Equal(t, &rule, got.Recurrence)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
@@ -0,0 +1,122 @@
+package repos
+
+import (
+	"context"
+	"github.com/cherrycutter/todo_app/internal/models"
+	"strings"
+	"unicode"
+)
+
+// searchHeadlineOptions mark the matching words of the highlighted snippets
+const searchHeadlineOptions = "StartSel=<mark>, StopSel=</mark>"
+
+// buildTsQuery translates a search box query into to_tsquery syntax. Words are ANDed, "quoted words"
+// must appear as a phrase, a word ending with * matches as a prefix and a leading - excludes a word
+// or phrase. Any other punctuation only separates words, so user input never reaches the tsquery parser
+// as operators. An empty string is returned when the query holds no word
+func buildTsQuery(q string) string {
+	var terms []string
+	for len(q) > 0 {
+		q = strings.TrimLeftFunc(q, unicode.IsSpace)
+		if q == "" {
+			break
+		}
+		negate := false
+		if q[0] == '-' {
+			negate = true
+			q = q[1:]
+		}
+
+		var term string
+		if strings.HasPrefix(q, `"`) {
+			phrase, rest, _ := strings.Cut(q[1:], `"`)
+			q = rest
+			term = strings.Join(searchWords(phrase), " <-> ")
+		} else {
+			end := strings.IndexFunc(q, unicode.IsSpace)
+			if end < 0 {
+				end = len(q)
+			}
+			word := q[:end]
+			q = q[end:]
+			prefix := strings.HasSuffix(word, "*")
+			words := searchWords(word)
+			if prefix && len(words) > 0 {
+				words[len(words)-1] += ":*"
+			}
+			term = strings.Join(words, " <-> ")
+		}
+		if term == "" {
+			continue
+		}
+		if strings.Contains(term, " <-> ") {
+			term = "(" + term + ")"
+		}
+		if negate {
+			term = "!" + term
+		}
+		terms = append(terms, term)
+	}
+	return strings.Join(terms, " & ")
+}
+
+// searchWords splits text into words made of letters and digits
+func searchWords(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// SearchTodos returns the todos of the owner matching the search query, best ranked first
+func (r *TodoRepositoryImpl) SearchTodos(ctx context.Context, ownerId int, search models.TodoSearch, limit int) ([]models.TodoSearchHit, error) {
+	tsQuery := buildTsQuery(search.Query)
+	if tsQuery == "" {
+		return nil, &FilterError{Param: "q", Value: search.Query}
+	}
+	var qb queryBuilder
+	query := qb.arg(tsQuery)
+	options := qb.arg(searchHeadlineOptions)
+	qb.where("owner_id = " + qb.arg(ownerId))
+	qb.where("deleted_at IS NULL")
+	qb.where("search @@ q")
+	if search.Completed != nil {
+		qb.where("completed = " + qb.arg(*search.Completed))
+	}
+	sql := "SELECT " + todoColumns + ", ts_rank(search, q) AS rank," +
+		" ts_headline('english', title, q, " + options + ")," +
+		" ts_headline('english', COALESCE(description, ''), q, " + options + ")" +
+		" FROM todo, to_tsquery('english', " + query + ") q" +
+		qb.whereClause() + " ORDER BY rank DESC, id LIMIT " + qb.arg(limit)
+
+	rows, err := r.db.Query(ctx, sql, qb.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hits := []models.TodoSearchHit{}
+	for rows.Next() {
+		var hit models.TodoSearchHit
+		if err = scanTodo(rows, &hit.Todo, &hit.Rank, &hit.TitleHighlight, &hit.DescriptionHighlight); err != nil {
+			return nil, err
+		}
+		hits = append(hits, hit)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	todos := make([]models.TodoModel, len(hits))
+	for i := range hits {
+		todos[i] = hits[i].Todo
+	}
+	if err = loadTodoTags(ctx, r.db, todos); err != nil {
+		return nil, err
+	}
+	for i := range hits {
+		hits[i].Todo = todos[i]
+	}
+	return hits, nil
+}
@@ -0,0 +1,102 @@
+package repos
+
+import (
+	"context"
+	"errors"
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestBuildTsQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "Words", input: "quarterly report", want: "quarterly & report"},
+		{name: "Phrase", input: `"quarterly report" draft`, want: "(quarterly <-> report) & draft"},
+		{name: "Prefix", input: "rep*", want: "rep:*"},
+		{name: "Negation", input: `report -draft -"old notes"`, want: "report & !draft & !(old <-> notes)"},
+		{name: "Operators Stripped", input: "a&b | !c (d)", want: "(a <-> b) & c & d"},
+		{name: "Unclosed Phrase", input: `"quarterly report`, want: "(quarterly <-> report)"},
+		{name: "No Words", input: `"" - * !`, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, buildTsQuery(tt.input))
+		})
+	}
+}
+
+func TestSearchTodos(t *testing.T) {
+	mockDB, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer mockDB.Close(context.Background())
+
+	r := NewTodoRepo(NewDB(mockDB), testMaxDepth)
+	now := time.Now()
+	completed := false
+	hitColumns := append(append([]string{}, todoRowColumns...), "rank", "title_highlight", "description_highlight")
+
+	tests := []struct {
+		name    string
+		mock    func()
+		search  models.TodoSearch
+		want    []models.TodoSearchHit
+		wantErr error
+	}{
+		{
+			name: "Ok",
+			mock: func() {
+				rows := pgxmock.NewRows(hitColumns).
+					AddRow(1, "Write report", "", false, now, noTime, noTime, noProject, nil, 0, nil, nil, nil, 1, 0, 0, float32(0.6), "Write <mark>report</mark>", "")
+				mockDB.ExpectQuery("SELECT (.+), ts_rank\\(search, q\\) AS rank, ts_headline\\('english', title, q, \\$2\\), ts_headline\\('english', COALESCE\\(description, ''\\), q, \\$2\\) FROM todo, to_tsquery\\('english', \\$1\\) q WHERE owner_id = \\$3 AND deleted_at IS NULL AND search @@ q AND completed = \\$4 ORDER BY rank DESC, id LIMIT \\$5").
+					WithArgs("report:*", searchHeadlineOptions, testUserId, false, 10).
+					WillReturnRows(rows)
+				expectTodoTags(mockDB, pgxmock.NewRows(tagRowColumns), 1)
+			},
+			search: models.TodoSearch{Query: "report*", Completed: &completed},
+			want: []models.TodoSearchHit{{
+				Todo:           models.TodoModel{Id: 1, Title: "Write report", CreatedAt: now, Version: 1, Tags: []models.TagModel{}},
+				Rank:           0.6,
+				TitleHighlight: "Write <mark>report</mark>",
+			}},
+		},
+		{
+			name: "No Words",
+			mock: func() {
+			},
+			search:  models.TodoSearch{Query: "!!"},
+			wantErr: &FilterError{Param: "q", Value: "!!"},
+		},
+		{
+			name: "Query Error",
+			mock: func() {
+				mockDB.ExpectQuery("FROM todo, to_tsquery").
+					WithArgs("report", searchHeadlineOptions, testUserId, 10).
+					WillReturnError(errors.New("query error"))
+			},
+			search:  models.TodoSearch{Query: "report"},
+			wantErr: errors.New("query error"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			got, err := r.SearchTodos(context.Background(), testUserId, tt.search, 10)
+			if tt.wantErr != nil {
+				assert.EqualError(t, err, tt.wantErr.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+			assert.NoError(t, mockDB.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package repos
+
+import (
+	"context"
+	"errors"
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// nextPosition returns the SQL expression of the position after the last subtask of parent
+func nextPosition(parent string) string {
+	return "(SELECT COALESCE(MAX(s.position) + 1, 0) FROM todo s WHERE s.parent_id = " + parent + ")"
+}
+
+// movedPosition returns the SQL expression of the position of an updated todo, a todo keeps its position
+// unless it moves to another parent where it is appended after the last subtask
+func movedPosition(parent string) string {
+	return "CASE WHEN parent_id IS NOT DISTINCT FROM " + parent + " THEN position ELSE " + nextPosition(parent) + " END"
+}
+
+// checkParent verifies that the todo id, 0 for a new todo, can be nested under parentId: the parent must belong
+// to the owner, must not be the todo or one of its subtasks, and the subtree of the todo must stay within maxDepth levels.
+// It runs within the transaction of the write, see withParent
+func (r *TodoRepositoryImpl) checkParent(ctx context.Context, ownerId, id, parentId int) error {
+	// the moved todo and the ancestors of the parent stay locked until the transaction ends, so that concurrent
+	// moves are checked one after the other and cannot create a cycle together. They are locked in the order of
+	// their ids so that such moves do not deadlock
+	lock := `
+		WITH RECURSIVE ancestors AS (
+			SELECT id, parent_id, 1 AS depth FROM todo WHERE id = $1 AND owner_id = $2
+			UNION ALL
+			SELECT t.id, t.parent_id, a.depth + 1 FROM todo t JOIN ancestors a ON t.id = a.parent_id WHERE a.depth <= $4
+		)
+		SELECT id FROM todo WHERE owner_id = $2 AND (id IN (SELECT id FROM ancestors) OR id = $3) ORDER BY id FOR UPDATE
+	`
+	if _, err := r.db.Exec(ctx, lock, parentId, ownerId, id, r.maxDepth); err != nil {
+		return err
+	}
+	// a statement of its own sees the ancestors as they are once locked
+	query := `
+		WITH RECURSIVE ancestors AS (
+			SELECT id, parent_id, 1 AS depth FROM todo WHERE id = $1 AND owner_id = $2 AND deleted_at IS NULL
+			UNION ALL
+			SELECT t.id, t.parent_id, a.depth + 1 FROM todo t JOIN ancestors a ON t.id = a.parent_id WHERE a.depth <= $4
+		), subtree AS (
+			SELECT id, 1 AS height FROM todo WHERE id = $3
+			UNION ALL
+			SELECT t.id, s.height + 1 FROM todo t JOIN subtree s ON t.parent_id = s.id WHERE s.height <= $4
+		)
+		SELECT
+			EXISTS (SELECT 1 FROM ancestors),
+			EXISTS (SELECT 1 FROM ancestors WHERE id = $3),
+			COALESCE((SELECT MAX(depth) FROM ancestors), 0),
+			COALESCE((SELECT MAX(height) FROM subtree), 1)
+	`
+	var found, cycle bool
+	var depth, height int
+	if err := r.db.QueryRow(ctx, query, parentId, ownerId, id, r.maxDepth).Scan(&found, &cycle, &depth, &height); err != nil {
+		return err
+	}
+	switch {
+	case !found:
+		return ErrParentNotFound
+	case cycle:
+		return ErrTodoCycle
+	case depth+height > r.maxDepth:
+		return ErrTodoDepthExceeded
+	}
+	return nil
+}
+
+// withParent checks that the todo id, 0 for a new todo, can be nested under parentId and runs write, both in one
+// transaction that holds the locks taken by checkParent
+func withParent[T any](ctx context.Context, r *TodoRepositoryImpl, ownerId, id, parentId int, write func(tx *TodoRepositoryImpl) (T, error)) (T, error) {
+	var result T
+	err := r.db.WithTx(ctx, func(tx PgxConnIface) error {
+		txRepo := &TodoRepositoryImpl{db: tx, maxDepth: r.maxDepth}
+		if err := txRepo.checkParent(ctx, ownerId, id, parentId); err != nil {
+			return err
+		}
+		var err error
+		result, err = write(txRepo)
+		return err
+	})
+	return result, err
+}
+
+// GetSubtasks returns the direct subtasks of a todo of the owner in their order
+func (r *TodoRepositoryImpl) GetSubtasks(ctx context.Context, ownerId, parentId int) ([]models.TodoModel, error) {
+	query := "SELECT " + todoColumns + " FROM todo WHERE parent_id = $1 AND owner_id = $2 AND deleted_at IS NULL ORDER BY position, id"
+	rows, err := r.db.Query(ctx, query, parentId, ownerId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	todos := []models.TodoModel{}
+	for rows.Next() {
+		var todo models.TodoModel
+		if err = scanTodo(rows, &todo); err != nil {
+			return nil, err
+		}
+		todos = append(todos, todo)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	if err = loadTodoTags(ctx, r.db, todos); err != nil {
+		return nil, err
+	}
+	return todos, nil
+}
+
+// ReorderSubtasks sets the position of every subtask of a todo to its index in ids,
+// ErrSubtaskOrderFailed is returned unless ids lists every subtask exactly once
+func (r *TodoRepositoryImpl) ReorderSubtasks(ctx context.Context, ownerId, parentId int, ids []int) error {
+	query := `
+		UPDATE todo t
+		SET position = o.ord - 1, version = t.version + 1
+		FROM unnest($1::int[]) WITH ORDINALITY AS o(id, ord)
+		WHERE t.id = o.id AND t.parent_id = $2 AND t.owner_id = $3 AND t.deleted_at IS NULL
+			AND (SELECT COUNT(*) FROM todo s WHERE s.parent_id = $2 AND s.deleted_at IS NULL) = cardinality($1::int[])
+	`
+	cmdTag, err := r.db.Exec(ctx, query, ids, parentId, ownerId)
+	if err != nil {
+		return err
+	}
+	if cmdTag.RowsAffected() != int64(len(ids)) {
+		return ErrSubtaskOrderFailed
+	}
+	return nil
+}
+
+// ToggleSubtask flips the completed flag of a direct subtask of a todo
+func (r *TodoRepositoryImpl) ToggleSubtask(ctx context.Context, ownerId, parentId, id int) (models.TodoModel, error) {
+	query := "UPDATE todo SET completed = NOT completed, version = version + 1 WHERE id = $1 AND parent_id = $2 AND owner_id = $3 AND deleted_at IS NULL RETURNING " + todoColumns
+	var todo models.TodoModel
+	if err := scanTodo(r.db.QueryRow(ctx, query, id, parentId, ownerId), &todo); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.TodoModel{}, ErrTodoNotFound
+		}
+		return models.TodoModel{}, err
+	}
+	return r.withTags(ctx, todo)
+}
+
+// CompleteSubtasks completes every subtask of a todo at any depth
+func (r *TodoRepositoryImpl) CompleteSubtasks(ctx context.Context, ownerId, id int) error {
+	query := `
+		WITH RECURSIVE subtree AS (
+			SELECT id FROM todo WHERE parent_id = $1 AND owner_id = $2 AND deleted_at IS NULL
+			UNION ALL
+			SELECT t.id FROM todo t JOIN subtree s ON t.parent_id = s.id WHERE t.deleted_at IS NULL
+		)
+		UPDATE todo SET completed = TRUE, version = version + 1
+		WHERE id IN (SELECT id FROM subtree) AND NOT completed
+	`
+	_, err := r.db.Exec(ctx, query, id, ownerId)
+	return err
+}
@@ -0,0 +1,161 @@
+package repos
+
+import (
+	"context"
+	"errors"
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestPatchTodoParent(t *testing.T) {
+	mockDB, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer mockDB.Close(context.Background())
+
+	r := NewTodoRepo(NewDB(mockDB), testMaxDepth)
+	now := time.Now()
+	parentId := 2
+	checkColumns := []string{"found", "cycle", "depth", "height"}
+
+	// the check locks the todo and the ancestors of the parent in the transaction of the update
+	expectCheck := func(found, cycle bool, depth, height int) {
+		mockDB.ExpectBegin()
+		mockDB.ExpectExec("WITH RECURSIVE ancestors AS (.+) SELECT id FROM todo WHERE owner_id = \\$2 AND \\(id IN \\(SELECT id FROM ancestors\\) OR id = \\$3\\) ORDER BY id FOR UPDATE").
+			WithArgs(2, testUserId, 1, testMaxDepth).
+			WillReturnResult(pgxmock.NewResult("SELECT", 2))
+		mockDB.ExpectQuery("WITH RECURSIVE ancestors AS (.+) subtree AS (.+) SELECT EXISTS").
+			WithArgs(2, testUserId, 1, testMaxDepth).
+			WillReturnRows(pgxmock.NewRows(checkColumns).AddRow(found, cycle, depth, height))
+	}
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    models.TodoModel
+		wantErr error
+	}{
+		{
+			name: "Ok",
+			mock: func() {
+				expectCheck(true, false, 1, 2)
+				rows := pgxmock.NewRows(todoRowColumns).
+					AddRow(1, "title", "", false, now, noTime, noTime, noProject, &parentId, 3, nil, nil, nil, 1, 1, 2)
+				mockDB.ExpectQuery("UPDATE todo SET position = CASE WHEN parent_id IS NOT DISTINCT FROM \\$1 THEN position ELSE (.+) END, parent_id = \\$1, version = version \\+ 1 WHERE id = \\$2 AND owner_id = \\$3 AND deleted_at IS NULL RETURNING (.+)").
+					WithArgs(2, 1, testUserId).
+					WillReturnRows(rows)
+				expectTodoTags(mockDB, pgxmock.NewRows(tagRowColumns), 1)
+				mockDB.ExpectCommit()
+			},
+			want: models.TodoModel{
+				Id:        1,
+				Title:     "title",
+				CreatedAt: now,
+				ParentId:  &parentId,
+				Position:  3,
+				Progress:  &models.TodoProgress{Done: 1, Total: 2},
+				Version:   1,
+				Tags:      []models.TagModel{},
+			},
+		},
+		{
+			name: "Parent Not Found",
+			mock: func() {
+				expectCheck(false, false, 0, 1)
+				mockDB.ExpectRollback()
+			},
+			wantErr: ErrParentNotFound,
+		},
+		{
+			name: "Cycle",
+			mock: func() {
+				expectCheck(true, true, 2, 2)
+				mockDB.ExpectRollback()
+			},
+			wantErr: ErrTodoCycle,
+		},
+		{
+			name: "Depth Exceeded",
+			mock: func() {
+				expectCheck(true, false, 2, 2)
+				mockDB.ExpectRollback()
+			},
+			wantErr: ErrTodoDepthExceeded,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			got, _, err := r.PatchTodo(context.Background(), testUserId, 1, models.TodoPatch{ParentId: models.PatchField[int]{Set: true, Value: 2}}, nil, nil)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+			assert.NoError(t, mockDB.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestReorderSubtasks(t *testing.T) {
+	mockDB, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer mockDB.Close(context.Background())
+
+	r := NewTodoRepo(NewDB(mockDB), testMaxDepth)
+	ids := []int{3, 1, 2}
+
+	tests := []struct {
+		name    string
+		mock    func()
+		wantErr error
+	}{
+		{
+			name: "Ok",
+			mock: func() {
+				mockDB.ExpectExec("UPDATE todo t SET position = o.ord - 1, version = t.version \\+ 1 FROM unnest\\(\\$1::int\\[\\]\\) WITH ORDINALITY AS o\\(id, ord\\) WHERE t.id = o.id AND t.parent_id = \\$2 AND t.owner_id = \\$3").
+					WithArgs(ids, 10, testUserId).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 3))
+			},
+		},
+		{
+			name: "Incomplete Ids",
+			mock: func() {
+				mockDB.ExpectExec("UPDATE todo t SET position").
+					WithArgs(ids, 10, testUserId).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+			},
+			wantErr: ErrSubtaskOrderFailed,
+		},
+		{
+			name: "Query Error",
+			mock: func() {
+				mockDB.ExpectExec("UPDATE todo t SET position").
+					WithArgs(ids, 10, testUserId).
+					WillReturnError(errors.New("query error"))
+			},
+			wantErr: errors.New("query error"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			err := r.ReorderSubtasks(context.Background(), testUserId, 10, ids)
+			if tt.wantErr != nil {
+				assert.EqualError(t, err, tt.wantErr.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mockDB.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package repos
+
+import (
+	"context"
+	"time"
+)
+
+// RestoreTodo takes a todo of the owner out of the trash together with the subtasks trashed along with it.
+// A subtask cannot be restored while its parent is in the trash. The restored todos get a new version, so that
+// writes conditioned on an ETag read before the delete fail
+func (r *TodoRepositoryImpl) RestoreTodo(ctx context.Context, ownerId, id int) error {
+	query := `
+		WITH RECURSIVE target AS (
+			SELECT id, parent_id, deleted_at FROM todo WHERE id = $1 AND owner_id = $2 AND deleted_at IS NOT NULL
+		), blocked AS (
+			SELECT 1 FROM target JOIN todo p ON p.id = target.parent_id WHERE p.deleted_at IS NOT NULL
+		), subtree AS (
+			SELECT id FROM target
+			UNION ALL
+			SELECT t.id FROM todo t JOIN subtree s ON t.parent_id = s.id WHERE t.deleted_at = (SELECT deleted_at FROM target)
+		), restored AS (
+			UPDATE todo SET deleted_at = NULL, version = version + 1
+			WHERE id IN (SELECT id FROM subtree) AND NOT EXISTS (SELECT 1 FROM blocked)
+		)
+		SELECT EXISTS (SELECT 1 FROM target), EXISTS (SELECT 1 FROM blocked)
+	`
+	var found, blocked bool
+	if err := r.db.QueryRow(ctx, query, id, ownerId).Scan(&found, &blocked); err != nil {
+		return err
+	}
+	if !found {
+		return ErrTodoNotFound
+	}
+	if blocked {
+		return ErrParentTrashed
+	}
+	return nil
+}
+
+// DeleteTrashedTodo permanently deletes a todo of the owner that is in the trash, its subtasks go with it
+func (r *TodoRepositoryImpl) DeleteTrashedTodo(ctx context.Context, ownerId, id int) error {
+	cmdTag, err := r.db.Exec(ctx, "DELETE FROM todo WHERE id = $1 AND owner_id = $2 AND deleted_at IS NOT NULL", id, ownerId)
+	if err != nil {
+		return err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return ErrTodoNotFound
+	}
+	return nil
+}
+
+// PurgeTrash permanently deletes the todos of every owner trashed before the given time
+// and returns how many were deleted
+func (r *TodoRepositoryImpl) PurgeTrash(ctx context.Context, before time.Time) (int64, error) {
+	cmdTag, err := r.db.Exec(ctx, "DELETE FROM todo WHERE deleted_at < $1", before)
+	if err != nil {
+		return 0, err
+	}
+	return cmdTag.RowsAffected(), nil
+}
@@ -0,0 +1,146 @@
+package repos
+
+import (
+	"context"
+	"errors"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestRestoreTodo(t *testing.T) {
+	mockDB, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer mockDB.Close(context.Background())
+
+	r := NewTodoRepo(NewDB(mockDB), testMaxDepth)
+	query := "WITH RECURSIVE target AS \\( SELECT id, parent_id, deleted_at FROM todo WHERE id = \\$1 AND owner_id = \\$2 AND deleted_at IS NOT NULL \\), " +
+		"blocked AS \\( (.+) \\), subtree AS \\( (.+) \\), restored AS \\( UPDATE todo SET deleted_at = NULL, version = version \\+ 1 (.+) \\) " +
+		"SELECT EXISTS \\(SELECT 1 FROM target\\), EXISTS \\(SELECT 1 FROM blocked\\)"
+	columns := []string{"found", "blocked"}
+
+	tests := []struct {
+		name    string
+		mock    func()
+		wantErr error
+	}{
+		{
+			name: "Ok",
+			mock: func() {
+				mockDB.ExpectQuery(query).
+					WithArgs(1, testUserId).
+					WillReturnRows(pgxmock.NewRows(columns).AddRow(true, false))
+			},
+		},
+		{
+			name: "Not Found",
+			mock: func() {
+				mockDB.ExpectQuery(query).
+					WithArgs(1, testUserId).
+					WillReturnRows(pgxmock.NewRows(columns).AddRow(false, false))
+			},
+			wantErr: ErrTodoNotFound,
+		},
+		{
+			name: "Parent Trashed",
+			mock: func() {
+				mockDB.ExpectQuery(query).
+					WithArgs(1, testUserId).
+					WillReturnRows(pgxmock.NewRows(columns).AddRow(true, true))
+			},
+			wantErr: ErrParentTrashed,
+		},
+		{
+			name: "Query Error",
+			mock: func() {
+				mockDB.ExpectQuery(query).
+					WithArgs(1, testUserId).
+					WillReturnError(errors.New("query error"))
+			},
+			wantErr: errors.New("query error"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			err := r.RestoreTodo(context.Background(), testUserId, 1)
+			if tt.wantErr != nil {
+				assert.EqualError(t, err, tt.wantErr.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mockDB.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestDeleteTrashedTodo(t *testing.T) {
+	mockDB, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer mockDB.Close(context.Background())
+
+	r := NewTodoRepo(NewDB(mockDB), testMaxDepth)
+
+	tests := []struct {
+		name    string
+		mock    func()
+		wantErr error
+	}{
+		{
+			name: "Ok",
+			mock: func() {
+				mockDB.ExpectExec("DELETE FROM todo WHERE id = \\$1 AND owner_id = \\$2 AND deleted_at IS NOT NULL").
+					WithArgs(1, testUserId).
+					WillReturnResult(pgxmock.NewResult("DELETE", 1))
+			},
+		},
+		{
+			name: "Not In Trash",
+			mock: func() {
+				mockDB.ExpectExec("DELETE FROM todo WHERE id = \\$1 AND owner_id = \\$2 AND deleted_at IS NOT NULL").
+					WithArgs(1, testUserId).
+					WillReturnResult(pgxmock.NewResult("DELETE", 0))
+			},
+			wantErr: ErrTodoNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			err := r.DeleteTrashedTodo(context.Background(), testUserId, 1)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mockDB.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestPurgeTrash(t *testing.T) {
+	mockDB, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer mockDB.Close(context.Background())
+
+	r := NewTodoRepo(NewDB(mockDB), testMaxDepth)
+	before := time.Now().Add(-30 * 24 * time.Hour)
+
+	mockDB.ExpectExec("DELETE FROM todo WHERE deleted_at < \\$1").
+		WithArgs(before).
+		WillReturnResult(pgxmock.NewResult("DELETE", 4))
+
+	purged, err := r.PurgeTrash(context.Background(), before)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), purged)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
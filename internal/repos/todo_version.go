@@ -0,0 +1,29 @@
+package repos
+
+import "context"
+
+// versionCondition returns the condition restricting a write to the version ifMatch of a todo,
+// an empty string when the write is unconditional
+func versionCondition(qb *queryBuilder, ifMatch *int) string {
+	if ifMatch == nil {
+		return ""
+	}
+	return " AND version = " + qb.arg(*ifMatch)
+}
+
+// missingTodoError tells why a write of a todo of the owner matched no row: ErrVersionMismatch when the write
+// was conditional and the todo exists with another version, ErrTodoNotFound otherwise
+func (r *TodoRepositoryImpl) missingTodoError(ctx context.Context, ownerId, id int, ifMatch *int) error {
+	if ifMatch == nil {
+		return ErrTodoNotFound
+	}
+	var exists bool
+	query := "SELECT EXISTS (SELECT 1 FROM todo WHERE id = $1 AND owner_id = $2 AND deleted_at IS NULL)"
+	if err := r.db.QueryRow(ctx, query, id, ownerId).Scan(&exists); err != nil {
+		return err
+	}
+	if exists {
+		return ErrVersionMismatch
+	}
+	return ErrTodoNotFound
+}
@@ -0,0 +1,139 @@
+package repos
+
+import (
+	"context"
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+const existsQuery = "SELECT EXISTS \\(SELECT 1 FROM todo WHERE id = \\$1 AND owner_id = \\$2 AND deleted_at IS NULL\\)"
+
+func TestUpdateTodoIfMatch(t *testing.T) {
+	mockDB, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer mockDB.Close(context.Background())
+
+	r := NewTodoRepo(NewDB(mockDB), testMaxDepth)
+
+	now := time.Now()
+	version := 3
+	input := models.TodoModel{Title: "title"}
+	updateQuery := "UPDATE todo SET (.+), version = version \\+ 1 WHERE id = \\$7 AND owner_id = \\$8 AND deleted_at IS NULL AND version = \\$11 RETURNING (.+)"
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    models.TodoModel
+		wantErr error
+	}{
+		{
+			name: "Ok",
+			mock: func() {
+				rows := pgxmock.NewRows(todoRowColumns).
+					AddRow(1, "title", "", false, now, noTime, noTime, noProject, nil, 0, nil, nil, nil, 4, 0, 0)
+				mockDB.ExpectQuery(updateQuery).
+					WithArgs("title", "", false, noTime, noTime, noProject, 1, testUserId, noProject, noRule, version).
+					WillReturnRows(rows)
+				expectTodoTags(mockDB, pgxmock.NewRows(tagRowColumns), 1)
+			},
+			want: models.TodoModel{Id: 1, Title: "title", CreatedAt: now, Version: 4, Tags: []models.TagModel{}},
+		},
+		{
+			name: "Version Mismatch",
+			mock: func() {
+				mockDB.ExpectQuery(updateQuery).
+					WithArgs("title", "", false, noTime, noTime, noProject, 1, testUserId, noProject, noRule, version).
+					WillReturnError(pgx.ErrNoRows)
+				mockDB.ExpectQuery(existsQuery).
+					WithArgs(1, testUserId).
+					WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(true))
+			},
+			wantErr: ErrVersionMismatch,
+		},
+		{
+			name: "Not Found",
+			mock: func() {
+				mockDB.ExpectQuery(updateQuery).
+					WithArgs("title", "", false, noTime, noTime, noProject, 1, testUserId, noProject, noRule, version).
+					WillReturnError(pgx.ErrNoRows)
+				mockDB.ExpectQuery(existsQuery).
+					WithArgs(1, testUserId).
+					WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(false))
+			},
+			wantErr: ErrTodoNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			got, _, err := r.UpdateTodo(context.Background(), testUserId, 1, input, nil, &version)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+			assert.NoError(t, mockDB.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestDeleteTodoByIdIfMatch(t *testing.T) {
+	mockDB, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer mockDB.Close(context.Background())
+
+	r := NewTodoRepo(NewDB(mockDB), testMaxDepth)
+
+	version := 2
+	deleteQuery := "WITH RECURSIVE subtree AS \\( SELECT id FROM todo WHERE id = \\$1 AND owner_id = \\$2 AND deleted_at IS NULL AND version = \\$3 (.+) \\) UPDATE todo SET deleted_at = NOW\\(\\)"
+
+	tests := []struct {
+		name    string
+		mock    func()
+		wantErr error
+	}{
+		{
+			name: "Ok",
+			mock: func() {
+				mockDB.ExpectExec(deleteQuery).
+					WithArgs(1, testUserId, version).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+			},
+		},
+		{
+			name: "Version Mismatch",
+			mock: func() {
+				mockDB.ExpectExec(deleteQuery).
+					WithArgs(1, testUserId, version).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+				mockDB.ExpectQuery(existsQuery).
+					WithArgs(1, testUserId).
+					WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(true))
+			},
+			wantErr: ErrVersionMismatch,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			err := r.DeleteTodoById(context.Background(), testUserId, 1, &version)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mockDB.ExpectationsWereMet())
+		})
+	}
+}
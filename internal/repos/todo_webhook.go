@@ -0,0 +1,25 @@
+package repos
+
+import (
+	"context"
+	"github.com/cherrycutter/todo_app/internal/models"
+)
+
+// AddWebhookDeliveries queues a delivery of each payload to every healthy webhook of the owner subscribed to its
+// event type. Written in the transaction of the change, the deliveries are sent once it commits
+func (r *TodoRepositoryImpl) AddWebhookDeliveries(ctx context.Context, ownerId int, deliveries []models.WebhookDelivery) error {
+	eventTypes := make([]string, len(deliveries))
+	payloads := make([]string, len(deliveries))
+	for i, delivery := range deliveries {
+		eventTypes[i], payloads[i] = delivery.EventType, string(delivery.Payload)
+	}
+	query := `
+		INSERT INTO webhook_delivery (webhook_id, event_type, payload)
+		SELECT w.id, d.event_type, d.payload::jsonb
+		FROM unnest($2::text[], $3::text[]) WITH ORDINALITY AS d(event_type, payload, n)
+		JOIN webhook w ON w.owner_id = $1 AND w.healthy AND d.event_type = ANY(w.event_types)
+		ORDER BY d.n, w.id
+	`
+	_, err := r.db.Exec(ctx, query, ownerId, eventTypes, payloads)
+	return err
+}
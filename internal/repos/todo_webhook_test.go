@@ -0,0 +1,31 @@
+package repos
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestAddWebhookDeliveries(t *testing.T) {
+	mockDB, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer mockDB.Close(context.Background())
+
+	r := NewTodoRepo(NewDB(mockDB), testMaxDepth)
+
+	deliveries := []models.WebhookDelivery{
+		{EventType: models.WebhookTodoCreated, Payload: json.RawMessage(`{"todo_id":1}`)},
+		{EventType: models.WebhookTodoDeleted, Payload: json.RawMessage(`{"todo_id":2}`)},
+	}
+	mockDB.ExpectExec("INSERT INTO webhook_delivery \\(webhook_id, event_type, payload\\) SELECT (.+) JOIN webhook w ON w.owner_id = \\$1 AND w.healthy AND d.event_type = ANY\\(w.event_types\\)").
+		WithArgs(testUserId, []string{"todo.created", "todo.deleted"}, []string{`{"todo_id":1}`, `{"todo_id":2}`}).
+		WillReturnResult(pgxmock.NewResult("INSERT", 3))
+
+	assert.NoError(t, r.AddWebhookDeliveries(context.Background(), testUserId, deliveries))
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
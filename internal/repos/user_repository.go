@@ -0,0 +1,57 @@
+package repos
+
+import (
+	"context"
+	"errors"
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+type UserRepositoryImpl struct {
+	db PgxConnIface
+}
+
+func NewUserRepo(db PgxConnIface) UserRepository {
+	return &UserRepositoryImpl{db: db}
+}
+
+var (
+	ErrUserNotFound = errors.New("user not found")
+	ErrEmailTaken   = errors.New("a user with this email already exists")
+)
+
+// CreateUser inserts a user together with the inbox project every user owns
+func (r *UserRepositoryImpl) CreateUser(ctx context.Context, user models.UserModel) (models.UserModel, error) {
+	query := `
+		WITH created AS (
+			INSERT INTO users (email, password_hash)
+			VALUES ($1, $2)
+			RETURNING id, created_at
+		), inbox AS (
+			INSERT INTO project (name, description, inbox, owner_id)
+			SELECT 'Inbox', 'Todos of deleted projects', TRUE, id FROM created
+		)
+		SELECT id, created_at FROM created
+	`
+	err := r.db.QueryRow(ctx, query, user.Email, user.PasswordHash).Scan(&user.Id, &user.CreatedAt)
+	if err != nil {
+		if isPgError(err, pgUniqueViolation) {
+			return models.UserModel{}, ErrEmailTaken
+		}
+		return models.UserModel{}, err
+	}
+	return user, nil
+}
+
+func (r *UserRepositoryImpl) GetUserByEmail(ctx context.Context, email string) (models.UserModel, error) {
+	var user models.UserModel
+	err := r.db.QueryRow(ctx, "SELECT id, email, password_hash, created_at FROM users WHERE email = $1", email).
+		Scan(&user.Id, &user.Email, &user.PasswordHash, &user.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.UserModel{}, ErrUserNotFound
+		}
+		return models.UserModel{}, err
+	}
+	return user, nil
+}
@@ -0,0 +1,117 @@
+package repos
+
+import (
+	"context"
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestCreateUser(t *testing.T) {
+	mockDB, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer mockDB.Close(context.Background())
+
+	r := NewUserRepo(NewDB(mockDB))
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    models.UserModel
+		wantErr error
+	}{
+		{
+			name: "Ok",
+			mock: func() {
+				rows := pgxmock.NewRows([]string{"id", "created_at"}).AddRow(1, now)
+				mockDB.ExpectQuery("WITH created AS \\( INSERT INTO users (.+) \\), inbox AS \\( INSERT INTO project (.+) \\) SELECT id, created_at FROM created").
+					WithArgs("jane@example.com", "hash").
+					WillReturnRows(rows)
+			},
+			want: models.UserModel{Id: 1, Email: "jane@example.com", PasswordHash: "hash", CreatedAt: now},
+		},
+		{
+			name: "Email Taken",
+			mock: func() {
+				mockDB.ExpectQuery("INSERT INTO users").
+					WithArgs("jane@example.com", "hash").
+					WillReturnError(&pgconn.PgError{Code: pgUniqueViolation})
+			},
+			wantErr: ErrEmailTaken,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			got, err := r.CreateUser(context.Background(), models.UserModel{Email: "jane@example.com", PasswordHash: "hash"})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+			assert.NoError(t, mockDB.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestGetUserByEmail(t *testing.T) {
+	mockDB, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer mockDB.Close(context.Background())
+
+	r := NewUserRepo(NewDB(mockDB))
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    models.UserModel
+		wantErr error
+	}{
+		{
+			name: "Ok",
+			mock: func() {
+				rows := pgxmock.NewRows([]string{"id", "email", "password_hash", "created_at"}).
+					AddRow(1, "jane@example.com", "hash", now)
+				mockDB.ExpectQuery("SELECT id, email, password_hash, created_at FROM users WHERE email = \\$1").
+					WithArgs("jane@example.com").
+					WillReturnRows(rows)
+			},
+			want: models.UserModel{Id: 1, Email: "jane@example.com", PasswordHash: "hash", CreatedAt: now},
+		},
+		{
+			name: "Not Found",
+			mock: func() {
+				mockDB.ExpectQuery("FROM users WHERE email = \\$1").
+					WithArgs("jane@example.com").
+					WillReturnError(pgx.ErrNoRows)
+			},
+			wantErr: ErrUserNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			got, err := r.GetUserByEmail(context.Background(), "jane@example.com")
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+			assert.NoError(t, mockDB.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,237 @@
+package repos
+
+import (
+	"context"
+	"errors"
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/jackc/pgx/v5"
+	"time"
+)
+
+type WebhookRepositoryImpl struct {
+	db PgxConnIface
+}
+
+func NewWebhookRepo(db PgxConnIface) WebhookRepository {
+	return &WebhookRepositoryImpl{db: db}
+}
+
+var (
+	ErrWebhookNotFound = errors.New("webhook not found")
+)
+
+// webhookColumns is the column list scanned by scanWebhook, the secret is left out
+const webhookColumns = "id, url, event_types, healthy, failure_count, created_at"
+
+// scanWebhook scans a row selected with webhookColumns
+func scanWebhook(row pgx.Row, webhook *models.WebhookModel) error {
+	err := row.Scan(&webhook.Id, &webhook.Url, &webhook.EventTypes, &webhook.Healthy, &webhook.FailureCount, &webhook.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrWebhookNotFound
+	}
+	return err
+}
+
+// GetAllWebhooks returns the webhooks of the owner
+func (r *WebhookRepositoryImpl) GetAllWebhooks(ctx context.Context, ownerId int) ([]models.WebhookModel, error) {
+	rows, err := r.db.Query(ctx, "SELECT "+webhookColumns+" FROM webhook WHERE owner_id = $1 ORDER BY id", ownerId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	webhooks := []models.WebhookModel{}
+	for rows.Next() {
+		var webhook models.WebhookModel
+		if err = scanWebhook(rows, &webhook); err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, webhook)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+func (r *WebhookRepositoryImpl) GetWebhookById(ctx context.Context, ownerId, id int) (models.WebhookModel, error) {
+	var webhook models.WebhookModel
+	query := "SELECT " + webhookColumns + " FROM webhook WHERE id = $1 AND owner_id = $2"
+	if err := scanWebhook(r.db.QueryRow(ctx, query, id, ownerId), &webhook); err != nil {
+		return models.WebhookModel{}, err
+	}
+	return webhook, nil
+}
+
+func (r *WebhookRepositoryImpl) CreateWebhook(ctx context.Context, ownerId int, webhook models.WebhookModel) (models.WebhookModel, error) {
+	query := `
+		INSERT INTO webhook (owner_id, url, secret, event_types)
+		VALUES ($1, $2, $3, $4)
+		RETURNING ` + webhookColumns
+	var createdWebhook models.WebhookModel
+	if err := scanWebhook(r.db.QueryRow(ctx, query, ownerId, webhook.Url, webhook.Secret, webhook.EventTypes), &createdWebhook); err != nil {
+		return models.WebhookModel{}, err
+	}
+	return createdWebhook, nil
+}
+
+// UpdateWebhook replaces the url and event types of a webhook, and its secret unless it is empty. The webhook is
+// healthy again, so that its pending deliveries are retried
+func (r *WebhookRepositoryImpl) UpdateWebhook(ctx context.Context, ownerId, id int, webhook models.WebhookModel) (models.WebhookModel, error) {
+	query := `
+		UPDATE webhook
+		SET url = $1, event_types = $2, secret = COALESCE(NULLIF($3, ''), secret), healthy = TRUE, failure_count = 0
+		WHERE id = $4 AND owner_id = $5
+		RETURNING ` + webhookColumns
+	var updatedWebhook models.WebhookModel
+	if err := scanWebhook(r.db.QueryRow(ctx, query, webhook.Url, webhook.EventTypes, webhook.Secret, id, ownerId), &updatedWebhook); err != nil {
+		return models.WebhookModel{}, err
+	}
+	return updatedWebhook, nil
+}
+
+// DeleteWebhookById deletes a webhook together with its deliveries
+func (r *WebhookRepositoryImpl) DeleteWebhookById(ctx context.Context, ownerId, id int) error {
+	tag, err := r.db.Exec(ctx, "DELETE FROM webhook WHERE id = $1 AND owner_id = $2", id, ownerId)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+// GetWebhookDeliveries returns the latest deliveries of a webhook with their attempts, newest first
+func (r *WebhookRepositoryImpl) GetWebhookDeliveries(ctx context.Context, ownerId, webhookId, limit int) ([]models.WebhookDelivery, error) {
+	query := `
+		SELECT d.id, d.webhook_id, d.event_type, d.payload, d.status, d.next_attempt_at, d.created_at, d.delivered_at
+		FROM webhook_delivery d JOIN webhook w ON w.id = d.webhook_id
+		WHERE d.webhook_id = $1 AND w.owner_id = $2
+		ORDER BY d.id DESC LIMIT $3
+	`
+	rows, err := r.db.Query(ctx, query, webhookId, ownerId, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []models.WebhookDelivery{}
+	for rows.Next() {
+		delivery := models.WebhookDelivery{Attempts: []models.WebhookAttempt{}}
+		err = rows.Scan(&delivery.Id, &delivery.WebhookId, &delivery.EventType, &delivery.Payload, &delivery.Status,
+			&delivery.NextAttemptAt, &delivery.CreatedAt, &delivery.DeliveredAt)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return r.withAttempts(ctx, deliveries)
+}
+
+// withAttempts loads the attempts of deliveries in one query
+func (r *WebhookRepositoryImpl) withAttempts(ctx context.Context, deliveries []models.WebhookDelivery) ([]models.WebhookDelivery, error) {
+	if len(deliveries) == 0 {
+		return deliveries, nil
+	}
+	ids := make([]int64, len(deliveries))
+	index := make(map[int64]int, len(deliveries))
+	for i, delivery := range deliveries {
+		ids[i] = delivery.Id
+		index[delivery.Id] = i
+	}
+	query := `
+		SELECT delivery_id, status_code, error, duration_ms, attempted_at FROM webhook_attempt
+		WHERE delivery_id = ANY($1) ORDER BY id
+	`
+	rows, err := r.db.Query(ctx, query, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var deliveryId int64
+		var attempt models.WebhookAttempt
+		if err = rows.Scan(&deliveryId, &attempt.StatusCode, &attempt.Error, &attempt.DurationMs, &attempt.AttemptedAt); err != nil {
+			return nil, err
+		}
+		i := index[deliveryId]
+		deliveries[i].Attempts = append(deliveries[i].Attempts, attempt)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// ClaimWebhookDeliveries takes up to limit pending deliveries of healthy webhooks that are due at now. They are
+// not due again before leaseUntil, so another dispatcher only picks them up when this one failed to record
+// the attempt
+func (r *WebhookRepositoryImpl) ClaimWebhookDeliveries(ctx context.Context, now, leaseUntil time.Time, limit int) ([]models.WebhookJob, error) {
+	query := `
+		UPDATE webhook_delivery d SET next_attempt_at = $2
+		FROM webhook w
+		WHERE w.id = d.webhook_id AND d.id IN (
+			SELECT dd.id FROM webhook_delivery dd JOIN webhook ww ON ww.id = dd.webhook_id
+			WHERE dd.status = 'pending' AND dd.next_attempt_at <= $1 AND ww.healthy
+			ORDER BY dd.next_attempt_at, dd.id
+			LIMIT $3
+			FOR UPDATE OF dd SKIP LOCKED
+		)
+		RETURNING d.id, d.webhook_id, w.url, w.secret, d.event_type, d.payload, d.attempts, d.created_at
+	`
+	rows, err := r.db.Query(ctx, query, now, leaseUntil, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := []models.WebhookJob{}
+	for rows.Next() {
+		var job models.WebhookJob
+		err = rows.Scan(&job.DeliveryId, &job.WebhookId, &job.Url, &job.Secret, &job.EventType, &job.Payload, &job.Attempts, &job.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// RecordWebhookAttempt stores the outcome of an attempt of a delivery. A failed delivery is retried at retryAt or,
+// when retryAt is nil, given up. The webhook counts its consecutive failures and turns unhealthy when they
+// reach unhealthyAfter
+func (r *WebhookRepositoryImpl) RecordWebhookAttempt(ctx context.Context, job models.WebhookJob, attempt models.WebhookAttempt, retryAt *time.Time, unhealthyAfter int) error {
+	succeeded := attempt.Error == ""
+	query := `
+		WITH attempt AS (
+			INSERT INTO webhook_attempt (delivery_id, status_code, error, duration_ms, attempted_at)
+			VALUES ($1, $2, $3, $4, $5)
+		), delivery AS (
+			UPDATE webhook_delivery
+			SET attempts = attempts + 1,
+				status = CASE WHEN $6::boolean THEN 'delivered' WHEN $7::timestamptz IS NULL THEN 'failed' ELSE 'pending' END,
+				next_attempt_at = $7,
+				delivered_at = CASE WHEN $6::boolean THEN $5 END
+			WHERE id = $1
+		)
+		UPDATE webhook
+		SET failure_count = CASE WHEN $6::boolean THEN 0 ELSE failure_count + 1 END,
+			healthy = $6::boolean OR failure_count + 1 < $8
+		WHERE id = $9
+	`
+	_, err := r.db.Exec(ctx, query, job.DeliveryId, attempt.StatusCode, attempt.Error, attempt.DurationMs, attempt.AttemptedAt,
+		succeeded, retryAt, unhealthyAfter, job.WebhookId)
+	return err
+}
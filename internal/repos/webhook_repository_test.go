@@ -0,0 +1,250 @@
+package repos
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+var webhookRowColumns = []string{"id", "url", "event_types", "healthy", "failure_count", "created_at"}
+
+func TestCreateWebhook(t *testing.T) {
+	mockDB, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer mockDB.Close(context.Background())
+
+	r := NewWebhookRepo(NewDB(mockDB))
+	now := time.Now()
+	eventTypes := []string{models.WebhookTodoCreated}
+
+	rows := pgxmock.NewRows(webhookRowColumns).AddRow(1, "https://example.com/hook", eventTypes, true, 0, now)
+	mockDB.ExpectQuery("INSERT INTO webhook \\(owner_id, url, secret, event_types\\) VALUES \\(\\$1, \\$2, \\$3, \\$4\\) RETURNING id, url, event_types, healthy, failure_count, created_at").
+		WithArgs(testUserId, "https://example.com/hook", "whsec_secret", eventTypes).
+		WillReturnRows(rows)
+
+	got, err := r.CreateWebhook(context.Background(), testUserId, models.WebhookModel{Url: "https://example.com/hook", Secret: "whsec_secret", EventTypes: eventTypes})
+	assert.NoError(t, err)
+	assert.Equal(t, models.WebhookModel{Id: 1, Url: "https://example.com/hook", EventTypes: eventTypes, Healthy: true, CreatedAt: now}, got)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestUpdateWebhook(t *testing.T) {
+	mockDB, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer mockDB.Close(context.Background())
+
+	r := NewWebhookRepo(NewDB(mockDB))
+	now := time.Now()
+	eventTypes := []string{models.WebhookTodoUpdated}
+	query := "UPDATE webhook SET url = \\$1, event_types = \\$2, secret = COALESCE\\(NULLIF\\(\\$3, ''\\), secret\\), healthy = TRUE, failure_count = 0 WHERE id = \\$4 AND owner_id = \\$5 RETURNING (.+)"
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    models.WebhookModel
+		wantErr error
+	}{
+		{
+			name: "Ok",
+			mock: func() {
+				rows := pgxmock.NewRows(webhookRowColumns).AddRow(1, "https://example.com/v2", eventTypes, true, 0, now)
+				mockDB.ExpectQuery(query).WithArgs("https://example.com/v2", eventTypes, "", 1, testUserId).WillReturnRows(rows)
+			},
+			want: models.WebhookModel{Id: 1, Url: "https://example.com/v2", EventTypes: eventTypes, Healthy: true, CreatedAt: now},
+		},
+		{
+			name: "Not Found",
+			mock: func() {
+				mockDB.ExpectQuery(query).WithArgs("https://example.com/v2", eventTypes, "", 1, testUserId).WillReturnError(pgx.ErrNoRows)
+			},
+			wantErr: ErrWebhookNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			got, err := r.UpdateWebhook(context.Background(), testUserId, 1, models.WebhookModel{Url: "https://example.com/v2", EventTypes: eventTypes})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+			assert.NoError(t, mockDB.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestDeleteWebhookById(t *testing.T) {
+	mockDB, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer mockDB.Close(context.Background())
+
+	r := NewWebhookRepo(NewDB(mockDB))
+	query := "DELETE FROM webhook WHERE id = \\$1 AND owner_id = \\$2"
+
+	tests := []struct {
+		name    string
+		mock    func()
+		wantErr error
+	}{
+		{
+			name: "Ok",
+			mock: func() {
+				mockDB.ExpectExec(query).WithArgs(1, testUserId).WillReturnResult(pgxmock.NewResult("DELETE", 1))
+			},
+		},
+		{
+			name: "Not Found",
+			mock: func() {
+				mockDB.ExpectExec(query).WithArgs(1, testUserId).WillReturnResult(pgxmock.NewResult("DELETE", 0))
+			},
+			wantErr: ErrWebhookNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			err := r.DeleteWebhookById(context.Background(), testUserId, 1)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mockDB.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestGetWebhookDeliveries(t *testing.T) {
+	mockDB, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer mockDB.Close(context.Background())
+
+	r := NewWebhookRepo(NewDB(mockDB))
+	now := time.Now()
+	retryAt := now.Add(time.Minute)
+	payload := json.RawMessage(`{"todo_id":1}`)
+	status := 500
+
+	rows := pgxmock.NewRows([]string{"id", "webhook_id", "event_type", "payload", "status", "next_attempt_at", "created_at", "delivered_at"}).
+		AddRow(int64(9), 1, models.WebhookTodoUpdated, payload, models.WebhookPending, &retryAt, now, noTime).
+		AddRow(int64(8), 1, models.WebhookTodoCreated, payload, models.WebhookDelivered, noTime, now, &now)
+	mockDB.ExpectQuery("SELECT d.id, (.+) FROM webhook_delivery d JOIN webhook w ON w.id = d.webhook_id WHERE d.webhook_id = \\$1 AND w.owner_id = \\$2 ORDER BY d.id DESC LIMIT \\$3").
+		WithArgs(1, testUserId, 20).
+		WillReturnRows(rows)
+	attemptRows := pgxmock.NewRows([]string{"delivery_id", "status_code", "error", "duration_ms", "attempted_at"}).
+		AddRow(int64(8), nil, "connection refused", int64(3), now).
+		AddRow(int64(9), &status, "unexpected status 500", int64(12), now)
+	mockDB.ExpectQuery("SELECT delivery_id, status_code, error, duration_ms, attempted_at FROM webhook_attempt WHERE delivery_id = ANY\\(\\$1\\) ORDER BY id").
+		WithArgs([]int64{9, 8}).
+		WillReturnRows(attemptRows)
+
+	got, err := r.GetWebhookDeliveries(context.Background(), testUserId, 1, 20)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.WebhookDelivery{
+		{
+			Id: 9, WebhookId: 1, EventType: models.WebhookTodoUpdated, Payload: payload, Status: models.WebhookPending,
+			Attempts:      []models.WebhookAttempt{{StatusCode: &status, Error: "unexpected status 500", DurationMs: 12, AttemptedAt: now}},
+			NextAttemptAt: &retryAt, CreatedAt: now,
+		},
+		{
+			Id: 8, WebhookId: 1, EventType: models.WebhookTodoCreated, Payload: payload, Status: models.WebhookDelivered,
+			Attempts:  []models.WebhookAttempt{{Error: "connection refused", DurationMs: 3, AttemptedAt: now}},
+			CreatedAt: now, DeliveredAt: &now,
+		},
+	}, got)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestClaimWebhookDeliveries(t *testing.T) {
+	mockDB, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer mockDB.Close(context.Background())
+
+	r := NewWebhookRepo(NewDB(mockDB))
+	now := time.Now()
+	leaseUntil := now.Add(5 * time.Minute)
+	payload := json.RawMessage(`{"todo_id":1}`)
+
+	rows := pgxmock.NewRows([]string{"id", "webhook_id", "url", "secret", "event_type", "payload", "attempts", "created_at"}).
+		AddRow(int64(9), 1, "https://example.com/hook", "whsec_secret", models.WebhookTodoUpdated, payload, 2, now)
+	mockDB.ExpectQuery("UPDATE webhook_delivery d SET next_attempt_at = \\$2 FROM webhook w (.+) WHERE dd.status = 'pending' AND dd.next_attempt_at <= \\$1 AND ww.healthy (.+) LIMIT \\$3 FOR UPDATE OF dd SKIP LOCKED \\) RETURNING (.+)").
+		WithArgs(now, leaseUntil, 20).
+		WillReturnRows(rows)
+
+	got, err := r.ClaimWebhookDeliveries(context.Background(), now, leaseUntil, 20)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.WebhookJob{{
+		DeliveryId: 9, WebhookId: 1, Url: "https://example.com/hook", Secret: "whsec_secret",
+		EventType: models.WebhookTodoUpdated, Payload: payload, Attempts: 2, CreatedAt: now,
+	}}, got)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestRecordWebhookAttempt(t *testing.T) {
+	mockDB, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("failed to create mock db: %v", err)
+	}
+	defer mockDB.Close(context.Background())
+
+	r := NewWebhookRepo(NewDB(mockDB))
+	now := time.Now()
+	retryAt := now.Add(time.Minute)
+	status := 503
+	job := models.WebhookJob{DeliveryId: 9, WebhookId: 1}
+	query := "WITH attempt AS \\( INSERT INTO webhook_attempt (.+) \\), delivery AS \\( UPDATE webhook_delivery (.+) \\) UPDATE webhook SET (.+) WHERE id = \\$9"
+
+	tests := []struct {
+		name    string
+		attempt models.WebhookAttempt
+		retryAt *time.Time
+		mock    func()
+	}{
+		{
+			name:    "Delivered",
+			attempt: models.WebhookAttempt{StatusCode: &status, DurationMs: 12, AttemptedAt: now},
+			mock: func() {
+				mockDB.ExpectExec(query).
+					WithArgs(int64(9), &status, "", int64(12), now, true, (*time.Time)(nil), 5, 1).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+			},
+		},
+		{
+			name:    "Retried",
+			attempt: models.WebhookAttempt{StatusCode: &status, Error: "unexpected status 503", DurationMs: 12, AttemptedAt: now},
+			retryAt: &retryAt,
+			mock: func() {
+				mockDB.ExpectExec(query).
+					WithArgs(int64(9), &status, "unexpected status 503", int64(12), now, false, &retryAt, 5, 1).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			assert.NoError(t, r.RecordWebhookAttempt(context.Background(), job, tt.attempt, tt.retryAt, 5))
+			assert.NoError(t, mockDB.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"github.com/cherrycutter/todo_app/internal/auth"
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/cherrycutter/todo_app/internal/repos"
+	"strings"
+)
+
+type APIKeyServiceImpl struct {
+	repo repos.APIKeyRepository
+}
+
+func NewAPIKeyService(repo repos.APIKeyRepository) APIKeyService {
+	return &APIKeyServiceImpl{repo: repo}
+}
+
+var (
+	ErrInvalidAPIKey = errors.New("api key is invalid or revoked")
+)
+
+// apiKeyPrefixLength is the number of leading characters of a key that are stored in clear to recognize it
+const apiKeyPrefixLength = len(auth.APIKeyPrefix) + 6
+
+func (s *APIKeyServiceImpl) GetAPIKeys(ctx context.Context) ([]models.APIKeyModel, error) {
+	userId, err := currentUserId(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetAllAPIKeys(ctx, userId)
+}
+
+// CreateAPIKey creates a key for the user, the returned secret is not stored and cannot be shown again
+func (s *APIKeyServiceImpl) CreateAPIKey(ctx context.Context, key models.APIKeyModel) (models.CreatedAPIKey, error) {
+	if err := s.validateAPIKeyInput(key); err != nil {
+		return models.CreatedAPIKey{}, err
+	}
+	userId, err := currentUserId(ctx)
+	if err != nil {
+		return models.CreatedAPIKey{}, err
+	}
+	secret, err := auth.NewAPIKey()
+	if err != nil {
+		return models.CreatedAPIKey{}, err
+	}
+	key.Prefix = secret[:apiKeyPrefixLength]
+	createdKey, err := s.repo.CreateAPIKey(ctx, userId, key, auth.HashToken(secret))
+	if err != nil {
+		return models.CreatedAPIKey{}, err
+	}
+	return models.CreatedAPIKey{APIKeyModel: createdKey, Key: secret}, nil
+}
+
+// RotateAPIKey gives a key a new secret while keeping its name and scopes
+func (s *APIKeyServiceImpl) RotateAPIKey(ctx context.Context, id int) (models.CreatedAPIKey, error) {
+	userId, err := currentUserId(ctx)
+	if err != nil {
+		return models.CreatedAPIKey{}, err
+	}
+	secret, err := auth.NewAPIKey()
+	if err != nil {
+		return models.CreatedAPIKey{}, err
+	}
+	rotatedKey, err := s.repo.RotateAPIKey(ctx, userId, id, secret[:apiKeyPrefixLength], auth.HashToken(secret))
+	if err != nil {
+		return models.CreatedAPIKey{}, err
+	}
+	return models.CreatedAPIKey{APIKeyModel: rotatedKey, Key: secret}, nil
+}
+
+func (s *APIKeyServiceImpl) RevokeAPIKey(ctx context.Context, id int) error {
+	userId, err := currentUserId(ctx)
+	if err != nil {
+		return err
+	}
+	return s.repo.RevokeAPIKey(ctx, userId, id)
+}
+
+// Authenticate returns the user and the scopes of an API key
+func (s *APIKeyServiceImpl) Authenticate(ctx context.Context, key string) (int, []string, error) {
+	if !strings.HasPrefix(key, auth.APIKeyPrefix) {
+		return 0, nil, ErrInvalidAPIKey
+	}
+	userId, scopes, err := s.repo.UseAPIKey(ctx, auth.HashToken(key))
+	if err != nil {
+		if errors.Is(err, repos.ErrAPIKeyNotFound) {
+			return 0, nil, ErrInvalidAPIKey
+		}
+		return 0, nil, err
+	}
+	return userId, scopes, nil
+}
+
+func (s *APIKeyServiceImpl) validateAPIKeyInput(key models.APIKeyModel) error {
+	if key.Name == "" {
+		return &ValidationError{Message: "field name cannot be empty"}
+	}
+	if len(key.Name) > 64 {
+		return &ValidationError{Message: "name cannot be longer than 64 characters"}
+	}
+	if len(key.Scopes) == 0 {
+		return &ValidationError{Message: "field scopes cannot be empty"}
+	}
+	for _, scope := range key.Scopes {
+		if !auth.IsScope(scope) {
+			return &ValidationError{Message: "unknown scope " + scope + ", expected one of " + strings.Join(auth.Scopes, ", ")}
+		}
+	}
+	return nil
+}
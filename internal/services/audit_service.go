@@ -0,0 +1,220 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/cherrycutter/todo_app/internal/repos"
+)
+
+// GetTodoHistory returns the audit entries of a todo newest first
+func (s *TodoServiceImpl) GetTodoHistory(ctx context.Context, id int, params models.ListParams) (models.AuditPage, error) {
+	return s.GetAuditLog(ctx, models.AuditFilter{TodoId: &id}, params)
+}
+
+// GetAuditLog returns a filtered page of the audit entries of the user newest first
+func (s *TodoServiceImpl) GetAuditLog(ctx context.Context, filter models.AuditFilter, params models.ListParams) (models.AuditPage, error) {
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return models.AuditPage{}, &ValidationError{Message: "param from must be before param to"}
+	}
+	userId, err := currentUserId(ctx)
+	if err != nil {
+		return models.AuditPage{}, err
+	}
+	var after *models.AuditCursor
+	if params.Cursor != "" {
+		cursor, err := decodeAuditCursor(params.Cursor)
+		if err != nil {
+			return models.AuditPage{}, err
+		}
+		after = &cursor
+	}
+	limit := params.Limit
+	if limit <= 0 {
+		limit = models.DefaultPageLimit
+	}
+
+	// one extra row tells whether there is a next page
+	entries, err := s.repo.GetAuditEntries(ctx, userId, filter, limit+1, after)
+	if err != nil {
+		return models.AuditPage{}, err
+	}
+	page := models.AuditPage{Items: entries}
+	if len(entries) > limit {
+		page.Items = entries[:limit]
+		page.NextCursor, err = encodeCursor(models.AuditCursor{Id: page.Items[limit-1].Id})
+		if err != nil {
+			return models.AuditPage{}, err
+		}
+	}
+	if page.Items == nil {
+		page.Items = []models.AuditEntry{}
+	}
+	return page, nil
+}
+
+// audited runs a change of a todo in a transaction that also records it in the audit log and the event log.
+// change returns the todo before and after the change, before is nil for a create and after for a delete
+func (s *TodoServiceImpl) audited(ctx context.Context, userId int, op models.AuditOperation, change auditedChange) error {
+	return s.auditedChanges(ctx, userId, func(repo repos.TodoRepository) ([]todoChange, error) {
+		before, after, err := change(repo)
+		if err != nil {
+			return nil, err
+		}
+		return []todoChange{{op: op, before: before, after: after}}, nil
+	})
+}
+
+// auditedChange changes a todo through repo and returns the todo before and after the change
+type auditedChange func(repo repos.TodoRepository) (before, after *models.TodoModel, err error)
+
+// auditedChanges is audited for a change writing several todos, change returns a todoChange for each of them
+func (s *TodoServiceImpl) auditedChanges(ctx context.Context, userId int, change func(repo repos.TodoRepository) ([]todoChange, error)) error {
+	return inAuditedTx(ctx, s.repo, userId, change)
+}
+
+// inAuditedTx runs change in a transaction of todos and saves the changes it returns in the same transaction,
+// it lets the services of projects and tags audit the todos they write
+func inAuditedTx(ctx context.Context, todos repos.TodoRepository, userId int, change func(repo repos.TodoRepository) ([]todoChange, error)) error {
+	return todos.InTx(ctx, func(repo repos.TodoRepository) error {
+		changes, err := change(repo)
+		if err != nil {
+			return err
+		}
+		return saveChanges(ctx, repo, userId, changes)
+	})
+}
+
+// writtenChanges compares two states of a subtree read with LockSubtree and returns a change of op for every todo
+// whose version moved in between, a todo missing from after is deleted
+func writtenChanges(op models.AuditOperation, before, after []models.TodoModel) []todoChange {
+	written := make(map[int]*models.TodoModel, len(after))
+	for i := range after {
+		written[after[i].Id] = &after[i]
+	}
+	var changes []todoChange
+	for i := range before {
+		todo, ok := written[before[i].Id]
+		if !ok || todo.Version != before[i].Version {
+			changes = append(changes, todoChange{op: op, before: &before[i], after: todo})
+		}
+	}
+	return changes
+}
+
+// touchedChanges compares two states of todos written by a change of a project or a tag and returns an update for
+// every todo whose version moved in between, or a delete for a todo that went to the trash
+func touchedChanges(before, after []models.TodoModel) []todoChange {
+	var changes []todoChange
+	for _, change := range writtenChanges(models.AuditUpdate, before, after) {
+		if change.after == nil || (change.before.DeletedAt == nil && change.after.DeletedAt != nil) {
+			change.op, change.after = models.AuditDelete, nil
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// todoIds returns the ids of todos
+func todoIds(todos []models.TodoModel) []int {
+	ids := make([]int, len(todos))
+	for i, todo := range todos {
+		ids[i] = todo.Id
+	}
+	return ids
+}
+
+// todoChange is a change of a todo made by the user, before is nil for a create and after for a delete
+type todoChange struct {
+	op     models.AuditOperation
+	before *models.TodoModel
+	after  *models.TodoModel
+}
+
+// saveChanges adds changes of todos to the audit log, the event log and the webhook outbox in the transaction
+// of repo
+func saveChanges(ctx context.Context, repo repos.TodoRepository, userId int, changes []todoChange) error {
+	if len(changes) == 0 {
+		return nil
+	}
+	entries := make([]models.AuditEntry, len(changes))
+	events := make([]models.TodoEvent, len(changes))
+	deliveries := make([]models.WebhookDelivery, len(changes))
+	for i, change := range changes {
+		entries[i] = newAuditEntry(userId, change.op, change.before, change.after)
+		events[i] = newTodoEvent(change.op, entries[i].TodoId, change.before, change.after)
+		delivery, err := newWebhookDelivery(events[i])
+		if err != nil {
+			return err
+		}
+		deliveries[i] = delivery
+	}
+	if err := repo.AddAuditEntries(ctx, userId, entries); err != nil {
+		return err
+	}
+	if err := repo.AddTodoEvents(ctx, userId, events); err != nil {
+		return err
+	}
+	return repo.AddWebhookDeliveries(ctx, userId, deliveries)
+}
+
+// newAuditEntry describes a change of a todo made by the actor
+func newAuditEntry(actorId int, op models.AuditOperation, before, after *models.TodoModel) models.AuditEntry {
+	entry := models.AuditEntry{ActorId: actorId, Operation: op, Changes: auditChanges(before, after)}
+	if after != nil {
+		entry.TodoId = after.Id
+	} else if before != nil {
+		entry.TodoId = before.Id
+	}
+	return entry
+}
+
+// auditChanges lists the audited fields whose value differs between two states of a todo
+func auditChanges(before, after *models.TodoModel) map[string]models.AuditChange {
+	oldFields, newFields := auditedFields(before), auditedFields(after)
+	changes := make(map[string]models.AuditChange)
+	for name := range auditedFields(&models.TodoModel{}) {
+		oldValue, newValue := oldFields[name], newFields[name]
+		if sameJSON(oldValue, newValue) {
+			continue
+		}
+		changes[name] = models.AuditChange{Old: oldValue, New: newValue}
+	}
+	return changes
+}
+
+// auditedFields returns the fields of a todo recorded in the audit log by their JSON name, nil for no todo
+func auditedFields(todo *models.TodoModel) map[string]interface{} {
+	if todo == nil {
+		return nil
+	}
+	return map[string]interface{}{
+		"title":       todo.Title,
+		"description": todo.Description,
+		"completed":   todo.Completed,
+		"due_at":      todo.DueAt,
+		"remind_at":   todo.RemindAt,
+		"project_id":  todo.ProjectId,
+		"parent_id":   todo.ParentId,
+		"position":    todo.Position,
+		"recurrence":  todo.Recurrence,
+		"tags":        tagNames(todo.Tags),
+	}
+}
+
+// tagNames returns the names of tags, nil when there are none
+func tagNames(tags []models.TagModel) []string {
+	var names []string
+	for _, tag := range tags {
+		names = append(names, tag.Name)
+	}
+	return names
+}
+
+// sameJSON tells whether two values have the same JSON encoding, a missing value equals null
+func sameJSON(a, b interface{}) bool {
+	rawA, errA := json.Marshal(a)
+	rawB, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(rawA, rawB)
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"context"
+	"github.com/cherrycutter/todo_app/internal/auth"
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// auditedOperations returns the todo and the operation of the audit entries recorded since the entry from
+func auditedOperations(repo *fakeTodoRepo, from int) map[int]models.AuditOperation {
+	ops := make(map[int]models.AuditOperation)
+	for _, entry := range repo.entries[from:] {
+		ops[entry.TodoId] = entry.Operation
+	}
+	return ops
+}
+
+func TestTrashAudited(t *testing.T) {
+	parentId, subtaskId := 1, 2
+	repo := newFakeTodoRepo(
+		models.TodoModel{Id: 1, Title: "Move out", Version: 1},
+		models.TodoModel{Id: 2, Title: "Pack", ParentId: &parentId, Version: 1},
+		models.TodoModel{Id: 3, Title: "Books", ParentId: &subtaskId, Version: 1},
+	)
+	s := NewTodoService(repo)
+	ctx := auth.WithUserId(context.Background(), testUserId)
+
+	assert.NoError(t, s.DeleteTodo(ctx, 1, nil))
+	assert.Equal(t, map[int]models.AuditOperation{1: models.AuditDelete, 2: models.AuditDelete, 3: models.AuditDelete}, auditedOperations(repo, 0))
+
+	from := len(repo.entries)
+	trashedVersion := repo.todos[1].Version
+	restored, err := s.RestoreTodo(ctx, 1)
+	assert.NoError(t, err)
+	assert.Nil(t, restored.DeletedAt)
+	assert.Greater(t, restored.Version, trashedVersion)
+	assert.Equal(t, map[int]models.AuditOperation{1: models.AuditRestore, 2: models.AuditRestore, 3: models.AuditRestore}, auditedOperations(repo, from))
+	// the todos come back as they were trashed
+	for _, entry := range repo.entries[from:] {
+		assert.Empty(t, entry.Changes)
+	}
+
+	assert.NoError(t, s.DeleteTodo(ctx, 1, nil))
+	from = len(repo.entries)
+	assert.NoError(t, s.DeleteTrashedTodo(ctx, 1))
+	assert.Empty(t, repo.todos)
+	assert.Equal(t, map[int]models.AuditOperation{1: models.AuditPurge, 2: models.AuditPurge, 3: models.AuditPurge}, auditedOperations(repo, from))
+	for _, event := range repo.events[from:] {
+		assert.Equal(t, models.TodoDeleted, event.Type)
+		assert.NotNil(t, event.Todo)
+	}
+	assert.Len(t, repo.deliveries, len(repo.entries))
+}
+
+func TestSpawnedInstanceAudited(t *testing.T) {
+	due := time.Date(2024, 6, 3, 9, 0, 0, 0, time.UTC)
+	rule := "FREQ=WEEKLY"
+	repo := newFakeTodoRepo(models.TodoModel{Id: 1, Title: "Take out the trash", DueAt: &due, Recurrence: &rule, Version: 1})
+	s := NewTodoService(repo)
+	ctx := auth.WithUserId(context.Background(), testUserId)
+
+	_, err := s.PatchTodo(ctx, 1, models.TodoPatch{Completed: models.PatchField[bool]{Set: true, Value: true}}, models.TodoUpdateOptions{})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, map[int]models.AuditOperation{1: models.AuditUpdate, 2: models.AuditCreate}, auditedOperations(repo, 0))
+	if assert.Len(t, repo.events, 2) {
+		assert.Equal(t, models.TodoCreated, repo.events[1].Type)
+		assert.Equal(t, 2, repo.events[1].Todo.Id)
+	}
+}
+
+func TestProjectDeleteAudited(t *testing.T) {
+	projectId, parentId := 3, 1
+	repo := newFakeTodoRepo(
+		models.TodoModel{Id: 1, Title: "Move out", ProjectId: &projectId, Version: 1},
+		models.TodoModel{Id: 2, Title: "Pack", ParentId: &parentId, Version: 1},
+		models.TodoModel{Id: 3, Title: "Water the plants", Version: 1},
+	)
+	s := NewProjectService(repo.Projects(), repo)
+	ctx := auth.WithUserId(context.Background(), testUserId)
+
+	assert.NoError(t, s.DeleteProject(ctx, projectId, models.ProjectDeleteCascade))
+	assert.Equal(t, map[int]models.AuditOperation{1: models.AuditDelete, 2: models.AuditDelete}, auditedOperations(repo, 0))
+
+	projectId = 4
+	repo = newFakeTodoRepo(models.TodoModel{Id: 1, Title: "Move out", ProjectId: &projectId, Version: 1})
+	s = NewProjectService(repo.Projects(), repo)
+
+	assert.NoError(t, s.DeleteProject(ctx, projectId, models.ProjectDeleteMoveToInbox))
+	if assert.Len(t, repo.entries, 1) {
+		assert.Equal(t, models.AuditUpdate, repo.entries[0].Operation)
+		assert.Contains(t, repo.entries[0].Changes, "project_id")
+	}
+}
+
+func TestTagChangesAudited(t *testing.T) {
+	backend := models.TagModel{Id: 5, Name: "backend"}
+	repo := newFakeTodoRepo(
+		models.TodoModel{Id: 1, Title: "Add the audit log", Tags: []models.TagModel{backend}, Version: 1},
+		models.TodoModel{Id: 2, Title: "Water the plants", Version: 1},
+	)
+	s := NewTagService(repo.Tags(), repo)
+	ctx := auth.WithUserId(context.Background(), testUserId)
+
+	assert.NoError(t, s.AttachTag(ctx, 2, backend.Id))
+	assert.Equal(t, map[int]models.AuditOperation{2: models.AuditUpdate}, auditedOperations(repo, 0))
+
+	from := len(repo.entries)
+	_, err := s.UpdateTag(ctx, backend.Id, models.TagModel{Name: "server"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[int]models.AuditOperation{1: models.AuditUpdate, 2: models.AuditUpdate}, auditedOperations(repo, from))
+	assert.Contains(t, repo.entries[from].Changes, "tags")
+
+	from = len(repo.entries)
+	assert.NoError(t, s.DetachTag(ctx, 1, backend.Id))
+	assert.Equal(t, map[int]models.AuditOperation{1: models.AuditUpdate}, auditedOperations(repo, from))
+
+	from = len(repo.entries)
+	assert.NoError(t, s.DeleteTag(ctx, backend.Id))
+	assert.Equal(t, map[int]models.AuditOperation{2: models.AuditUpdate}, auditedOperations(repo, from))
+}
+
+func TestEmptyAuditLog(t *testing.T) {
+	repo := newFakeTodoRepo()
+	ctx := auth.WithUserId(context.Background(), testUserId)
+
+	page, err := NewTodoService(repo).GetAuditLog(ctx, models.AuditFilter{}, models.ListParams{})
+	assert.NoError(t, err)
+	// an empty log is listed as [] rather than null
+	assert.NotNil(t, page.Items)
+	assert.Empty(t, page.Items)
+	assert.Empty(t, page.NextCursor)
+}
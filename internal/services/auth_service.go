@@ -0,0 +1,147 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"github.com/cherrycutter/todo_app/internal/auth"
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/cherrycutter/todo_app/internal/repos"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+type AuthServiceImpl struct {
+	repo            repos.UserRepository
+	tokenRepo       repos.RefreshTokenRepository
+	signer          *auth.TokenSigner
+	refreshTokenTTL time.Duration
+}
+
+func NewAuthService(repo repos.UserRepository, tokenRepo repos.RefreshTokenRepository, signer *auth.TokenSigner, refreshTokenTTL time.Duration) AuthService {
+	return &AuthServiceImpl{repo: repo, tokenRepo: tokenRepo, signer: signer, refreshTokenTTL: refreshTokenTTL}
+}
+
+var (
+	ErrUnauthenticated     = errors.New("authentication required")
+	ErrInvalidCredentials  = errors.New("invalid email or password")
+	ErrInvalidRefreshToken = errors.New("refresh token is invalid, expired or revoked")
+)
+
+const (
+	minPasswordLength = 8
+	// maxPasswordLength is the bcrypt input limit, longer passwords would be silently truncated
+	maxPasswordLength = 72
+)
+
+// currentUserId returns the id of the authenticated user of the request
+func currentUserId(ctx context.Context) (int, error) {
+	userId, ok := auth.UserIdFromContext(ctx)
+	if !ok {
+		return 0, ErrUnauthenticated
+	}
+	return userId, nil
+}
+
+// Register creates a new user, the email is stored lower-cased
+func (s *AuthServiceImpl) Register(ctx context.Context, credentials models.Credentials) (models.UserModel, error) {
+	email := normalizeEmail(credentials.Email)
+	if err := validateCredentials(email, credentials.Password); err != nil {
+		return models.UserModel{}, err
+	}
+	hash, err := auth.HashPassword(credentials.Password)
+	if err != nil {
+		return models.UserModel{}, err
+	}
+	return s.repo.CreateUser(ctx, models.UserModel{Email: email, PasswordHash: hash})
+}
+
+// Login issues a token pair for the user matching the credentials, unknown emails and wrong passwords
+// both give ErrInvalidCredentials
+func (s *AuthServiceImpl) Login(ctx context.Context, credentials models.Credentials) (models.TokenPair, error) {
+	user, err := s.repo.GetUserByEmail(ctx, normalizeEmail(credentials.Email))
+	if err != nil && !errors.Is(err, repos.ErrUserNotFound) {
+		return models.TokenPair{}, err
+	}
+	ok, err := auth.CheckPassword(user.PasswordHash, credentials.Password)
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+	if !ok {
+		return models.TokenPair{}, ErrInvalidCredentials
+	}
+
+	refreshToken, err := auth.NewRefreshToken()
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+	if err = s.tokenRepo.CreateRefreshToken(ctx, user.Id, auth.HashToken(refreshToken), time.Now().Add(s.refreshTokenTTL)); err != nil {
+		return models.TokenPair{}, err
+	}
+	return s.tokenPair(user.Id, refreshToken)
+}
+
+// Refresh exchanges a refresh token for a new token pair, the old refresh token is revoked
+func (s *AuthServiceImpl) Refresh(ctx context.Context, refreshToken string) (models.TokenPair, error) {
+	if refreshToken == "" {
+		return models.TokenPair{}, ErrInvalidRefreshToken
+	}
+	newRefreshToken, err := auth.NewRefreshToken()
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+	userId, err := s.tokenRepo.RotateRefreshToken(ctx, auth.HashToken(refreshToken), auth.HashToken(newRefreshToken), time.Now().Add(s.refreshTokenTTL))
+	if err != nil {
+		if errors.Is(err, repos.ErrRefreshTokenNotFound) {
+			return models.TokenPair{}, ErrInvalidRefreshToken
+		}
+		return models.TokenPair{}, err
+	}
+	return s.tokenPair(userId, newRefreshToken)
+}
+
+// Logout revokes a refresh token, the access tokens issued with it stay valid until they expire
+func (s *AuthServiceImpl) Logout(ctx context.Context, refreshToken string) error {
+	if refreshToken == "" {
+		return ErrInvalidRefreshToken
+	}
+	return s.tokenRepo.RevokeRefreshToken(ctx, auth.HashToken(refreshToken))
+}
+
+// Authenticate verifies an access token and returns the id of its user
+func (s *AuthServiceImpl) Authenticate(_ context.Context, accessToken string) (int, error) {
+	return s.signer.Parse(accessToken)
+}
+
+func (s *AuthServiceImpl) tokenPair(userId int, refreshToken string) (models.TokenPair, error) {
+	accessToken, expiresAt, err := s.signer.Issue(userId)
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+	return models.TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(time.Until(expiresAt).Round(time.Second).Seconds()),
+	}, nil
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func validateCredentials(email, password string) error {
+	if email == "" {
+		return &ValidationError{Message: "field email cannot be empty"}
+	}
+	if address, err := mail.ParseAddress(email); err != nil || address.Address != email {
+		return &ValidationError{Message: "email is not a valid address"}
+	}
+	if len(password) < minPasswordLength {
+		return &ValidationError{Message: "password must be at least 8 characters long"}
+	}
+	if len(password) > maxPasswordLength {
+		return &ValidationError{Message: "password cannot be longer than 72 bytes"}
+	}
+	return nil
+}
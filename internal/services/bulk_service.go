@@ -0,0 +1,230 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/cherrycutter/todo_app/internal/repos"
+)
+
+// BulkOperationError is returned when the operation Index of an atomic bulk request fails, none of the operations
+// were applied
+type BulkOperationError struct {
+	Index int
+	Err   error
+}
+
+func (e *BulkOperationError) Error() string {
+	return fmt.Sprintf("operation %d: %s", e.Index, e.Err)
+}
+
+func (e *BulkOperationError) Unwrap() error {
+	return e.Err
+}
+
+// bulkOperation is a validated bulk operation, todo and next are only set for creates and updates
+type bulkOperation struct {
+	models.TodoBulkOperation
+	todo models.TodoModel
+	next *models.TodoOccurrence
+}
+
+// BulkTodos runs a list of creates, updates and deletes in order. In atomic mode they share one transaction and
+// the first failure is returned as a BulkOperationError, in best-effort mode every result carries its own error.
+// Consecutive creates are sent to the database in one batch
+func (s *TodoServiceImpl) BulkTodos(ctx context.Context, req models.TodoBulkRequest) (models.TodoBulkResponse, error) {
+	if req.Mode != models.BulkAtomic && req.Mode != models.BulkBestEffort {
+		return models.TodoBulkResponse{}, &ValidationError{Message: "field mode must be atomic or best_effort"}
+	}
+	if len(req.Operations) == 0 {
+		return models.TodoBulkResponse{}, &ValidationError{Message: "field operations cannot be empty"}
+	}
+	if len(req.Operations) > models.MaxBulkOperations {
+		return models.TodoBulkResponse{}, &ValidationError{Message: fmt.Sprintf("a bulk request cannot have more than %d operations", models.MaxBulkOperations)}
+	}
+	userId, err := currentUserId(ctx)
+	if err != nil {
+		return models.TodoBulkResponse{}, err
+	}
+	atomic := req.Mode == models.BulkAtomic
+
+	ops := make([]bulkOperation, len(req.Operations))
+	results := make([]models.TodoBulkResult, len(req.Operations))
+	for i, op := range req.Operations {
+		results[i] = models.TodoBulkResult{Index: i, Op: op.Op, Id: op.Id}
+		if ops[i], err = s.prepareBulkOperation(op); err != nil {
+			if atomic {
+				return models.TodoBulkResponse{}, &BulkOperationError{Index: i, Err: err}
+			}
+			results[i].Err = err
+		}
+	}
+
+	if atomic {
+		err = s.repo.InTx(ctx, func(repo repos.TodoRepository) error {
+			return s.runBulk(ctx, repo, userId, ops, results, true)
+		})
+	} else {
+		err = s.runBulk(ctx, s.repo, userId, ops, results, false)
+	}
+	if err != nil {
+		return models.TodoBulkResponse{}, err
+	}
+	return models.TodoBulkResponse{Results: results}, nil
+}
+
+// prepareBulkOperation validates an operation like the matching single todo request
+func (s *TodoServiceImpl) prepareBulkOperation(op models.TodoBulkOperation) (bulkOperation, error) {
+	prepared := bulkOperation{TodoBulkOperation: op}
+	switch op.Op {
+	case models.BulkCreate, models.BulkUpdate:
+		if op.Todo == nil {
+			return prepared, &ValidationError{Message: "field todo is required"}
+		}
+		prepared.todo = *op.Todo
+	case models.BulkDelete:
+	default:
+		return prepared, &ValidationError{Message: "field op must be create, update or delete"}
+	}
+	if op.Op != models.BulkCreate && op.Id <= 0 {
+		return prepared, &ValidationError{Message: "field id must be a positive number"}
+	}
+	if op.Op == models.BulkDelete {
+		return prepared, nil
+	}
+
+	if err := s.validateTodoInput(prepared.todo); err != nil {
+		return prepared, err
+	}
+	var err error
+	if prepared.todo.Recurrence, err = validateRecurrence(prepared.todo); err != nil {
+		return prepared, err
+	}
+	if op.Op == models.BulkUpdate {
+		if prepared.next, err = nextOccurrence(prepared.todo); err != nil {
+			return prepared, err
+		}
+	}
+	return prepared, nil
+}
+
+// runBulk applies the operations that passed the validation and fills in their results. An atomic run uses
+// the repository of its transaction and returns the first failure, a best-effort run gives every operation, or run
+// of creates, a transaction of its own
+func (s *TodoServiceImpl) runBulk(ctx context.Context, repo repos.TodoRepository, userId int, ops []bulkOperation, results []models.TodoBulkResult, atomic bool) error {
+	inTx := func(fn func(repo repos.TodoRepository) error) error {
+		if atomic {
+			return fn(repo)
+		}
+		return s.repo.InTx(ctx, fn)
+	}
+	for i := 0; i < len(ops); {
+		if results[i].Err != nil {
+			i++
+			continue
+		}
+		if ops[i].Op == models.BulkCreate {
+			end := i
+			for end < len(ops) && ops[end].Op == models.BulkCreate && results[end].Err == nil {
+				end++
+			}
+			if err := s.createBulk(ctx, inTx, userId, ops[i:end], results[i:end], atomic); err != nil {
+				return err
+			}
+			i = end
+			continue
+		}
+
+		op := ops[i]
+		var todo *models.TodoModel
+		err := inTx(func(repo repos.TodoRepository) error {
+			if op.Op == models.BulkDelete {
+				changes, err := trashTodo(ctx, repo, userId, op.Id, op.IfMatch)
+				if err != nil {
+					return err
+				}
+				return saveChanges(ctx, repo, userId, changes)
+			}
+			current, err := repo.LockTodo(ctx, userId, op.Id)
+			if err != nil {
+				return err
+			}
+			updatedTodo, spawned, err := repo.UpdateTodo(ctx, userId, op.Id, op.todo, op.next, op.IfMatch)
+			if err != nil {
+				return err
+			}
+			todo = &updatedTodo
+			changes, err := updateChanges(ctx, repo, userId, &current, todo, spawned, models.TodoUpdateOptions{})
+			if err != nil {
+				return err
+			}
+			return saveChanges(ctx, repo, userId, changes)
+		})
+		if err != nil {
+			if atomic {
+				return &BulkOperationError{Index: i, Err: err}
+			}
+			results[i].Err = s.versionError(ctx, userId, op.Id, err)
+			var preconditionErr *PreconditionFailedError
+			if errors.As(results[i].Err, &preconditionErr) {
+				results[i].Todo = &preconditionErr.Current
+			}
+		} else {
+			results[i].Todo = todo
+		}
+		i++
+	}
+	return nil
+}
+
+// createBulk creates a run of todos in one batch. A failed batch creates none of its todos, in best-effort mode
+// it is sent again without the failed todo until the rest goes through
+func (s *TodoServiceImpl) createBulk(ctx context.Context, inTx func(fn func(repo repos.TodoRepository) error) error, userId int, ops []bulkOperation, results []models.TodoBulkResult, atomic bool) error {
+	pending := make([]int, len(ops))
+	for i := range pending {
+		pending[i] = i
+	}
+	for len(pending) > 0 {
+		todos := make([]models.TodoModel, len(pending))
+		for i, index := range pending {
+			todos[i] = ops[index].todo
+		}
+		var created []models.TodoModel
+		err := inTx(func(repo repos.TodoRepository) error {
+			var err error
+			if created, err = repo.CreateTodos(ctx, userId, todos); err != nil {
+				return err
+			}
+			changes := make([]todoChange, len(created))
+			for i := range created {
+				changes[i] = todoChange{op: models.AuditCreate, after: &created[i]}
+			}
+			return saveChanges(ctx, repo, userId, changes)
+		})
+		if err == nil {
+			for i, index := range pending {
+				results[index].Id = created[i].Id
+				results[index].Todo = &created[i]
+			}
+			return nil
+		}
+		var batchErr *repos.BatchError
+		if !errors.As(err, &batchErr) {
+			if atomic {
+				return &BulkOperationError{Index: results[pending[0]].Index, Err: err}
+			}
+			for _, index := range pending {
+				results[index].Err = err
+			}
+			return nil
+		}
+		failed := pending[batchErr.Index]
+		if atomic {
+			return &BulkOperationError{Index: results[failed].Index, Err: batchErr.Err}
+		}
+		results[failed].Err = batchErr.Err
+		pending = append(pending[:batchErr.Index], pending[batchErr.Index+1:]...)
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+package services
+
+import (
+	"context"
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/cherrycutter/todo_app/internal/repos"
+	"sync"
+	"time"
+)
+
+const (
+	// subscriberBufferSize is how many events a subscriber can fall behind before it is dropped
+	subscriberBufferSize = 64
+	// maxReplayedEvents bounds the events replayed to a resuming subscriber, a longer gap is answered with a reset
+	maxReplayedEvents = 1000
+)
+
+// EventServiceImpl streams the changes of todos to the subscribers connected to this instance. Every instance
+// relays the events notified by postgres, so subscribers see the changes made through any instance
+type EventServiceImpl struct {
+	repo     repos.EventRepository
+	listener repos.EventListener
+	// retention is how long events stay in the log to resume a stream
+	retention time.Duration
+
+	mu          sync.Mutex
+	subscribers map[int]map[*subscriber]struct{}
+}
+
+// subscriber receives the live events of a user, events is closed when the subscriber falls behind
+type subscriber struct {
+	events chan models.TodoEvent
+}
+
+func NewEventService(repo repos.EventRepository, listener repos.EventListener, retention time.Duration) EventService {
+	return &EventServiceImpl{
+		repo:        repo,
+		listener:    listener,
+		retention:   retention,
+		subscribers: make(map[int]map[*subscriber]struct{}),
+	}
+}
+
+// Subscribe streams the changes of the todos of the user until ctx is done. With a lastEventId the events that
+// followed it are replayed first, or a reset event is sent when they are no longer in the log. The channel is
+// closed when the stream ends, also when the subscriber could not keep up and has to resume
+func (s *EventServiceImpl) Subscribe(ctx context.Context, lastEventId *int64) (<-chan models.TodoEvent, error) {
+	userId, err := currentUserId(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// subscribing before reading the log makes sure no event falls between the replay and the live events
+	sub := s.subscribe(userId)
+	var replay []models.TodoEvent
+	if lastEventId != nil {
+		if replay, err = s.replay(ctx, userId, *lastEventId); err != nil {
+			s.unsubscribe(userId, sub)
+			return nil, err
+		}
+	}
+
+	out := make(chan models.TodoEvent)
+	go func() {
+		defer close(out)
+		defer s.unsubscribe(userId, sub)
+
+		replayed := make(map[int64]bool, len(replay))
+		for _, event := range replay {
+			replayed[event.Id] = true
+			select {
+			case out <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+		for {
+			select {
+			case event, ok := <-sub.events:
+				if !ok {
+					return
+				}
+				if replayed[event.Id] {
+					continue
+				}
+				select {
+				case out <- event:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// replay returns the events of the user that followed lastEventId, or a reset event when some may be lost
+func (s *EventServiceImpl) replay(ctx context.Context, userId int, lastEventId int64) ([]models.TodoEvent, error) {
+	events, found, err := s.repo.GetEventsAfter(ctx, userId, lastEventId, maxReplayedEvents+1)
+	if err != nil {
+		return nil, err
+	}
+	if !found || len(events) > maxReplayedEvents {
+		return []models.TodoEvent{{Type: models.TodoEventsReset, CreatedAt: time.Now()}}, nil
+	}
+	return events, nil
+}
+
+func (s *EventServiceImpl) subscribe(userId int) *subscriber {
+	sub := &subscriber{events: make(chan models.TodoEvent, subscriberBufferSize)}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.subscribers[userId] == nil {
+		s.subscribers[userId] = make(map[*subscriber]struct{})
+	}
+	s.subscribers[userId][sub] = struct{}{}
+	return sub
+}
+
+func (s *EventServiceImpl) unsubscribe(userId int, sub *subscriber) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.subscribers[userId], sub)
+	if len(s.subscribers[userId]) == 0 {
+		delete(s.subscribers, userId)
+	}
+}
+
+// Relay passes the events notified by postgres to the subscribers until ctx is done. When the listener fails
+// the notifications in between are lost, so every subscriber is dropped to resume from the log
+func (s *EventServiceImpl) Relay(ctx context.Context) error {
+	err := s.listener.Listen(ctx, func(ownerId int, eventId int64) {
+		s.publish(ctx, ownerId, eventId)
+	})
+	if err != nil {
+		s.dropSubscribers()
+	}
+	return err
+}
+
+// publish sends an event to the subscribers of its owner, a subscriber that is too far behind is dropped
+func (s *EventServiceImpl) publish(ctx context.Context, ownerId int, eventId int64) {
+	s.mu.Lock()
+	subscribed := len(s.subscribers[ownerId]) > 0
+	s.mu.Unlock()
+	if !subscribed {
+		return
+	}
+	event, err := s.repo.GetEvent(ctx, ownerId, eventId)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for sub := range s.subscribers[ownerId] {
+		if err != nil {
+			// the subscribers would miss the event, they resume from the log instead
+			s.drop(ownerId, sub)
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			s.drop(ownerId, sub)
+		}
+	}
+}
+
+// Disconnect ends the streams of every subscriber of this instance, which is shutting down. They resume through
+// another instance
+func (s *EventServiceImpl) Disconnect() {
+	s.dropSubscribers()
+}
+
+func (s *EventServiceImpl) dropSubscribers() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for userId, subs := range s.subscribers {
+		for sub := range subs {
+			s.drop(userId, sub)
+		}
+	}
+}
+
+// drop ends the stream of a subscriber, s.mu must be held
+func (s *EventServiceImpl) drop(userId int, sub *subscriber) {
+	close(sub.events)
+	delete(s.subscribers[userId], sub)
+	if len(s.subscribers[userId]) == 0 {
+		delete(s.subscribers, userId)
+	}
+}
+
+// PurgeExpiredEvents deletes the events older than the retention, streams cannot be resumed before them
+func (s *EventServiceImpl) PurgeExpiredEvents(ctx context.Context) (int64, error) {
+	return s.repo.PurgeEvents(ctx, time.Now().Add(-s.retention))
+}
+
+// newTodoEvent describes an audited change for the event feed, a restored todo shows up as created and
+// a deleted one carries its last state
+func newTodoEvent(op models.AuditOperation, todoId int, before, after *models.TodoModel) models.TodoEvent {
+	event := models.TodoEvent{TodoId: todoId, Todo: after}
+	switch op {
+	case models.AuditCreate, models.AuditRestore:
+		event.Type = models.TodoCreated
+	case models.AuditDelete, models.AuditPurge:
+		event.Type = models.TodoDeleted
+		event.Todo = before
+	default:
+		event.Type = models.TodoUpdated
+	}
+	return event
+}
@@ -0,0 +1,194 @@
+package services
+
+import (
+	"context"
+	"github.com/cherrycutter/todo_app/internal/auth"
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/cherrycutter/todo_app/internal/repos"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// fakeEventRepo holds the event log of the test user, events before the first one were purged
+type fakeEventRepo struct {
+	repos.EventRepository
+	events []models.TodoEvent
+}
+
+func (r *fakeEventRepo) GetEvent(ctx context.Context, ownerId int, id int64) (models.TodoEvent, error) {
+	for _, event := range r.events {
+		if event.Id == id {
+			return event, nil
+		}
+	}
+	return models.TodoEvent{}, repos.ErrEventNotFound
+}
+
+func (r *fakeEventRepo) GetEventsAfter(ctx context.Context, ownerId int, after int64, limit int) ([]models.TodoEvent, bool, error) {
+	events := []models.TodoEvent{}
+	found := false
+	for _, event := range r.events {
+		if event.Id == after {
+			found = true
+		}
+		if event.Id > after && len(events) < limit {
+			events = append(events, event)
+		}
+	}
+	return events, found, nil
+}
+
+func newTestEvent(id int64) models.TodoEvent {
+	return models.TodoEvent{Id: id, Type: models.TodoUpdated, TodoId: int(id)}
+}
+
+// receive reads n events of a stream, fewer when the stream is closed or stalls
+func receive(events <-chan models.TodoEvent, n int) []models.TodoEvent {
+	var received []models.TodoEvent
+	for len(received) < n {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return received
+			}
+			received = append(received, event)
+		case <-time.After(time.Second):
+			return received
+		}
+	}
+	return received
+}
+
+func eventIds(events []models.TodoEvent) []int64 {
+	ids := make([]int64, len(events))
+	for i, event := range events {
+		ids[i] = event.Id
+	}
+	return ids
+}
+
+func TestSubscribeReplay(t *testing.T) {
+	repo := &fakeEventRepo{events: []models.TodoEvent{newTestEvent(1), newTestEvent(2), newTestEvent(3)}}
+	s := NewEventService(repo, nil, time.Hour).(*EventServiceImpl)
+	ctx, cancel := context.WithCancel(auth.WithUserId(context.Background(), testUserId))
+	defer cancel()
+
+	lastEventId := int64(1)
+	events, err := s.Subscribe(ctx, &lastEventId)
+	if !assert.NoError(t, err) {
+		return
+	}
+	// event 3 was committed while the log was read, it is notified after being replayed
+	repo.events = append(repo.events, newTestEvent(4))
+	s.publish(ctx, testUserId, 3)
+	s.publish(ctx, testUserId, 4)
+
+	assert.Equal(t, []int64{2, 3, 4}, eventIds(receive(events, 3)))
+	cancel()
+	assert.Empty(t, receive(events, 1))
+}
+
+func TestSubscribeReset(t *testing.T) {
+	repo := &fakeEventRepo{events: []models.TodoEvent{newTestEvent(5), newTestEvent(6)}}
+	s := NewEventService(repo, nil, time.Hour).(*EventServiceImpl)
+	ctx, cancel := context.WithCancel(auth.WithUserId(context.Background(), testUserId))
+	defer cancel()
+
+	// event 2 was purged, the events between it and event 5 are lost
+	lastEventId := int64(2)
+	events, err := s.Subscribe(ctx, &lastEventId)
+	if !assert.NoError(t, err) {
+		return
+	}
+	received := receive(events, 1)
+	if assert.Len(t, received, 1) {
+		assert.Equal(t, models.TodoEventsReset, received[0].Type)
+	}
+	s.publish(ctx, testUserId, 6)
+	assert.Equal(t, []int64{6}, eventIds(receive(events, 1)))
+}
+
+func TestSubscribeDropsSlowSubscriber(t *testing.T) {
+	repo := &fakeEventRepo{}
+	for id := int64(1); id <= subscriberBufferSize+2; id++ {
+		repo.events = append(repo.events, newTestEvent(id))
+	}
+	s := NewEventService(repo, nil, time.Hour).(*EventServiceImpl)
+	ctx, cancel := context.WithCancel(auth.WithUserId(context.Background(), testUserId))
+	defer cancel()
+
+	slow, err := s.Subscribe(ctx, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	// the subscriber reads nothing while its buffer fills up
+	for _, event := range repo.events {
+		s.publish(ctx, testUserId, event.Id)
+	}
+	received := receive(slow, len(repo.events))
+	assert.Less(t, len(received), len(repo.events))
+	_, open := <-slow
+	assert.False(t, open)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	assert.Empty(t, s.subscribers)
+}
+
+func TestCascadeCompletionEvents(t *testing.T) {
+	parentId := 1
+	repo := newFakeTodoRepo(
+		models.TodoModel{Id: 1, Title: "Release", Version: 1},
+		models.TodoModel{Id: 2, Title: "Changelog", ParentId: &parentId, Version: 1},
+		models.TodoModel{Id: 3, Title: "Tag", ParentId: &parentId, Completed: true, Version: 1},
+	)
+	s := NewTodoService(repo)
+	ctx := auth.WithUserId(context.Background(), testUserId)
+
+	todo, err := s.PatchTodo(ctx, 1, models.TodoPatch{Completed: models.PatchField[bool]{Set: true, Value: true}}, models.TodoUpdateOptions{CascadeCompletion: true})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, &models.TodoProgress{Done: 2, Total: 2}, todo.Progress)
+	// completing the subtask moved the version of the todo again, the response carries the latest one
+	assert.Equal(t, repo.todos[1].Version, todo.Version)
+	assert.Equal(t, 3, todo.Version)
+
+	// the subtask that was already completed is left out
+	if assert.Len(t, repo.events, 2) {
+		assert.Equal(t, models.TodoUpdated, repo.events[0].Type)
+		assert.Equal(t, 1, repo.events[0].TodoId)
+		assert.Equal(t, models.TodoUpdated, repo.events[1].Type)
+		assert.Equal(t, 2, repo.events[1].TodoId)
+		assert.True(t, repo.events[1].Todo.Completed)
+		assert.Equal(t, 2, repo.events[1].Todo.Version)
+	}
+	assert.Len(t, repo.entries, 2)
+	assert.Len(t, repo.deliveries, 2)
+}
+
+func TestProjectAndTagEvents(t *testing.T) {
+	projectId := 3
+	backend := models.TagModel{Id: 5, Name: "backend"}
+	repo := newFakeTodoRepo(
+		models.TodoModel{Id: 1, Title: "Move out", ProjectId: &projectId, Version: 1},
+		models.TodoModel{Id: 2, Title: "Add the audit log", Tags: []models.TagModel{backend}, Version: 1},
+	)
+	ctx := auth.WithUserId(context.Background(), testUserId)
+
+	assert.NoError(t, NewProjectService(repo.Projects(), repo).DeleteProject(ctx, projectId, models.ProjectDeleteCascade))
+	if assert.Len(t, repo.events, 1) {
+		assert.Equal(t, models.TodoDeleted, repo.events[0].Type)
+		assert.Equal(t, 1, repo.events[0].TodoId)
+		assert.NotNil(t, repo.events[0].Todo)
+	}
+
+	_, err := NewTagService(repo.Tags(), repo).UpdateTag(ctx, backend.Id, models.TagModel{Name: "server"})
+	assert.NoError(t, err)
+	if assert.Len(t, repo.events, 2) {
+		assert.Equal(t, models.TodoUpdated, repo.events[1].Type)
+		assert.Equal(t, "server", repo.events[1].Todo.Tags[0].Name)
+		assert.Equal(t, repo.todos[2].Version, repo.events[1].Todo.Version)
+	}
+}
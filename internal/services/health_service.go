@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/cherrycutter/todo_app/internal/repos"
+	"sync"
+	"time"
+)
+
+// HealthCheck probes a part of the app, it returns an error when that part is not usable
+type HealthCheck func(ctx context.Context) error
+
+// HealthServiceImpl runs the checks registered by the parts of the app
+type HealthServiceImpl struct {
+	// timeout bounds every check, a check still running after it is down
+	timeout time.Duration
+
+	mu     sync.Mutex
+	checks []registeredCheck
+}
+
+type registeredCheck struct {
+	name     string
+	critical bool
+	check    HealthCheck
+}
+
+func NewHealthService(timeout time.Duration) HealthService {
+	return &HealthServiceImpl{timeout: timeout}
+}
+
+// Register adds a check to the report, the app is only ready when all its critical checks are up
+func (s *HealthServiceImpl) Register(name string, critical bool, check HealthCheck) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.checks = append(s.checks, registeredCheck{name: name, critical: critical, check: check})
+}
+
+// Check runs every check at once and lists their outcome in the order they were registered
+func (s *HealthServiceImpl) Check(ctx context.Context) models.HealthReport {
+	s.mu.Lock()
+	checks := append([]registeredCheck(nil), s.checks...)
+	s.mu.Unlock()
+
+	report := models.HealthReport{Status: models.HealthUp, Checks: make([]models.HealthCheckResult, len(checks))}
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check registeredCheck) {
+			defer wg.Done()
+			report.Checks[i] = s.run(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		if result.Critical && result.Status == models.HealthDown {
+			report.Status = models.HealthDown
+		}
+	}
+	return report
+}
+
+// run runs a check within the timeout, a check that ignores its context is left behind once it is exceeded
+func (s *HealthServiceImpl) run(ctx context.Context, check registeredCheck) models.HealthCheckResult {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- check.check(ctx)
+	}()
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	result := models.HealthCheckResult{Name: check.name, Status: models.HealthUp, Critical: check.critical, LatencyMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		result.Status, result.Error = models.HealthDown, err.Error()
+	}
+	return result
+}
+
+// DatabaseCheck makes sure the database answers
+func DatabaseCheck(repo repos.HealthRepository) HealthCheck {
+	return repo.Ping
+}
+
+// SchemaCheck makes sure the migrations of the database are complete and at least at the expected version
+func SchemaCheck(repo repos.HealthRepository, expected int64) HealthCheck {
+	return func(ctx context.Context) error {
+		version, dirty, err := repo.GetSchemaVersion(ctx)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return fmt.Errorf("migration %d failed and has to be fixed", version)
+		}
+		if version < expected {
+			return fmt.Errorf("schema is at version %d, expected %d", version, expected)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/cherrycutter/todo_app/internal/repos"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestHealthCheck(t *testing.T) {
+	up := func(ctx context.Context) error { return nil }
+	down := func(ctx context.Context) error { return errors.New("unreachable") }
+	hung := func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	}
+
+	tests := []struct {
+		name       string
+		register   func(s HealthService)
+		wantStatus models.HealthStatus
+		wantChecks []models.HealthStatus
+	}{
+		{
+			name: "Up",
+			register: func(s HealthService) {
+				s.Register("database", true, up)
+				s.Register("worker:purger", false, up)
+			},
+			wantStatus: models.HealthUp,
+			wantChecks: []models.HealthStatus{models.HealthUp, models.HealthUp},
+		},
+		{
+			name: "Critical Down",
+			register: func(s HealthService) {
+				s.Register("database", true, down)
+				s.Register("worker:purger", false, up)
+			},
+			wantStatus: models.HealthDown,
+			wantChecks: []models.HealthStatus{models.HealthDown, models.HealthUp},
+		},
+		{
+			name: "Non Critical Down",
+			register: func(s HealthService) {
+				s.Register("database", true, up)
+				s.Register("worker:purger", false, down)
+			},
+			wantStatus: models.HealthUp,
+			wantChecks: []models.HealthStatus{models.HealthUp, models.HealthDown},
+		},
+		{
+			name: "Timeout",
+			register: func(s HealthService) {
+				s.Register("database", true, hung)
+			},
+			wantStatus: models.HealthDown,
+			wantChecks: []models.HealthStatus{models.HealthDown},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewHealthService(50 * time.Millisecond)
+			tt.register(s)
+
+			report := s.Check(context.Background())
+			assert.Equal(t, tt.wantStatus, report.Status)
+			statuses := make([]models.HealthStatus, len(report.Checks))
+			for i, check := range report.Checks {
+				statuses[i] = check.Status
+				assert.Less(t, check.LatencyMs, int64(time.Second/time.Millisecond))
+			}
+			assert.Equal(t, tt.wantChecks, statuses)
+		})
+	}
+}
+
+// fakeHealthRepo reports a fixed schema version
+type fakeHealthRepo struct {
+	repos.HealthRepository
+	version int64
+	dirty   bool
+}
+
+func (r *fakeHealthRepo) GetSchemaVersion(ctx context.Context) (int64, bool, error) {
+	return r.version, r.dirty, nil
+}
+
+func TestSchemaCheck(t *testing.T) {
+	assert.NoError(t, SchemaCheck(&fakeHealthRepo{version: 17}, 17)(context.Background()))
+	assert.NoError(t, SchemaCheck(&fakeHealthRepo{version: 18}, 17)(context.Background()))
+	assert.Error(t, SchemaCheck(&fakeHealthRepo{version: 16}, 17)(context.Background()))
+	assert.Error(t, SchemaCheck(&fakeHealthRepo{version: 17, dirty: true}, 17)(context.Background()))
+}
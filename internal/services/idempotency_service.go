@@ -0,0 +1,77 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/cherrycutter/todo_app/internal/repos"
+	"time"
+)
+
+type IdempotencyServiceImpl struct {
+	repo repos.IdempotencyRepository
+	// ttl is how long a key is kept and its response replayed
+	ttl time.Duration
+}
+
+func NewIdempotencyService(repo repos.IdempotencyRepository, ttl time.Duration) IdempotencyService {
+	return &IdempotencyServiceImpl{repo: repo, ttl: ttl}
+}
+
+var (
+	ErrIdempotencyKeyReused     = errors.New("idempotency key was already used for a different request")
+	ErrIdempotencyKeyInProgress = errors.New("a request with this idempotency key is still in progress")
+)
+
+// maxIdempotencyKeyLength bounds the size of the keys chosen by clients
+const maxIdempotencyKeyLength = 255
+
+// BeginRequest reserves a key of the user for the request with the fingerprint. When the key was already used
+// by the same request the stored response is returned to be replayed, nil means the request has to run
+func (s *IdempotencyServiceImpl) BeginRequest(ctx context.Context, key, fingerprint string) (*models.IdempotentResponse, error) {
+	if len(key) > maxIdempotencyKeyLength {
+		return nil, &ValidationError{Message: "header Idempotency-Key cannot be longer than 255 characters"}
+	}
+	userId, err := currentUserId(ctx)
+	if err != nil {
+		return nil, err
+	}
+	record, reserved, err := s.repo.ReserveIdempotencyKey(ctx, userId, key, fingerprint, time.Now().Add(s.ttl))
+	if err != nil {
+		return nil, err
+	}
+	if reserved {
+		return nil, nil
+	}
+	// a record without fingerprint is still being reserved by a concurrent request
+	if record.Fingerprint != "" && record.Fingerprint != fingerprint {
+		return nil, ErrIdempotencyKeyReused
+	}
+	if record.Response == nil {
+		return nil, ErrIdempotencyKeyInProgress
+	}
+	return record.Response, nil
+}
+
+// CompleteRequest stores the response of the request reserved under a key
+func (s *IdempotencyServiceImpl) CompleteRequest(ctx context.Context, key string, response models.IdempotentResponse) error {
+	userId, err := currentUserId(ctx)
+	if err != nil {
+		return err
+	}
+	return s.repo.SaveIdempotentResponse(ctx, userId, key, response)
+}
+
+// AbandonRequest releases a key whose request failed, so that a retry runs it again
+func (s *IdempotencyServiceImpl) AbandonRequest(ctx context.Context, key string) error {
+	userId, err := currentUserId(ctx)
+	if err != nil {
+		return err
+	}
+	return s.repo.DeleteIdempotencyKey(ctx, userId, key)
+}
+
+// PurgeExpiredKeys deletes the keys of every user whose TTL elapsed
+func (s *IdempotencyServiceImpl) PurgeExpiredKeys(ctx context.Context) (int64, error) {
+	return s.repo.PurgeIdempotencyKeys(ctx, time.Now())
+}
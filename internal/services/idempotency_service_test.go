@@ -0,0 +1,52 @@
+package services
+
+import (
+	"context"
+	"github.com/cherrycutter/todo_app/internal/auth"
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/cherrycutter/todo_app/internal/repos"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// fakeIdempotencyRepo answers every reservation with the same record
+type fakeIdempotencyRepo struct {
+	repos.IdempotencyRepository
+	record   models.IdempotencyRecord
+	reserved bool
+}
+
+func (r *fakeIdempotencyRepo) ReserveIdempotencyKey(ctx context.Context, userId int, key, fingerprint string, expiresAt time.Time) (models.IdempotencyRecord, bool, error) {
+	return r.record, r.reserved, nil
+}
+
+func TestBeginRequest(t *testing.T) {
+	response := &models.IdempotentResponse{StatusCode: 201, Body: []byte("{}")}
+
+	tests := []struct {
+		name    string
+		repo    *fakeIdempotencyRepo
+		want    *models.IdempotentResponse
+		wantErr error
+	}{
+		{name: "Reserved", repo: &fakeIdempotencyRepo{record: models.IdempotencyRecord{Fingerprint: "fp"}, reserved: true}},
+		{name: "Replayed", repo: &fakeIdempotencyRepo{record: models.IdempotencyRecord{Fingerprint: "fp", Response: response}}, want: response},
+		{name: "Reused", repo: &fakeIdempotencyRepo{record: models.IdempotencyRecord{Fingerprint: "other"}}, wantErr: ErrIdempotencyKeyReused},
+		{name: "In Progress", repo: &fakeIdempotencyRepo{record: models.IdempotencyRecord{Fingerprint: "fp"}}, wantErr: ErrIdempotencyKeyInProgress},
+		{name: "Reserved Concurrently", repo: &fakeIdempotencyRepo{}, wantErr: ErrIdempotencyKeyInProgress},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewIdempotencyService(tt.repo, time.Hour)
+
+			got, err := s.BeginRequest(auth.WithUserId(context.Background(), testUserId), "key", "fp")
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -7,8 +7,10 @@ package mock_services
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	models "github.com/cherrycutter/todo_app/internal/models"
+	services "github.com/cherrycutter/todo_app/internal/services"
 	gomock "github.com/golang/mock/gomock"
 )
 
@@ -35,6 +37,36 @@ func (m *MockTodoService) EXPECT() *MockTodoServiceMockRecorder {
 	return m.recorder
 }
 
+// BulkTodos mocks base method.
+func (m *MockTodoService) BulkTodos(ctx context.Context, req models.TodoBulkRequest) (models.TodoBulkResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BulkTodos", ctx, req)
+	ret0, _ := ret[0].(models.TodoBulkResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BulkTodos indicates an expected call of BulkTodos.
+func (mr *MockTodoServiceMockRecorder) BulkTodos(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BulkTodos", reflect.TypeOf((*MockTodoService)(nil).BulkTodos), ctx, req)
+}
+
+// CreateSubtask mocks base method.
+func (m *MockTodoService) CreateSubtask(ctx context.Context, id int, todo models.TodoModel) (models.TodoModel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSubtask", ctx, id, todo)
+	ret0, _ := ret[0].(models.TodoModel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateSubtask indicates an expected call of CreateSubtask.
+func (mr *MockTodoServiceMockRecorder) CreateSubtask(ctx, id, todo interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSubtask", reflect.TypeOf((*MockTodoService)(nil).CreateSubtask), ctx, id, todo)
+}
+
 // CreateTodo mocks base method.
 func (m *MockTodoService) CreateTodo(ctx context.Context, todo models.TodoModel) (models.TodoModel, error) {
 	m.ctrl.T.Helper()
@@ -51,17 +83,76 @@ func (mr *MockTodoServiceMockRecorder) CreateTodo(ctx, todo interface{}) *gomock
 }
 
 // DeleteTodo mocks base method.
-func (m *MockTodoService) DeleteTodo(ctx context.Context, id int) error {
+func (m *MockTodoService) DeleteTodo(ctx context.Context, id int, ifMatch *int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteTodo", ctx, id)
+	ret := m.ctrl.Call(m, "DeleteTodo", ctx, id, ifMatch)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeleteTodo indicates an expected call of DeleteTodo.
-func (mr *MockTodoServiceMockRecorder) DeleteTodo(ctx, id interface{}) *gomock.Call {
+func (mr *MockTodoServiceMockRecorder) DeleteTodo(ctx, id, ifMatch interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTodo", reflect.TypeOf((*MockTodoService)(nil).DeleteTodo), ctx, id, ifMatch)
+}
+
+// DeleteTrashedTodo mocks base method.
+func (m *MockTodoService) DeleteTrashedTodo(ctx context.Context, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTrashedTodo", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteTrashedTodo indicates an expected call of DeleteTrashedTodo.
+func (mr *MockTodoServiceMockRecorder) DeleteTrashedTodo(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTrashedTodo", reflect.TypeOf((*MockTodoService)(nil).DeleteTrashedTodo), ctx, id)
+}
+
+// GetAuditLog mocks base method.
+func (m *MockTodoService) GetAuditLog(ctx context.Context, filter models.AuditFilter, params models.ListParams) (models.AuditPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAuditLog", ctx, filter, params)
+	ret0, _ := ret[0].(models.AuditPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAuditLog indicates an expected call of GetAuditLog.
+func (mr *MockTodoServiceMockRecorder) GetAuditLog(ctx, filter, params interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAuditLog", reflect.TypeOf((*MockTodoService)(nil).GetAuditLog), ctx, filter, params)
+}
+
+// GetOccurrences mocks base method.
+func (m *MockTodoService) GetOccurrences(ctx context.Context, id, limit int) (models.TodoOccurrences, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOccurrences", ctx, id, limit)
+	ret0, _ := ret[0].(models.TodoOccurrences)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOccurrences indicates an expected call of GetOccurrences.
+func (mr *MockTodoServiceMockRecorder) GetOccurrences(ctx, id, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOccurrences", reflect.TypeOf((*MockTodoService)(nil).GetOccurrences), ctx, id, limit)
+}
+
+// GetSubtasks mocks base method.
+func (m *MockTodoService) GetSubtasks(ctx context.Context, id int) ([]models.TodoModel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSubtasks", ctx, id)
+	ret0, _ := ret[0].([]models.TodoModel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSubtasks indicates an expected call of GetSubtasks.
+func (mr *MockTodoServiceMockRecorder) GetSubtasks(ctx, id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTodo", reflect.TypeOf((*MockTodoService)(nil).DeleteTodo), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSubtasks", reflect.TypeOf((*MockTodoService)(nil).GetSubtasks), ctx, id)
 }
 
 // GetTodo mocks base method.
@@ -79,32 +170,890 @@ func (mr *MockTodoServiceMockRecorder) GetTodo(ctx, id interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTodo", reflect.TypeOf((*MockTodoService)(nil).GetTodo), ctx, id)
 }
 
+// GetTodoHistory mocks base method.
+func (m *MockTodoService) GetTodoHistory(ctx context.Context, id int, params models.ListParams) (models.AuditPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTodoHistory", ctx, id, params)
+	ret0, _ := ret[0].(models.AuditPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTodoHistory indicates an expected call of GetTodoHistory.
+func (mr *MockTodoServiceMockRecorder) GetTodoHistory(ctx, id, params interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTodoHistory", reflect.TypeOf((*MockTodoService)(nil).GetTodoHistory), ctx, id, params)
+}
+
 // GetTodos mocks base method.
-func (m *MockTodoService) GetTodos(ctx context.Context) ([]models.TodoModel, error) {
+func (m *MockTodoService) GetTodos(ctx context.Context, filter models.TodoFilter, params models.ListParams) (models.TodoPage, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetTodos", ctx)
-	ret0, _ := ret[0].([]models.TodoModel)
+	ret := m.ctrl.Call(m, "GetTodos", ctx, filter, params)
+	ret0, _ := ret[0].(models.TodoPage)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetTodos indicates an expected call of GetTodos.
-func (mr *MockTodoServiceMockRecorder) GetTodos(ctx interface{}) *gomock.Call {
+func (mr *MockTodoServiceMockRecorder) GetTodos(ctx, filter, params interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTodos", reflect.TypeOf((*MockTodoService)(nil).GetTodos), ctx, filter, params)
+}
+
+// PatchTodo mocks base method.
+func (m *MockTodoService) PatchTodo(ctx context.Context, id int, patch models.TodoPatch, opts models.TodoUpdateOptions) (models.TodoModel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PatchTodo", ctx, id, patch, opts)
+	ret0, _ := ret[0].(models.TodoModel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PatchTodo indicates an expected call of PatchTodo.
+func (mr *MockTodoServiceMockRecorder) PatchTodo(ctx, id, patch, opts interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchTodo", reflect.TypeOf((*MockTodoService)(nil).PatchTodo), ctx, id, patch, opts)
+}
+
+// PurgeTrash mocks base method.
+func (m *MockTodoService) PurgeTrash(ctx context.Context, retention time.Duration) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeTrash", ctx, retention)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeTrash indicates an expected call of PurgeTrash.
+func (mr *MockTodoServiceMockRecorder) PurgeTrash(ctx, retention interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeTrash", reflect.TypeOf((*MockTodoService)(nil).PurgeTrash), ctx, retention)
+}
+
+// ReorderSubtasks mocks base method.
+func (m *MockTodoService) ReorderSubtasks(ctx context.Context, id int, ids []int) ([]models.TodoModel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReorderSubtasks", ctx, id, ids)
+	ret0, _ := ret[0].([]models.TodoModel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReorderSubtasks indicates an expected call of ReorderSubtasks.
+func (mr *MockTodoServiceMockRecorder) ReorderSubtasks(ctx, id, ids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReorderSubtasks", reflect.TypeOf((*MockTodoService)(nil).ReorderSubtasks), ctx, id, ids)
+}
+
+// RestoreTodo mocks base method.
+func (m *MockTodoService) RestoreTodo(ctx context.Context, id int) (models.TodoModel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreTodo", ctx, id)
+	ret0, _ := ret[0].(models.TodoModel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestoreTodo indicates an expected call of RestoreTodo.
+func (mr *MockTodoServiceMockRecorder) RestoreTodo(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreTodo", reflect.TypeOf((*MockTodoService)(nil).RestoreTodo), ctx, id)
+}
+
+// SearchTodos mocks base method.
+func (m *MockTodoService) SearchTodos(ctx context.Context, search models.TodoSearch, limit int) (models.TodoSearchResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchTodos", ctx, search, limit)
+	ret0, _ := ret[0].(models.TodoSearchResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchTodos indicates an expected call of SearchTodos.
+func (mr *MockTodoServiceMockRecorder) SearchTodos(ctx, search, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchTodos", reflect.TypeOf((*MockTodoService)(nil).SearchTodos), ctx, search, limit)
+}
+
+// ToggleSubtask mocks base method.
+func (m *MockTodoService) ToggleSubtask(ctx context.Context, id, subtaskId int) (models.TodoModel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ToggleSubtask", ctx, id, subtaskId)
+	ret0, _ := ret[0].(models.TodoModel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ToggleSubtask indicates an expected call of ToggleSubtask.
+func (mr *MockTodoServiceMockRecorder) ToggleSubtask(ctx, id, subtaskId interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTodos", reflect.TypeOf((*MockTodoService)(nil).GetTodos), ctx)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ToggleSubtask", reflect.TypeOf((*MockTodoService)(nil).ToggleSubtask), ctx, id, subtaskId)
 }
 
 // UpdateTodo mocks base method.
-func (m *MockTodoService) UpdateTodo(ctx context.Context, id int, todo models.TodoModel) (models.TodoModel, error) {
+func (m *MockTodoService) UpdateTodo(ctx context.Context, id int, todo models.TodoModel, opts models.TodoUpdateOptions) (models.TodoModel, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateTodo", ctx, id, todo)
+	ret := m.ctrl.Call(m, "UpdateTodo", ctx, id, todo, opts)
 	ret0, _ := ret[0].(models.TodoModel)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // UpdateTodo indicates an expected call of UpdateTodo.
-func (mr *MockTodoServiceMockRecorder) UpdateTodo(ctx, id, todo interface{}) *gomock.Call {
+func (mr *MockTodoServiceMockRecorder) UpdateTodo(ctx, id, todo, opts interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTodo", reflect.TypeOf((*MockTodoService)(nil).UpdateTodo), ctx, id, todo, opts)
+}
+
+// MockTagService is a mock of TagService interface.
+type MockTagService struct {
+	ctrl     *gomock.Controller
+	recorder *MockTagServiceMockRecorder
+}
+
+// MockTagServiceMockRecorder is the mock recorder for MockTagService.
+type MockTagServiceMockRecorder struct {
+	mock *MockTagService
+}
+
+// NewMockTagService creates a new mock instance.
+func NewMockTagService(ctrl *gomock.Controller) *MockTagService {
+	mock := &MockTagService{ctrl: ctrl}
+	mock.recorder = &MockTagServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTagService) EXPECT() *MockTagServiceMockRecorder {
+	return m.recorder
+}
+
+// AttachTag mocks base method.
+func (m *MockTagService) AttachTag(ctx context.Context, todoId, tagId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AttachTag", ctx, todoId, tagId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AttachTag indicates an expected call of AttachTag.
+func (mr *MockTagServiceMockRecorder) AttachTag(ctx, todoId, tagId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AttachTag", reflect.TypeOf((*MockTagService)(nil).AttachTag), ctx, todoId, tagId)
+}
+
+// CreateTag mocks base method.
+func (m *MockTagService) CreateTag(ctx context.Context, tag models.TagModel) (models.TagModel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTag", ctx, tag)
+	ret0, _ := ret[0].(models.TagModel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateTag indicates an expected call of CreateTag.
+func (mr *MockTagServiceMockRecorder) CreateTag(ctx, tag interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTag", reflect.TypeOf((*MockTagService)(nil).CreateTag), ctx, tag)
+}
+
+// DeleteTag mocks base method.
+func (m *MockTagService) DeleteTag(ctx context.Context, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTag", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteTag indicates an expected call of DeleteTag.
+func (mr *MockTagServiceMockRecorder) DeleteTag(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTag", reflect.TypeOf((*MockTagService)(nil).DeleteTag), ctx, id)
+}
+
+// DetachTag mocks base method.
+func (m *MockTagService) DetachTag(ctx context.Context, todoId, tagId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DetachTag", ctx, todoId, tagId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DetachTag indicates an expected call of DetachTag.
+func (mr *MockTagServiceMockRecorder) DetachTag(ctx, todoId, tagId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DetachTag", reflect.TypeOf((*MockTagService)(nil).DetachTag), ctx, todoId, tagId)
+}
+
+// GetTag mocks base method.
+func (m *MockTagService) GetTag(ctx context.Context, id int) (models.TagModel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTag", ctx, id)
+	ret0, _ := ret[0].(models.TagModel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTag indicates an expected call of GetTag.
+func (mr *MockTagServiceMockRecorder) GetTag(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTag", reflect.TypeOf((*MockTagService)(nil).GetTag), ctx, id)
+}
+
+// GetTags mocks base method.
+func (m *MockTagService) GetTags(ctx context.Context) ([]models.TagModel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTags", ctx)
+	ret0, _ := ret[0].([]models.TagModel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTags indicates an expected call of GetTags.
+func (mr *MockTagServiceMockRecorder) GetTags(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTags", reflect.TypeOf((*MockTagService)(nil).GetTags), ctx)
+}
+
+// UpdateTag mocks base method.
+func (m *MockTagService) UpdateTag(ctx context.Context, id int, tag models.TagModel) (models.TagModel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateTag", ctx, id, tag)
+	ret0, _ := ret[0].(models.TagModel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateTag indicates an expected call of UpdateTag.
+func (mr *MockTagServiceMockRecorder) UpdateTag(ctx, id, tag interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTag", reflect.TypeOf((*MockTagService)(nil).UpdateTag), ctx, id, tag)
+}
+
+// MockProjectService is a mock of ProjectService interface.
+type MockProjectService struct {
+	ctrl     *gomock.Controller
+	recorder *MockProjectServiceMockRecorder
+}
+
+// MockProjectServiceMockRecorder is the mock recorder for MockProjectService.
+type MockProjectServiceMockRecorder struct {
+	mock *MockProjectService
+}
+
+// NewMockProjectService creates a new mock instance.
+func NewMockProjectService(ctrl *gomock.Controller) *MockProjectService {
+	mock := &MockProjectService{ctrl: ctrl}
+	mock.recorder = &MockProjectServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockProjectService) EXPECT() *MockProjectServiceMockRecorder {
+	return m.recorder
+}
+
+// CreateProject mocks base method.
+func (m *MockProjectService) CreateProject(ctx context.Context, project models.ProjectModel) (models.ProjectModel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateProject", ctx, project)
+	ret0, _ := ret[0].(models.ProjectModel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateProject indicates an expected call of CreateProject.
+func (mr *MockProjectServiceMockRecorder) CreateProject(ctx, project interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateProject", reflect.TypeOf((*MockProjectService)(nil).CreateProject), ctx, project)
+}
+
+// DeleteProject mocks base method.
+func (m *MockProjectService) DeleteProject(ctx context.Context, id int, mode models.ProjectDeleteMode) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteProject", ctx, id, mode)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteProject indicates an expected call of DeleteProject.
+func (mr *MockProjectServiceMockRecorder) DeleteProject(ctx, id, mode interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteProject", reflect.TypeOf((*MockProjectService)(nil).DeleteProject), ctx, id, mode)
+}
+
+// GetProject mocks base method.
+func (m *MockProjectService) GetProject(ctx context.Context, id int) (models.ProjectModel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProject", ctx, id)
+	ret0, _ := ret[0].(models.ProjectModel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetProject indicates an expected call of GetProject.
+func (mr *MockProjectServiceMockRecorder) GetProject(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProject", reflect.TypeOf((*MockProjectService)(nil).GetProject), ctx, id)
+}
+
+// GetProjects mocks base method.
+func (m *MockProjectService) GetProjects(ctx context.Context, includeArchived bool) ([]models.ProjectModel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProjects", ctx, includeArchived)
+	ret0, _ := ret[0].([]models.ProjectModel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetProjects indicates an expected call of GetProjects.
+func (mr *MockProjectServiceMockRecorder) GetProjects(ctx, includeArchived interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProjects", reflect.TypeOf((*MockProjectService)(nil).GetProjects), ctx, includeArchived)
+}
+
+// UpdateProject mocks base method.
+func (m *MockProjectService) UpdateProject(ctx context.Context, id int, project models.ProjectModel) (models.ProjectModel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateProject", ctx, id, project)
+	ret0, _ := ret[0].(models.ProjectModel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateProject indicates an expected call of UpdateProject.
+func (mr *MockProjectServiceMockRecorder) UpdateProject(ctx, id, project interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateProject", reflect.TypeOf((*MockProjectService)(nil).UpdateProject), ctx, id, project)
+}
+
+// MockAuthService is a mock of AuthService interface.
+type MockAuthService struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuthServiceMockRecorder
+}
+
+// MockAuthServiceMockRecorder is the mock recorder for MockAuthService.
+type MockAuthServiceMockRecorder struct {
+	mock *MockAuthService
+}
+
+// NewMockAuthService creates a new mock instance.
+func NewMockAuthService(ctrl *gomock.Controller) *MockAuthService {
+	mock := &MockAuthService{ctrl: ctrl}
+	mock.recorder = &MockAuthServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuthService) EXPECT() *MockAuthServiceMockRecorder {
+	return m.recorder
+}
+
+// Authenticate mocks base method.
+func (m *MockAuthService) Authenticate(ctx context.Context, accessToken string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Authenticate", ctx, accessToken)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Authenticate indicates an expected call of Authenticate.
+func (mr *MockAuthServiceMockRecorder) Authenticate(ctx, accessToken interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Authenticate", reflect.TypeOf((*MockAuthService)(nil).Authenticate), ctx, accessToken)
+}
+
+// Login mocks base method.
+func (m *MockAuthService) Login(ctx context.Context, credentials models.Credentials) (models.TokenPair, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Login", ctx, credentials)
+	ret0, _ := ret[0].(models.TokenPair)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Login indicates an expected call of Login.
+func (mr *MockAuthServiceMockRecorder) Login(ctx, credentials interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Login", reflect.TypeOf((*MockAuthService)(nil).Login), ctx, credentials)
+}
+
+// Logout mocks base method.
+func (m *MockAuthService) Logout(ctx context.Context, refreshToken string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Logout", ctx, refreshToken)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Logout indicates an expected call of Logout.
+func (mr *MockAuthServiceMockRecorder) Logout(ctx, refreshToken interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Logout", reflect.TypeOf((*MockAuthService)(nil).Logout), ctx, refreshToken)
+}
+
+// Refresh mocks base method.
+func (m *MockAuthService) Refresh(ctx context.Context, refreshToken string) (models.TokenPair, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Refresh", ctx, refreshToken)
+	ret0, _ := ret[0].(models.TokenPair)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Refresh indicates an expected call of Refresh.
+func (mr *MockAuthServiceMockRecorder) Refresh(ctx, refreshToken interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Refresh", reflect.TypeOf((*MockAuthService)(nil).Refresh), ctx, refreshToken)
+}
+
+// Register mocks base method.
+func (m *MockAuthService) Register(ctx context.Context, credentials models.Credentials) (models.UserModel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Register", ctx, credentials)
+	ret0, _ := ret[0].(models.UserModel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Register indicates an expected call of Register.
+func (mr *MockAuthServiceMockRecorder) Register(ctx, credentials interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Register", reflect.TypeOf((*MockAuthService)(nil).Register), ctx, credentials)
+}
+
+// MockAPIKeyService is a mock of APIKeyService interface.
+type MockAPIKeyService struct {
+	ctrl     *gomock.Controller
+	recorder *MockAPIKeyServiceMockRecorder
+}
+
+// MockAPIKeyServiceMockRecorder is the mock recorder for MockAPIKeyService.
+type MockAPIKeyServiceMockRecorder struct {
+	mock *MockAPIKeyService
+}
+
+// NewMockAPIKeyService creates a new mock instance.
+func NewMockAPIKeyService(ctrl *gomock.Controller) *MockAPIKeyService {
+	mock := &MockAPIKeyService{ctrl: ctrl}
+	mock.recorder = &MockAPIKeyServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAPIKeyService) EXPECT() *MockAPIKeyServiceMockRecorder {
+	return m.recorder
+}
+
+// Authenticate mocks base method.
+func (m *MockAPIKeyService) Authenticate(ctx context.Context, key string) (int, []string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Authenticate", ctx, key)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].([]string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Authenticate indicates an expected call of Authenticate.
+func (mr *MockAPIKeyServiceMockRecorder) Authenticate(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Authenticate", reflect.TypeOf((*MockAPIKeyService)(nil).Authenticate), ctx, key)
+}
+
+// CreateAPIKey mocks base method.
+func (m *MockAPIKeyService) CreateAPIKey(ctx context.Context, key models.APIKeyModel) (models.CreatedAPIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAPIKey", ctx, key)
+	ret0, _ := ret[0].(models.CreatedAPIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAPIKey indicates an expected call of CreateAPIKey.
+func (mr *MockAPIKeyServiceMockRecorder) CreateAPIKey(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAPIKey", reflect.TypeOf((*MockAPIKeyService)(nil).CreateAPIKey), ctx, key)
+}
+
+// GetAPIKeys mocks base method.
+func (m *MockAPIKeyService) GetAPIKeys(ctx context.Context) ([]models.APIKeyModel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAPIKeys", ctx)
+	ret0, _ := ret[0].([]models.APIKeyModel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAPIKeys indicates an expected call of GetAPIKeys.
+func (mr *MockAPIKeyServiceMockRecorder) GetAPIKeys(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAPIKeys", reflect.TypeOf((*MockAPIKeyService)(nil).GetAPIKeys), ctx)
+}
+
+// RevokeAPIKey mocks base method.
+func (m *MockAPIKeyService) RevokeAPIKey(ctx context.Context, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAPIKey", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeAPIKey indicates an expected call of RevokeAPIKey.
+func (mr *MockAPIKeyServiceMockRecorder) RevokeAPIKey(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAPIKey", reflect.TypeOf((*MockAPIKeyService)(nil).RevokeAPIKey), ctx, id)
+}
+
+// RotateAPIKey mocks base method.
+func (m *MockAPIKeyService) RotateAPIKey(ctx context.Context, id int) (models.CreatedAPIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RotateAPIKey", ctx, id)
+	ret0, _ := ret[0].(models.CreatedAPIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RotateAPIKey indicates an expected call of RotateAPIKey.
+func (mr *MockAPIKeyServiceMockRecorder) RotateAPIKey(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateAPIKey", reflect.TypeOf((*MockAPIKeyService)(nil).RotateAPIKey), ctx, id)
+}
+
+// MockIdempotencyService is a mock of IdempotencyService interface.
+type MockIdempotencyService struct {
+	ctrl     *gomock.Controller
+	recorder *MockIdempotencyServiceMockRecorder
+}
+
+// MockIdempotencyServiceMockRecorder is the mock recorder for MockIdempotencyService.
+type MockIdempotencyServiceMockRecorder struct {
+	mock *MockIdempotencyService
+}
+
+// NewMockIdempotencyService creates a new mock instance.
+func NewMockIdempotencyService(ctrl *gomock.Controller) *MockIdempotencyService {
+	mock := &MockIdempotencyService{ctrl: ctrl}
+	mock.recorder = &MockIdempotencyServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIdempotencyService) EXPECT() *MockIdempotencyServiceMockRecorder {
+	return m.recorder
+}
+
+// AbandonRequest mocks base method.
+func (m *MockIdempotencyService) AbandonRequest(ctx context.Context, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AbandonRequest", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AbandonRequest indicates an expected call of AbandonRequest.
+func (mr *MockIdempotencyServiceMockRecorder) AbandonRequest(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AbandonRequest", reflect.TypeOf((*MockIdempotencyService)(nil).AbandonRequest), ctx, key)
+}
+
+// BeginRequest mocks base method.
+func (m *MockIdempotencyService) BeginRequest(ctx context.Context, key, fingerprint string) (*models.IdempotentResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BeginRequest", ctx, key, fingerprint)
+	ret0, _ := ret[0].(*models.IdempotentResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BeginRequest indicates an expected call of BeginRequest.
+func (mr *MockIdempotencyServiceMockRecorder) BeginRequest(ctx, key, fingerprint interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BeginRequest", reflect.TypeOf((*MockIdempotencyService)(nil).BeginRequest), ctx, key, fingerprint)
+}
+
+// CompleteRequest mocks base method.
+func (m *MockIdempotencyService) CompleteRequest(ctx context.Context, key string, response models.IdempotentResponse) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteRequest", ctx, key, response)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CompleteRequest indicates an expected call of CompleteRequest.
+func (mr *MockIdempotencyServiceMockRecorder) CompleteRequest(ctx, key, response interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteRequest", reflect.TypeOf((*MockIdempotencyService)(nil).CompleteRequest), ctx, key, response)
+}
+
+// PurgeExpiredKeys mocks base method.
+func (m *MockIdempotencyService) PurgeExpiredKeys(ctx context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeExpiredKeys", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeExpiredKeys indicates an expected call of PurgeExpiredKeys.
+func (mr *MockIdempotencyServiceMockRecorder) PurgeExpiredKeys(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeExpiredKeys", reflect.TypeOf((*MockIdempotencyService)(nil).PurgeExpiredKeys), ctx)
+}
+
+// MockEventService is a mock of EventService interface.
+type MockEventService struct {
+	ctrl     *gomock.Controller
+	recorder *MockEventServiceMockRecorder
+}
+
+// MockEventServiceMockRecorder is the mock recorder for MockEventService.
+type MockEventServiceMockRecorder struct {
+	mock *MockEventService
+}
+
+// NewMockEventService creates a new mock instance.
+func NewMockEventService(ctrl *gomock.Controller) *MockEventService {
+	mock := &MockEventService{ctrl: ctrl}
+	mock.recorder = &MockEventServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEventService) EXPECT() *MockEventServiceMockRecorder {
+	return m.recorder
+}
+
+// Disconnect mocks base method.
+func (m *MockEventService) Disconnect() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Disconnect")
+}
+
+// Disconnect indicates an expected call of Disconnect.
+func (mr *MockEventServiceMockRecorder) Disconnect() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Disconnect", reflect.TypeOf((*MockEventService)(nil).Disconnect))
+}
+
+// PurgeExpiredEvents mocks base method.
+func (m *MockEventService) PurgeExpiredEvents(ctx context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeExpiredEvents", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeExpiredEvents indicates an expected call of PurgeExpiredEvents.
+func (mr *MockEventServiceMockRecorder) PurgeExpiredEvents(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeExpiredEvents", reflect.TypeOf((*MockEventService)(nil).PurgeExpiredEvents), ctx)
+}
+
+// Relay mocks base method.
+func (m *MockEventService) Relay(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Relay", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Relay indicates an expected call of Relay.
+func (mr *MockEventServiceMockRecorder) Relay(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Relay", reflect.TypeOf((*MockEventService)(nil).Relay), ctx)
+}
+
+// Subscribe mocks base method.
+func (m *MockEventService) Subscribe(ctx context.Context, lastEventId *int64) (<-chan models.TodoEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Subscribe", ctx, lastEventId)
+	ret0, _ := ret[0].(<-chan models.TodoEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Subscribe indicates an expected call of Subscribe.
+func (mr *MockEventServiceMockRecorder) Subscribe(ctx, lastEventId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Subscribe", reflect.TypeOf((*MockEventService)(nil).Subscribe), ctx, lastEventId)
+}
+
+// MockWebhookService is a mock of WebhookService interface.
+type MockWebhookService struct {
+	ctrl     *gomock.Controller
+	recorder *MockWebhookServiceMockRecorder
+}
+
+// MockWebhookServiceMockRecorder is the mock recorder for MockWebhookService.
+type MockWebhookServiceMockRecorder struct {
+	mock *MockWebhookService
+}
+
+// NewMockWebhookService creates a new mock instance.
+func NewMockWebhookService(ctrl *gomock.Controller) *MockWebhookService {
+	mock := &MockWebhookService{ctrl: ctrl}
+	mock.recorder = &MockWebhookServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWebhookService) EXPECT() *MockWebhookServiceMockRecorder {
+	return m.recorder
+}
+
+// CreateWebhook mocks base method.
+func (m *MockWebhookService) CreateWebhook(ctx context.Context, webhook models.WebhookModel) (models.WebhookModel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebhook", ctx, webhook)
+	ret0, _ := ret[0].(models.WebhookModel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateWebhook indicates an expected call of CreateWebhook.
+func (mr *MockWebhookServiceMockRecorder) CreateWebhook(ctx, webhook interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebhook", reflect.TypeOf((*MockWebhookService)(nil).CreateWebhook), ctx, webhook)
+}
+
+// DeleteWebhook mocks base method.
+func (m *MockWebhookService) DeleteWebhook(ctx context.Context, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebhook", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteWebhook indicates an expected call of DeleteWebhook.
+func (mr *MockWebhookServiceMockRecorder) DeleteWebhook(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebhook", reflect.TypeOf((*MockWebhookService)(nil).DeleteWebhook), ctx, id)
+}
+
+// DeliverWebhooks mocks base method.
+func (m *MockWebhookService) DeliverWebhooks(ctx context.Context) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeliverWebhooks", ctx)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeliverWebhooks indicates an expected call of DeliverWebhooks.
+func (mr *MockWebhookServiceMockRecorder) DeliverWebhooks(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeliverWebhooks", reflect.TypeOf((*MockWebhookService)(nil).DeliverWebhooks), ctx)
+}
+
+// GetWebhook mocks base method.
+func (m *MockWebhookService) GetWebhook(ctx context.Context, id int) (models.WebhookModel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhook", ctx, id)
+	ret0, _ := ret[0].(models.WebhookModel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhook indicates an expected call of GetWebhook.
+func (mr *MockWebhookServiceMockRecorder) GetWebhook(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhook", reflect.TypeOf((*MockWebhookService)(nil).GetWebhook), ctx, id)
+}
+
+// GetWebhookDeliveries mocks base method.
+func (m *MockWebhookService) GetWebhookDeliveries(ctx context.Context, id, limit int) ([]models.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhookDeliveries", ctx, id, limit)
+	ret0, _ := ret[0].([]models.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhookDeliveries indicates an expected call of GetWebhookDeliveries.
+func (mr *MockWebhookServiceMockRecorder) GetWebhookDeliveries(ctx, id, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhookDeliveries", reflect.TypeOf((*MockWebhookService)(nil).GetWebhookDeliveries), ctx, id, limit)
+}
+
+// GetWebhooks mocks base method.
+func (m *MockWebhookService) GetWebhooks(ctx context.Context) ([]models.WebhookModel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhooks", ctx)
+	ret0, _ := ret[0].([]models.WebhookModel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhooks indicates an expected call of GetWebhooks.
+func (mr *MockWebhookServiceMockRecorder) GetWebhooks(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhooks", reflect.TypeOf((*MockWebhookService)(nil).GetWebhooks), ctx)
+}
+
+// UpdateWebhook mocks base method.
+func (m *MockWebhookService) UpdateWebhook(ctx context.Context, id int, webhook models.WebhookModel) (models.WebhookModel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateWebhook", ctx, id, webhook)
+	ret0, _ := ret[0].(models.WebhookModel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateWebhook indicates an expected call of UpdateWebhook.
+func (mr *MockWebhookServiceMockRecorder) UpdateWebhook(ctx, id, webhook interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWebhook", reflect.TypeOf((*MockWebhookService)(nil).UpdateWebhook), ctx, id, webhook)
+}
+
+// MockHealthService is a mock of HealthService interface.
+type MockHealthService struct {
+	ctrl     *gomock.Controller
+	recorder *MockHealthServiceMockRecorder
+}
+
+// MockHealthServiceMockRecorder is the mock recorder for MockHealthService.
+type MockHealthServiceMockRecorder struct {
+	mock *MockHealthService
+}
+
+// NewMockHealthService creates a new mock instance.
+func NewMockHealthService(ctrl *gomock.Controller) *MockHealthService {
+	mock := &MockHealthService{ctrl: ctrl}
+	mock.recorder = &MockHealthServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockHealthService) EXPECT() *MockHealthServiceMockRecorder {
+	return m.recorder
+}
+
+// Check mocks base method.
+func (m *MockHealthService) Check(ctx context.Context) models.HealthReport {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Check", ctx)
+	ret0, _ := ret[0].(models.HealthReport)
+	return ret0
+}
+
+// Check indicates an expected call of Check.
+func (mr *MockHealthServiceMockRecorder) Check(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Check", reflect.TypeOf((*MockHealthService)(nil).Check), ctx)
+}
+
+// Register mocks base method.
+func (m *MockHealthService) Register(name string, critical bool, check services.HealthCheck) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Register", name, critical, check)
+}
+
+// Register indicates an expected call of Register.
+func (mr *MockHealthServiceMockRecorder) Register(name, critical, check interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTodo", reflect.TypeOf((*MockTodoService)(nil).UpdateTodo), ctx, id, todo)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Register", reflect.TypeOf((*MockHealthService)(nil).Register), name, critical, check)
 }
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"github.com/cherrycutter/todo_app/internal/models"
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+// encodeCursor turns a cursor into an opaque url-safe string
+func encodeCursor[T any](cursor T) (string, error) {
+	raw, err := json.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// decodeCursor parses a cursor previously produced by encodeCursor
+func decodeCursor(s string) (models.TodoCursor, error) {
+	var cursor models.TodoCursor
+	if err := unmarshalCursor(s, &cursor); err != nil || cursor.Id <= 0 {
+		return cursor, ErrInvalidCursor
+	}
+	return cursor, nil
+}
+
+// decodeAuditCursor parses an audit cursor previously produced by encodeCursor
+func decodeAuditCursor(s string) (models.AuditCursor, error) {
+	var cursor models.AuditCursor
+	if err := unmarshalCursor(s, &cursor); err != nil || cursor.Id <= 0 {
+		return cursor, ErrInvalidCursor
+	}
+	return cursor, nil
+}
+
+func unmarshalCursor(s string, cursor interface{}) error {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, cursor)
+}
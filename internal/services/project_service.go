@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/cherrycutter/todo_app/internal/repos"
+)
+
+type ProjectServiceImpl struct {
+	repo repos.ProjectRepository
+	// todos runs the deletes of projects, which write their todos, in audited transactions
+	todos repos.TodoRepository
+}
+
+func NewProjectService(repo repos.ProjectRepository, todos repos.TodoRepository) ProjectService {
+	return &ProjectServiceImpl{repo: repo, todos: todos}
+}
+
+var (
+	ErrInboxProject = errors.New("the inbox project cannot be deleted or archived")
+)
+
+func (s *ProjectServiceImpl) GetProjects(ctx context.Context, includeArchived bool) ([]models.ProjectModel, error) {
+	userId, err := currentUserId(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetAllProjects(ctx, userId, includeArchived)
+}
+
+func (s *ProjectServiceImpl) GetProject(ctx context.Context, id int) (models.ProjectModel, error) {
+	userId, err := currentUserId(ctx)
+	if err != nil {
+		return models.ProjectModel{}, err
+	}
+	return s.repo.GetProjectById(ctx, userId, id)
+}
+
+func (s *ProjectServiceImpl) CreateProject(ctx context.Context, project models.ProjectModel) (models.ProjectModel, error) {
+	if err := s.validateProjectInput(project); err != nil {
+		return project, err
+	}
+	userId, err := currentUserId(ctx)
+	if err != nil {
+		return project, err
+	}
+	return s.repo.CreateProject(ctx, userId, project)
+}
+
+func (s *ProjectServiceImpl) UpdateProject(ctx context.Context, id int, project models.ProjectModel) (models.ProjectModel, error) {
+	if err := s.validateProjectInput(project); err != nil {
+		return project, err
+	}
+	userId, err := currentUserId(ctx)
+	if err != nil {
+		return project, err
+	}
+	if project.Archived {
+		current, err := s.repo.GetProjectById(ctx, userId, id)
+		if err != nil {
+			return models.ProjectModel{}, err
+		}
+		if current.Inbox {
+			return models.ProjectModel{}, ErrInboxProject
+		}
+	}
+	return s.repo.UpdateProject(ctx, userId, id, project)
+}
+
+// DeleteProject deletes a project, its todos are moved to the trash or to the inbox depending on mode. The todos
+// are audited in the transaction of the delete
+func (s *ProjectServiceImpl) DeleteProject(ctx context.Context, id int, mode models.ProjectDeleteMode) error {
+	if mode != models.ProjectDeleteCascade && mode != models.ProjectDeleteMoveToInbox {
+		return &ValidationError{Message: "mode must be cascade or move"}
+	}
+	userId, err := currentUserId(ctx)
+	if err != nil {
+		return err
+	}
+	project, err := s.repo.GetProjectById(ctx, userId, id)
+	if err != nil {
+		return err
+	}
+	if project.Inbox {
+		return ErrInboxProject
+	}
+	return inAuditedTx(ctx, s.todos, userId, func(repo repos.TodoRepository) ([]todoChange, error) {
+		before, err := repo.LockProjectTodos(ctx, userId, id)
+		if err != nil {
+			return nil, err
+		}
+		if err = repo.Projects().DeleteProjectById(ctx, userId, id, mode); err != nil {
+			return nil, err
+		}
+		after, err := repo.LockTodos(ctx, userId, todoIds(before))
+		if err != nil {
+			return nil, err
+		}
+		return touchedChanges(before, after), nil
+	})
+}
+
+func (s *ProjectServiceImpl) validateProjectInput(project models.ProjectModel) error {
+	if project.Name == "" {
+		return &ValidationError{Message: "field name cannot be empty"}
+	}
+	if len(project.Name) > 255 {
+		return &ValidationError{Message: "name cannot be longer than 255 characters"}
+	}
+	if project.Color != "" && !colorPattern.MatchString(project.Color) {
+		return &ValidationError{Message: "color must be a hex color like #1e90ff"}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/cherrycutter/todo_app/pkg/rrule"
+)
+
+// GetOccurrences previews up to limit instances following the todo in its series, a todo that does not
+// recur has none
+func (s *TodoServiceImpl) GetOccurrences(ctx context.Context, id, limit int) (models.TodoOccurrences, error) {
+	userId, err := currentUserId(ctx)
+	if err != nil {
+		return models.TodoOccurrences{}, err
+	}
+	todo, err := s.repo.GetTodoById(ctx, userId, id)
+	if err != nil {
+		return models.TodoOccurrences{}, err
+	}
+	if limit <= 0 {
+		limit = defaultOccurrenceLimit
+	}
+	items, err := occurrences(todo, limit)
+	if err != nil {
+		return models.TodoOccurrences{}, err
+	}
+	return models.TodoOccurrences{Items: items}, nil
+}
+
+// defaultOccurrenceLimit is the number of instances previewed when no limit is given
+const defaultOccurrenceLimit = 5
+
+// validateRecurrence checks the recurrence rule of a todo and returns it in its canonical form
+func validateRecurrence(todo models.TodoModel) (*string, error) {
+	if todo.Recurrence == nil {
+		return nil, nil
+	}
+	rule, err := rrule.Parse(*todo.Recurrence)
+	if err != nil {
+		return nil, &ValidationError{Message: "invalid recurrence: " + err.Error()}
+	}
+	if todo.DueAt == nil {
+		return nil, &ValidationError{Message: "a recurring todo needs a due date"}
+	}
+	canonical := rule.String()
+	return &canonical, nil
+}
+
+// nextOccurrence returns the instance created when the todo gets completed, nil when the todo
+// is not completed, does not recur or is the last instance of its series
+func nextOccurrence(todo models.TodoModel) (*models.TodoOccurrence, error) {
+	if !todo.Completed || todo.Recurrence == nil {
+		return nil, nil
+	}
+	items, err := occurrences(todo, 1)
+	if err != nil || len(items) == 0 {
+		return nil, err
+	}
+	return &items[0], nil
+}
+
+// occurrences returns up to n instances following the todo in its series. The reminder keeps its distance
+// to the due date and the COUNT of the rule is reduced to the instances left after each one
+func occurrences(todo models.TodoModel, n int) ([]models.TodoOccurrence, error) {
+	items := []models.TodoOccurrence{}
+	if todo.Recurrence == nil || todo.DueAt == nil {
+		return items, nil
+	}
+	rule, err := rrule.Parse(*todo.Recurrence)
+	if err != nil {
+		return nil, err
+	}
+	left := rule
+	for _, due := range rule.Next(*todo.DueAt, n) {
+		occurrence := models.TodoOccurrence{DueAt: due}
+		if todo.RemindAt != nil {
+			remindAt := todo.RemindAt.Add(due.Sub(*todo.DueAt))
+			occurrence.RemindAt = &remindAt
+		}
+		if left.Count > 0 {
+			left.Count--
+		}
+		occurrence.Recurrence = left.String()
+		items = append(items, occurrence)
+	}
+	return items, nil
+}
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"context"
+	"github.com/cherrycutter/todo_app/internal/auth"
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestCompleteSeriesWithCount(t *testing.T) {
+	due := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+	rule := "FREQ=DAILY;COUNT=3"
+	repo := newFakeTodoRepo(models.TodoModel{Id: 1, Title: "Water the plants", DueAt: &due, Recurrence: &rule, Version: 1})
+	s := NewTodoService(repo)
+	ctx := auth.WithUserId(context.Background(), testUserId)
+
+	// completing the open instance spawns the next one until the COUNT of the series is used up
+	for completed := 0; completed < 5; completed++ {
+		var open *models.TodoModel
+		for _, todo := range repo.sorted() {
+			if !todo.Completed {
+				open = &todo
+			}
+		}
+		if open == nil {
+			break
+		}
+		open.Completed = true
+		_, err := s.UpdateTodo(ctx, open.Id, *open, models.TodoUpdateOptions{})
+		if !assert.NoError(t, err) {
+			return
+		}
+	}
+
+	todos := repo.sorted()
+	if !assert.Len(t, todos, 3) {
+		return
+	}
+	wantRules := []string{"FREQ=DAILY;COUNT=3", "FREQ=DAILY;COUNT=2", "FREQ=DAILY;COUNT=1"}
+	for i, todo := range todos {
+		assert.True(t, todo.Completed)
+		assert.Equal(t, due.AddDate(0, 0, i), *todo.DueAt)
+		assert.Equal(t, wantRules[i], *todo.Recurrence)
+		assert.Equal(t, 1, *todo.SeriesId)
+	}
+}
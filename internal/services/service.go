@@ -2,9 +2,10 @@ package services
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"github.com/cherrycutter/todo_app/internal/models"
 	"github.com/cherrycutter/todo_app/internal/repos"
+	"strings"
 )
 
 type TodoServiceImpl struct {
@@ -15,38 +16,323 @@ func NewTodoService(repo repos.TodoRepository) TodoService {
 	return &TodoServiceImpl{repo: repo}
 }
 
-func (s *TodoServiceImpl) GetTodos(ctx context.Context) ([]models.TodoModel, error) {
-	return s.repo.GetAllTodos(ctx)
+// PreconditionFailedError is returned when a conditional write finds the todo at another version than the one
+// the client read, Current is the todo as it is now
+type PreconditionFailedError struct {
+	Current models.TodoModel
+}
+
+func (e *PreconditionFailedError) Error() string {
+	return repos.ErrVersionMismatch.Error()
+}
+
+func (e *PreconditionFailedError) Unwrap() error {
+	return repos.ErrVersionMismatch
+}
+
+// maxSearchQueryLength bounds the size of the tsquery built from a search
+const maxSearchQueryLength = 256
+
+func (s *TodoServiceImpl) GetTodos(ctx context.Context, filter models.TodoFilter, params models.ListParams) (models.TodoPage, error) {
+	userId, err := currentUserId(ctx)
+	if err != nil {
+		return models.TodoPage{}, err
+	}
+	var after *models.TodoCursor
+	if params.Cursor != "" {
+		cursor, err := decodeCursor(params.Cursor)
+		if err != nil {
+			return models.TodoPage{}, err
+		}
+		if cursor.Sort != models.FormatSort(filter.Sort) {
+			return models.TodoPage{}, ErrInvalidCursor
+		}
+		after = &cursor
+	}
+	limit := params.Limit
+	if limit <= 0 {
+		limit = models.DefaultPageLimit
+	}
+
+	// one extra row tells whether there is a next page
+	todos, err := s.repo.GetAllTodos(ctx, userId, filter, limit+1, after)
+	if err != nil {
+		return models.TodoPage{}, err
+	}
+
+	page := models.TodoPage{Items: todos}
+	if len(todos) > limit {
+		page.Items = todos[:limit]
+		page.NextCursor, err = encodeCursor(models.NewTodoCursor(page.Items[limit-1], filter.Sort))
+		if err != nil {
+			return models.TodoPage{}, err
+		}
+	}
+	if page.Items == nil {
+		page.Items = []models.TodoModel{}
+	}
+
+	if params.WithTotal {
+		total, err := s.repo.CountTodos(ctx, userId, filter)
+		if err != nil {
+			return models.TodoPage{}, err
+		}
+		page.Total = &total
+	}
+	return page, nil
 }
 
 func (s *TodoServiceImpl) GetTodo(ctx context.Context, id int) (models.TodoModel, error) {
-	return s.repo.GetTodoById(ctx, id)
+	userId, err := currentUserId(ctx)
+	if err != nil {
+		return models.TodoModel{}, err
+	}
+	return s.repo.GetTodoById(ctx, userId, id)
 }
 
 func (s *TodoServiceImpl) CreateTodo(ctx context.Context, todo models.TodoModel) (models.TodoModel, error) {
 	if err := s.validateTodoInput(todo); err != nil {
 		return todo, err
 	}
-	return s.repo.CreateTodo(ctx, todo)
+	var err error
+	if todo.Recurrence, err = validateRecurrence(todo); err != nil {
+		return todo, err
+	}
+	userId, err := currentUserId(ctx)
+	if err != nil {
+		return todo, err
+	}
+	var createdTodo models.TodoModel
+	err = s.audited(ctx, userId, models.AuditCreate, func(repo repos.TodoRepository) (*models.TodoModel, *models.TodoModel, error) {
+		var err error
+		createdTodo, err = repo.CreateTodo(ctx, userId, todo)
+		return nil, &createdTodo, err
+	})
+	if err != nil {
+		return models.TodoModel{}, err
+	}
+	return createdTodo, nil
 }
 
-func (s *TodoServiceImpl) UpdateTodo(ctx context.Context, id int, todo models.TodoModel) (models.TodoModel, error) {
+// UpdateTodo replaces a todo, completing an instance of a recurring todo creates the next instance of its series
+func (s *TodoServiceImpl) UpdateTodo(ctx context.Context, id int, todo models.TodoModel, opts models.TodoUpdateOptions) (models.TodoModel, error) {
 	if err := s.validateTodoInput(todo); err != nil {
 		return todo, err
 	}
-	return s.repo.UpdateTodo(ctx, id, todo)
+	var err error
+	if todo.Recurrence, err = validateRecurrence(todo); err != nil {
+		return todo, err
+	}
+	userId, err := currentUserId(ctx)
+	if err != nil {
+		return todo, err
+	}
+	next, err := nextOccurrence(todo)
+	if err != nil {
+		return todo, err
+	}
+	var updatedTodo models.TodoModel
+	err = s.auditedChanges(ctx, userId, func(repo repos.TodoRepository) ([]todoChange, error) {
+		current, err := repo.LockTodo(ctx, userId, id)
+		if err != nil {
+			return nil, err
+		}
+		var spawned *models.TodoModel
+		if updatedTodo, spawned, err = repo.UpdateTodo(ctx, userId, id, todo, next, opts.IfMatch); err != nil {
+			return nil, err
+		}
+		return updateChanges(ctx, repo, userId, &current, &updatedTodo, spawned, opts)
+	})
+	if err != nil {
+		return models.TodoModel{}, s.versionError(ctx, userId, id, err)
+	}
+	return updatedTodo, nil
+}
+
+// PatchTodo applies a merge patch to a todo, the validation runs on the merged todo. Like UpdateTodo, completing
+// an instance of a recurring todo creates the next instance
+func (s *TodoServiceImpl) PatchTodo(ctx context.Context, id int, patch models.TodoPatch, opts models.TodoUpdateOptions) (models.TodoModel, error) {
+	if patch.Title.Null {
+		return models.TodoModel{}, &ValidationError{Message: "field title cannot be null"}
+	}
+	if patch.Completed.Null {
+		return models.TodoModel{}, &ValidationError{Message: "field completed cannot be null"}
+	}
+	userId, err := currentUserId(ctx)
+	if err != nil {
+		return models.TodoModel{}, err
+	}
+	if patch.IsEmpty() {
+		current, err := s.repo.GetTodoById(ctx, userId, id)
+		if err != nil {
+			return models.TodoModel{}, err
+		}
+		if opts.IfMatch != nil && *opts.IfMatch != current.Version {
+			return models.TodoModel{}, &PreconditionFailedError{Current: current}
+		}
+		return current, nil
+	}
+	var patchedTodo models.TodoModel
+	err = s.auditedChanges(ctx, userId, func(repo repos.TodoRepository) ([]todoChange, error) {
+		current, err := repo.LockTodo(ctx, userId, id)
+		if err != nil {
+			return nil, err
+		}
+		if opts.IfMatch != nil && *opts.IfMatch != current.Version {
+			return nil, &PreconditionFailedError{Current: current}
+		}
+		merged := patch.Apply(current)
+		if err = s.validateTodoInput(merged); err != nil {
+			return nil, err
+		}
+		rule, err := validateRecurrence(merged)
+		if err != nil {
+			return nil, err
+		}
+		if patch.Recurrence.Set && !patch.Recurrence.Null {
+			patch.Recurrence.Value = *rule
+		}
+		var next *models.TodoOccurrence
+		if !current.Completed {
+			if next, err = nextOccurrence(merged); err != nil {
+				return nil, err
+			}
+		}
+		var spawned *models.TodoModel
+		if patchedTodo, spawned, err = repo.PatchTodo(ctx, userId, id, patch, next, opts.IfMatch); err != nil {
+			return nil, err
+		}
+		return updateChanges(ctx, repo, userId, &current, &patchedTodo, spawned, opts)
+	})
+	if err != nil {
+		return models.TodoModel{}, s.versionError(ctx, userId, id, err)
+	}
+	return patchedTodo, nil
+}
+
+// updateChanges returns the changes made by an update of a todo: the update itself, the next instance of its series
+// it spawned and, when the options ask for it, the completion of its subtasks. The progress of the updated todo
+// is refreshed after the subtasks are completed
+func updateChanges(ctx context.Context, repo repos.TodoRepository, userId int, before, after, spawned *models.TodoModel, opts models.TodoUpdateOptions) ([]todoChange, error) {
+	changes := []todoChange{{op: models.AuditUpdate, before: before, after: after}}
+	if spawned != nil {
+		changes = append(changes, todoChange{op: models.AuditCreate, after: spawned})
+	}
+	cascaded, err := cascadeCompletion(ctx, repo, userId, after, opts)
+	if err != nil {
+		return nil, err
+	}
+	return append(changes, cascaded...), nil
+}
+
+// cascadeCompletion completes the subtasks of a completed todo when the options ask for it and returns
+// the changes of the subtasks it completed
+func cascadeCompletion(ctx context.Context, repo repos.TodoRepository, userId int, todo *models.TodoModel, opts models.TodoUpdateOptions) ([]todoChange, error) {
+	if !opts.CascadeCompletion || !todo.Completed || todo.Progress == nil {
+		return nil, nil
+	}
+	before, err := repo.LockSubtree(ctx, userId, todo.Id)
+	if err != nil {
+		return nil, err
+	}
+	if err = repo.CompleteSubtasks(ctx, userId, todo.Id); err != nil {
+		return nil, err
+	}
+	after, err := repo.LockSubtree(ctx, userId, todo.Id)
+	if err != nil {
+		return nil, err
+	}
+	// the todo got a new version with its progress, its change is the update itself
+	var changes []todoChange
+	for _, change := range writtenChanges(models.AuditUpdate, before, after) {
+		if change.after != nil && change.after.Id == todo.Id {
+			todo.Progress, todo.Version = change.after.Progress, change.after.Version
+			continue
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// DeleteTodo moves a todo to the trash, with ifMatch only while the todo has that version
+func (s *TodoServiceImpl) DeleteTodo(ctx context.Context, id int, ifMatch *int) error {
+	userId, err := currentUserId(ctx)
+	if err != nil {
+		return err
+	}
+	err = s.auditedChanges(ctx, userId, func(repo repos.TodoRepository) ([]todoChange, error) {
+		return trashTodo(ctx, repo, userId, id, ifMatch)
+	})
+	if err != nil {
+		return s.versionError(ctx, userId, id, err)
+	}
+	return nil
 }
 
-func (s *TodoServiceImpl) DeleteTodo(ctx context.Context, id int) error {
-	return s.repo.DeleteTodoById(ctx, id)
+// trashTodo moves a todo to the trash with its subtasks and returns the deletion of each of them
+func trashTodo(ctx context.Context, repo repos.TodoRepository, userId, id int, ifMatch *int) ([]todoChange, error) {
+	subtree, err := repo.LockSubtree(ctx, userId, id)
+	if err != nil {
+		return nil, err
+	}
+	if err = repo.DeleteTodoById(ctx, userId, id, ifMatch); err != nil {
+		return nil, err
+	}
+	var changes []todoChange
+	for i := range subtree {
+		// subtasks trashed before stay in the trash as they are
+		if subtree[i].DeletedAt == nil {
+			changes = append(changes, todoChange{op: models.AuditDelete, before: &subtree[i]})
+		}
+	}
+	return changes, nil
+}
+
+// versionError turns a version mismatch of a conditional write into a PreconditionFailedError
+// carrying the current todo, other errors are returned as they are
+func (s *TodoServiceImpl) versionError(ctx context.Context, userId, id int, err error) error {
+	var preconditionErr *PreconditionFailedError
+	if !errors.Is(err, repos.ErrVersionMismatch) || errors.As(err, &preconditionErr) {
+		return err
+	}
+	current, err := s.repo.GetTodoById(ctx, userId, id)
+	if err != nil {
+		return err
+	}
+	return &PreconditionFailedError{Current: current}
+}
+
+// SearchTodos returns the best ranked todos matching a full-text search
+func (s *TodoServiceImpl) SearchTodos(ctx context.Context, search models.TodoSearch, limit int) (models.TodoSearchResult, error) {
+	if strings.TrimSpace(search.Query) == "" {
+		return models.TodoSearchResult{}, &ValidationError{Message: "param q cannot be empty"}
+	}
+	if len(search.Query) > maxSearchQueryLength {
+		return models.TodoSearchResult{}, &ValidationError{Message: "param q cannot be longer than 256 characters"}
+	}
+	if limit <= 0 {
+		limit = models.DefaultPageLimit
+	}
+	userId, err := currentUserId(ctx)
+	if err != nil {
+		return models.TodoSearchResult{}, err
+	}
+	hits, err := s.repo.SearchTodos(ctx, userId, search, limit)
+	if err != nil {
+		return models.TodoSearchResult{}, err
+	}
+	return models.TodoSearchResult{Items: hits}, nil
 }
 
 func (s *TodoServiceImpl) validateTodoInput(todo models.TodoModel) error {
 	if todo.Title == "" {
-		return fmt.Errorf("field title cannot be empty")
+		return &ValidationError{Message: "field title cannot be empty"}
 	}
 	if len(todo.Title) > 255 {
-		return fmt.Errorf("title cannot be longer than 255 characters")
+		return &ValidationError{Message: "title cannot be longer than 255 characters"}
+	}
+	if todo.RemindAt != nil && todo.DueAt != nil && todo.RemindAt.After(*todo.DueAt) {
+		return &ValidationError{Message: "reminder cannot be set after the due date"}
 	}
 	return nil
 }
@@ -3,14 +3,91 @@ package services
 import (
 	"context"
 	"github.com/cherrycutter/todo_app/internal/models"
+	"time"
 )
 
 //go:generate mockgen -source=service_interfaces.go -destination=mocks/mock.go
 
 type TodoService interface {
-	GetTodos(ctx context.Context) ([]models.TodoModel, error)
+	GetTodos(ctx context.Context, filter models.TodoFilter, params models.ListParams) (models.TodoPage, error)
 	GetTodo(ctx context.Context, id int) (models.TodoModel, error)
 	CreateTodo(ctx context.Context, todo models.TodoModel) (models.TodoModel, error)
-	UpdateTodo(ctx context.Context, id int, todo models.TodoModel) (models.TodoModel, error)
-	DeleteTodo(ctx context.Context, id int) error
+	UpdateTodo(ctx context.Context, id int, todo models.TodoModel, opts models.TodoUpdateOptions) (models.TodoModel, error)
+	PatchTodo(ctx context.Context, id int, patch models.TodoPatch, opts models.TodoUpdateOptions) (models.TodoModel, error)
+	DeleteTodo(ctx context.Context, id int, ifMatch *int) error
+	BulkTodos(ctx context.Context, req models.TodoBulkRequest) (models.TodoBulkResponse, error)
+	SearchTodos(ctx context.Context, search models.TodoSearch, limit int) (models.TodoSearchResult, error)
+	GetSubtasks(ctx context.Context, id int) ([]models.TodoModel, error)
+	CreateSubtask(ctx context.Context, id int, todo models.TodoModel) (models.TodoModel, error)
+	ReorderSubtasks(ctx context.Context, id int, ids []int) ([]models.TodoModel, error)
+	ToggleSubtask(ctx context.Context, id, subtaskId int) (models.TodoModel, error)
+	GetOccurrences(ctx context.Context, id, limit int) (models.TodoOccurrences, error)
+	RestoreTodo(ctx context.Context, id int) (models.TodoModel, error)
+	DeleteTrashedTodo(ctx context.Context, id int) error
+	PurgeTrash(ctx context.Context, retention time.Duration) (int64, error)
+	GetTodoHistory(ctx context.Context, id int, params models.ListParams) (models.AuditPage, error)
+	GetAuditLog(ctx context.Context, filter models.AuditFilter, params models.ListParams) (models.AuditPage, error)
+}
+
+type TagService interface {
+	GetTags(ctx context.Context) ([]models.TagModel, error)
+	GetTag(ctx context.Context, id int) (models.TagModel, error)
+	CreateTag(ctx context.Context, tag models.TagModel) (models.TagModel, error)
+	UpdateTag(ctx context.Context, id int, tag models.TagModel) (models.TagModel, error)
+	DeleteTag(ctx context.Context, id int) error
+	AttachTag(ctx context.Context, todoId, tagId int) error
+	DetachTag(ctx context.Context, todoId, tagId int) error
+}
+
+type ProjectService interface {
+	GetProjects(ctx context.Context, includeArchived bool) ([]models.ProjectModel, error)
+	GetProject(ctx context.Context, id int) (models.ProjectModel, error)
+	CreateProject(ctx context.Context, project models.ProjectModel) (models.ProjectModel, error)
+	UpdateProject(ctx context.Context, id int, project models.ProjectModel) (models.ProjectModel, error)
+	DeleteProject(ctx context.Context, id int, mode models.ProjectDeleteMode) error
+}
+
+type AuthService interface {
+	Register(ctx context.Context, credentials models.Credentials) (models.UserModel, error)
+	Login(ctx context.Context, credentials models.Credentials) (models.TokenPair, error)
+	Refresh(ctx context.Context, refreshToken string) (models.TokenPair, error)
+	Logout(ctx context.Context, refreshToken string) error
+	Authenticate(ctx context.Context, accessToken string) (int, error)
+}
+
+type APIKeyService interface {
+	GetAPIKeys(ctx context.Context) ([]models.APIKeyModel, error)
+	CreateAPIKey(ctx context.Context, key models.APIKeyModel) (models.CreatedAPIKey, error)
+	RotateAPIKey(ctx context.Context, id int) (models.CreatedAPIKey, error)
+	RevokeAPIKey(ctx context.Context, id int) error
+	Authenticate(ctx context.Context, key string) (int, []string, error)
+}
+
+type IdempotencyService interface {
+	BeginRequest(ctx context.Context, key, fingerprint string) (*models.IdempotentResponse, error)
+	CompleteRequest(ctx context.Context, key string, response models.IdempotentResponse) error
+	AbandonRequest(ctx context.Context, key string) error
+	PurgeExpiredKeys(ctx context.Context) (int64, error)
+}
+
+type EventService interface {
+	Subscribe(ctx context.Context, lastEventId *int64) (<-chan models.TodoEvent, error)
+	Relay(ctx context.Context) error
+	PurgeExpiredEvents(ctx context.Context) (int64, error)
+	Disconnect()
+}
+
+type WebhookService interface {
+	GetWebhooks(ctx context.Context) ([]models.WebhookModel, error)
+	GetWebhook(ctx context.Context, id int) (models.WebhookModel, error)
+	CreateWebhook(ctx context.Context, webhook models.WebhookModel) (models.WebhookModel, error)
+	UpdateWebhook(ctx context.Context, id int, webhook models.WebhookModel) (models.WebhookModel, error)
+	DeleteWebhook(ctx context.Context, id int) error
+	GetWebhookDeliveries(ctx context.Context, id, limit int) ([]models.WebhookDelivery, error)
+	DeliverWebhooks(ctx context.Context) (int, error)
+}
+
+type HealthService interface {
+	Register(name string, critical bool, check HealthCheck)
+	Check(ctx context.Context) models.HealthReport
 }
@@ -0,0 +1,362 @@
+package services
+
+import (
+	"context"
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/cherrycutter/todo_app/internal/repos"
+	"slices"
+	"sort"
+	"time"
+)
+
+const testUserId = 7
+
+// fakeTodoRepo keeps the todos of one user in memory and records the audit entries, events and webhook
+// deliveries written with their changes. Its transactions run fn on the repository itself
+type fakeTodoRepo struct {
+	repos.TodoRepository
+	todos      map[int]models.TodoModel
+	lastId     int
+	entries    []models.AuditEntry
+	events     []models.TodoEvent
+	deliveries []models.WebhookDelivery
+}
+
+func newFakeTodoRepo(todos ...models.TodoModel) *fakeTodoRepo {
+	r := &fakeTodoRepo{todos: make(map[int]models.TodoModel)}
+	for _, todo := range todos {
+		r.todos[todo.Id] = todo
+		r.lastId = max(r.lastId, todo.Id)
+	}
+	return r
+}
+
+// sorted returns the todos by id
+func (r *fakeTodoRepo) sorted() []models.TodoModel {
+	todos := make([]models.TodoModel, 0, len(r.todos))
+	for _, todo := range r.todos {
+		todos = append(todos, todo)
+	}
+	sort.Slice(todos, func(i, j int) bool { return todos[i].Id < todos[j].Id })
+	return todos
+}
+
+func (r *fakeTodoRepo) InTx(ctx context.Context, fn func(repo repos.TodoRepository) error) error {
+	return fn(r)
+}
+
+// withProgress returns a todo with the progress of its subtasks that are not in the trash
+func (r *fakeTodoRepo) withProgress(todo models.TodoModel) models.TodoModel {
+	var progress models.TodoProgress
+	for _, subtask := range r.todos {
+		if subtask.ParentId != nil && *subtask.ParentId == todo.Id && subtask.DeletedAt == nil {
+			progress.Total++
+			if subtask.Completed {
+				progress.Done++
+			}
+		}
+	}
+	todo.Progress = nil
+	if progress.Total > 0 {
+		todo.Progress = &progress
+	}
+	return todo
+}
+
+// touchParent gives a new version to the parent of a subtask whose state counts in its progress, as the
+// todo_touch_parent trigger does
+func (r *fakeTodoRepo) touchParent(subtask models.TodoModel) {
+	if subtask.ParentId == nil {
+		return
+	}
+	if parent, ok := r.todos[*subtask.ParentId]; ok {
+		parent.Version++
+		r.todos[parent.Id] = parent
+	}
+}
+
+// subtree returns the ids of a todo and of its subtasks at any depth
+func (r *fakeTodoRepo) subtree(id int) []int {
+	ids := []int{id}
+	for i := 0; i < len(ids); i++ {
+		for _, todo := range r.sorted() {
+			if todo.ParentId != nil && *todo.ParentId == ids[i] {
+				ids = append(ids, todo.Id)
+			}
+		}
+	}
+	return ids
+}
+
+func (r *fakeTodoRepo) GetTodoById(ctx context.Context, ownerId, id int) (models.TodoModel, error) {
+	todo, ok := r.todos[id]
+	if !ok || todo.DeletedAt != nil {
+		return models.TodoModel{}, repos.ErrTodoNotFound
+	}
+	return r.withProgress(todo), nil
+}
+
+func (r *fakeTodoRepo) LockTodo(ctx context.Context, ownerId, id int) (models.TodoModel, error) {
+	return r.GetTodoById(ctx, ownerId, id)
+}
+
+func (r *fakeTodoRepo) LockSubtree(ctx context.Context, ownerId, id int) ([]models.TodoModel, error) {
+	if _, ok := r.todos[id]; !ok {
+		return nil, repos.ErrTodoNotFound
+	}
+	var todos []models.TodoModel
+	for _, subtaskId := range r.subtree(id) {
+		todos = append(todos, r.withProgress(r.todos[subtaskId]))
+	}
+	sort.Slice(todos, func(i, j int) bool { return todos[i].Id < todos[j].Id })
+	return todos, nil
+}
+
+func (r *fakeTodoRepo) UpdateTodo(ctx context.Context, ownerId, id int, todo models.TodoModel, next *models.TodoOccurrence, ifMatch *int) (models.TodoModel, *models.TodoModel, error) {
+	current, err := r.GetTodoById(ctx, ownerId, id)
+	if err != nil {
+		return models.TodoModel{}, nil, err
+	}
+	if ifMatch != nil && *ifMatch != current.Version {
+		return models.TodoModel{}, nil, repos.ErrVersionMismatch
+	}
+	todo.Id, todo.CreatedAt, todo.SeriesId, todo.Version = id, current.CreatedAt, current.SeriesId, current.Version+1
+	var spawned *models.TodoModel
+	if next != nil && todo.Completed && !current.Completed {
+		if todo.SeriesId == nil {
+			todo.SeriesId = &todo.Id
+		}
+		r.lastId++
+		recurrence := next.Recurrence
+		spawned = &models.TodoModel{
+			Id: r.lastId, Title: todo.Title, Description: todo.Description, DueAt: &next.DueAt, RemindAt: next.RemindAt,
+			ProjectId: todo.ProjectId, ParentId: todo.ParentId, Recurrence: &recurrence, SeriesId: todo.SeriesId, Version: 1,
+		}
+		r.todos[spawned.Id] = *spawned
+	}
+	r.todos[id] = todo
+	if todo.Completed != current.Completed {
+		r.touchParent(todo)
+	}
+	return r.withProgress(r.todos[id]), spawned, nil
+}
+
+func (r *fakeTodoRepo) PatchTodo(ctx context.Context, ownerId, id int, patch models.TodoPatch, next *models.TodoOccurrence, ifMatch *int) (models.TodoModel, *models.TodoModel, error) {
+	current, err := r.GetTodoById(ctx, ownerId, id)
+	if err != nil {
+		return models.TodoModel{}, nil, err
+	}
+	return r.UpdateTodo(ctx, ownerId, id, patch.Apply(current), next, ifMatch)
+}
+
+func (r *fakeTodoRepo) CompleteSubtasks(ctx context.Context, ownerId, id int) error {
+	for _, subtaskId := range r.subtree(id)[1:] {
+		todo := r.todos[subtaskId]
+		if todo.DeletedAt == nil && !todo.Completed {
+			todo.Completed = true
+			todo.Version++
+			r.todos[subtaskId] = todo
+			r.touchParent(todo)
+		}
+	}
+	return nil
+}
+
+func (r *fakeTodoRepo) DeleteTodoById(ctx context.Context, ownerId, id int, ifMatch *int) error {
+	if _, err := r.GetTodoById(ctx, ownerId, id); err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, subtaskId := range r.subtree(id) {
+		todo := r.todos[subtaskId]
+		if todo.DeletedAt == nil {
+			todo.DeletedAt = &now
+			r.todos[subtaskId] = todo
+			r.touchParent(todo)
+		}
+	}
+	return nil
+}
+
+func (r *fakeTodoRepo) RestoreTodo(ctx context.Context, ownerId, id int) error {
+	target, ok := r.todos[id]
+	if !ok || target.DeletedAt == nil {
+		return repos.ErrTodoNotFound
+	}
+	deletedAt := *target.DeletedAt
+	for _, subtaskId := range r.subtree(id) {
+		todo := r.todos[subtaskId]
+		if todo.DeletedAt != nil && todo.DeletedAt.Equal(deletedAt) {
+			todo.DeletedAt = nil
+			todo.Version++
+			r.todos[subtaskId] = todo
+			r.touchParent(todo)
+		}
+	}
+	return nil
+}
+
+func (r *fakeTodoRepo) DeleteTrashedTodo(ctx context.Context, ownerId, id int) error {
+	if todo, ok := r.todos[id]; !ok || todo.DeletedAt == nil {
+		return repos.ErrTodoNotFound
+	}
+	for _, subtaskId := range r.subtree(id) {
+		delete(r.todos, subtaskId)
+	}
+	return nil
+}
+
+func (r *fakeTodoRepo) AddAuditEntries(ctx context.Context, ownerId int, entries []models.AuditEntry) error {
+	r.entries = append(r.entries, entries...)
+	return nil
+}
+
+// GetAuditEntries returns the recorded audit entries, it ignores the filter and the page
+func (r *fakeTodoRepo) GetAuditEntries(ctx context.Context, ownerId int, filter models.AuditFilter, limit int, after *models.AuditCursor) ([]models.AuditEntry, error) {
+	return r.entries, nil
+}
+
+func (r *fakeTodoRepo) AddTodoEvents(ctx context.Context, ownerId int, events []models.TodoEvent) error {
+	r.events = append(r.events, events...)
+	return nil
+}
+
+func (r *fakeTodoRepo) AddWebhookDeliveries(ctx context.Context, ownerId int, deliveries []models.WebhookDelivery) error {
+	r.deliveries = append(r.deliveries, deliveries...)
+	return nil
+}
+
+// lockWhere returns the todos matching keep ordered by id, as the Lock methods of the repository do
+func (r *fakeTodoRepo) lockWhere(keep func(todo models.TodoModel) bool) []models.TodoModel {
+	var todos []models.TodoModel
+	for _, todo := range r.sorted() {
+		if keep(todo) {
+			todos = append(todos, r.withProgress(todo))
+		}
+	}
+	return todos
+}
+
+func (r *fakeTodoRepo) LockProjectTodos(ctx context.Context, ownerId, projectId int) ([]models.TodoModel, error) {
+	affected := make(map[int]bool)
+	for _, todo := range r.sorted() {
+		if todo.ProjectId == nil || *todo.ProjectId != projectId {
+			continue
+		}
+		affected[todo.Id] = true
+		if todo.DeletedAt == nil {
+			for _, subtaskId := range r.subtree(todo.Id) {
+				affected[subtaskId] = affected[subtaskId] || r.todos[subtaskId].DeletedAt == nil
+			}
+		}
+	}
+	return r.lockWhere(func(todo models.TodoModel) bool { return affected[todo.Id] }), nil
+}
+
+func (r *fakeTodoRepo) LockTaggedTodos(ctx context.Context, ownerId, tagId int) ([]models.TodoModel, error) {
+	return r.lockWhere(func(todo models.TodoModel) bool { return hasTag(todo, tagId) }), nil
+}
+
+func (r *fakeTodoRepo) LockTodos(ctx context.Context, ownerId int, ids []int) ([]models.TodoModel, error) {
+	return r.lockWhere(func(todo models.TodoModel) bool { return slices.Contains(ids, todo.Id) }), nil
+}
+
+func (r *fakeTodoRepo) Projects() repos.ProjectRepository {
+	return &fakeProjectRepo{todos: r, inboxId: fakeInboxId}
+}
+
+func (r *fakeTodoRepo) Tags() repos.TagRepository {
+	return &fakeTagRepo{todos: r}
+}
+
+// fakeInboxId is the id of the inbox project of the fake repositories
+const fakeInboxId = 100
+
+// fakeProjectRepo deletes projects from the todos of a fakeTodoRepo, any other project exists and is not an inbox
+type fakeProjectRepo struct {
+	repos.ProjectRepository
+	todos   *fakeTodoRepo
+	inboxId int
+}
+
+func (r *fakeProjectRepo) GetProjectById(ctx context.Context, ownerId, id int) (models.ProjectModel, error) {
+	return models.ProjectModel{Id: id, Inbox: id == r.inboxId}, nil
+}
+
+func (r *fakeProjectRepo) DeleteProjectById(ctx context.Context, ownerId, id int, mode models.ProjectDeleteMode) error {
+	todos, _ := r.todos.LockProjectTodos(ctx, ownerId, id)
+	now := time.Now()
+	for _, todo := range todos {
+		if mode == models.ProjectDeleteCascade && todo.DeletedAt == nil {
+			todo.DeletedAt = &now
+		}
+		if todo.ProjectId != nil && *todo.ProjectId == id {
+			todo.ProjectId = &r.inboxId
+		}
+		todo.Version++
+		r.todos.todos[todo.Id] = todo
+	}
+	return nil
+}
+
+// fakeTagRepo writes the tags carried by the todos of a fakeTodoRepo
+type fakeTagRepo struct {
+	repos.TagRepository
+	todos *fakeTodoRepo
+}
+
+func hasTag(todo models.TodoModel, tagId int) bool {
+	return slices.ContainsFunc(todo.Tags, func(tag models.TagModel) bool { return tag.Id == tagId })
+}
+
+// retag replaces the tags of every todo carrying the tag with the result of tags and gives them a new version
+func (r *fakeTagRepo) retag(tagId int, tags func(todo models.TodoModel) []models.TagModel) {
+	for _, todo := range r.todos.sorted() {
+		if hasTag(todo, tagId) {
+			todo.Tags = tags(todo)
+			todo.Version++
+			r.todos.todos[todo.Id] = todo
+		}
+	}
+}
+
+func (r *fakeTagRepo) UpdateTag(ctx context.Context, ownerId, id int, tag models.TagModel) (models.TagModel, error) {
+	tag.Id = id
+	r.retag(id, func(todo models.TodoModel) []models.TagModel {
+		tags := slices.Clone(todo.Tags)
+		tags[slices.IndexFunc(tags, func(t models.TagModel) bool { return t.Id == id })] = tag
+		return tags
+	})
+	return tag, nil
+}
+
+func (r *fakeTagRepo) DeleteTagById(ctx context.Context, ownerId, id int) error {
+	r.retag(id, func(todo models.TodoModel) []models.TagModel {
+		return slices.DeleteFunc(slices.Clone(todo.Tags), func(t models.TagModel) bool { return t.Id == id })
+	})
+	return nil
+}
+
+func (r *fakeTagRepo) AttachTag(ctx context.Context, ownerId, todoId, tagId int) error {
+	todo, err := r.todos.GetTodoById(ctx, ownerId, todoId)
+	if err != nil {
+		return err
+	}
+	if !hasTag(todo, tagId) {
+		todo.Tags = append(slices.Clone(todo.Tags), models.TagModel{Id: tagId})
+		todo.Version++
+		r.todos.todos[todoId] = todo
+	}
+	return nil
+}
+
+func (r *fakeTagRepo) DetachTag(ctx context.Context, ownerId, todoId, tagId int) error {
+	todo, ok := r.todos.todos[todoId]
+	if !ok || !hasTag(todo, tagId) {
+		return repos.ErrTagNotFound
+	}
+	todo.Tags = slices.DeleteFunc(slices.Clone(todo.Tags), func(t models.TagModel) bool { return t.Id == tagId })
+	todo.Version++
+	r.todos.todos[todoId] = todo
+	return nil
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/cherrycutter/todo_app/internal/repos"
+)
+
+// GetSubtasks returns the direct subtasks of a todo in their order
+func (s *TodoServiceImpl) GetSubtasks(ctx context.Context, id int) ([]models.TodoModel, error) {
+	userId, err := currentUserId(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = s.repo.GetTodoById(ctx, userId, id); err != nil {
+		return nil, err
+	}
+	return s.repo.GetSubtasks(ctx, userId, id)
+}
+
+// CreateSubtask creates a todo nested under the todo id, after its last subtask
+func (s *TodoServiceImpl) CreateSubtask(ctx context.Context, id int, todo models.TodoModel) (models.TodoModel, error) {
+	if err := s.validateTodoInput(todo); err != nil {
+		return todo, err
+	}
+	var err error
+	if todo.Recurrence, err = validateRecurrence(todo); err != nil {
+		return todo, err
+	}
+	userId, err := currentUserId(ctx)
+	if err != nil {
+		return todo, err
+	}
+	if _, err = s.repo.GetTodoById(ctx, userId, id); err != nil {
+		return models.TodoModel{}, err
+	}
+	todo.ParentId = &id
+	var createdTodo models.TodoModel
+	err = s.audited(ctx, userId, models.AuditCreate, func(repo repos.TodoRepository) (*models.TodoModel, *models.TodoModel, error) {
+		var err error
+		createdTodo, err = repo.CreateTodo(ctx, userId, todo)
+		return nil, &createdTodo, err
+	})
+	if err != nil {
+		return models.TodoModel{}, err
+	}
+	return createdTodo, nil
+}
+
+// ReorderSubtasks puts the subtasks of a todo in the order of ids, which must list every subtask once
+func (s *TodoServiceImpl) ReorderSubtasks(ctx context.Context, id int, ids []int) ([]models.TodoModel, error) {
+	userId, err := currentUserId(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var subtasks []models.TodoModel
+	err = s.auditedChanges(ctx, userId, func(repo repos.TodoRepository) ([]todoChange, error) {
+		if _, err := repo.LockTodo(ctx, userId, id); err != nil {
+			return nil, err
+		}
+		before, err := repo.LockSubtree(ctx, userId, id)
+		if err != nil {
+			return nil, err
+		}
+		if err = repo.ReorderSubtasks(ctx, userId, id, ids); err != nil {
+			return nil, err
+		}
+		after, err := repo.LockSubtree(ctx, userId, id)
+		if err != nil {
+			return nil, err
+		}
+		if subtasks, err = repo.GetSubtasks(ctx, userId, id); err != nil {
+			return nil, err
+		}
+		return writtenChanges(models.AuditUpdate, before, after), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return subtasks, nil
+}
+
+// ToggleSubtask flips the completed flag of a direct subtask of the todo id
+func (s *TodoServiceImpl) ToggleSubtask(ctx context.Context, id, subtaskId int) (models.TodoModel, error) {
+	userId, err := currentUserId(ctx)
+	if err != nil {
+		return models.TodoModel{}, err
+	}
+	var toggled models.TodoModel
+	err = s.audited(ctx, userId, models.AuditUpdate, func(repo repos.TodoRepository) (*models.TodoModel, *models.TodoModel, error) {
+		current, err := repo.LockTodo(ctx, userId, subtaskId)
+		if err != nil {
+			return nil, nil, err
+		}
+		toggled, err = repo.ToggleSubtask(ctx, userId, id, subtaskId)
+		return &current, &toggled, err
+	})
+	if err != nil {
+		return models.TodoModel{}, err
+	}
+	return toggled, nil
+}
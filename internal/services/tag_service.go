@@ -0,0 +1,140 @@
+package services
+
+import (
+	"context"
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/cherrycutter/todo_app/internal/repos"
+)
+
+type TagServiceImpl struct {
+	repo repos.TagRepository
+	// todos runs the writes of tags that change todos in audited transactions
+	todos repos.TodoRepository
+}
+
+func NewTagService(repo repos.TagRepository, todos repos.TodoRepository) TagService {
+	return &TagServiceImpl{repo: repo, todos: todos}
+}
+
+func (s *TagServiceImpl) GetTags(ctx context.Context) ([]models.TagModel, error) {
+	userId, err := currentUserId(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetAllTags(ctx, userId)
+}
+
+func (s *TagServiceImpl) GetTag(ctx context.Context, id int) (models.TagModel, error) {
+	userId, err := currentUserId(ctx)
+	if err != nil {
+		return models.TagModel{}, err
+	}
+	return s.repo.GetTagById(ctx, userId, id)
+}
+
+func (s *TagServiceImpl) CreateTag(ctx context.Context, tag models.TagModel) (models.TagModel, error) {
+	if err := s.validateTagInput(tag); err != nil {
+		return tag, err
+	}
+	userId, err := currentUserId(ctx)
+	if err != nil {
+		return tag, err
+	}
+	return s.repo.CreateTag(ctx, userId, tag)
+}
+
+func (s *TagServiceImpl) UpdateTag(ctx context.Context, id int, tag models.TagModel) (models.TagModel, error) {
+	if err := s.validateTagInput(tag); err != nil {
+		return tag, err
+	}
+	userId, err := currentUserId(ctx)
+	if err != nil {
+		return tag, err
+	}
+	var updatedTag models.TagModel
+	err = s.taggedTodosChange(ctx, userId, id, func(tags repos.TagRepository) error {
+		updatedTag, err = tags.UpdateTag(ctx, userId, id, tag)
+		return err
+	})
+	if err != nil {
+		return models.TagModel{}, err
+	}
+	return updatedTag, nil
+}
+
+func (s *TagServiceImpl) DeleteTag(ctx context.Context, id int) error {
+	userId, err := currentUserId(ctx)
+	if err != nil {
+		return err
+	}
+	return s.taggedTodosChange(ctx, userId, id, func(tags repos.TagRepository) error {
+		return tags.DeleteTagById(ctx, userId, id)
+	})
+}
+
+func (s *TagServiceImpl) AttachTag(ctx context.Context, todoId, tagId int) error {
+	userId, err := currentUserId(ctx)
+	if err != nil {
+		return err
+	}
+	return s.todoChange(ctx, userId, todoId, func(tags repos.TagRepository) error {
+		return tags.AttachTag(ctx, userId, todoId, tagId)
+	})
+}
+
+func (s *TagServiceImpl) DetachTag(ctx context.Context, todoId, tagId int) error {
+	userId, err := currentUserId(ctx)
+	if err != nil {
+		return err
+	}
+	return s.todoChange(ctx, userId, todoId, func(tags repos.TagRepository) error {
+		return tags.DetachTag(ctx, userId, todoId, tagId)
+	})
+}
+
+// taggedTodosChange runs a write of a tag in a transaction that audits the todos carrying the tag
+func (s *TagServiceImpl) taggedTodosChange(ctx context.Context, userId, tagId int, write func(tags repos.TagRepository) error) error {
+	return inAuditedTx(ctx, s.todos, userId, func(repo repos.TodoRepository) ([]todoChange, error) {
+		before, err := repo.LockTaggedTodos(ctx, userId, tagId)
+		if err != nil {
+			return nil, err
+		}
+		return writeTouched(ctx, repo, userId, before, write)
+	})
+}
+
+// todoChange runs a write of the tags of a todo in a transaction that audits the todo
+func (s *TagServiceImpl) todoChange(ctx context.Context, userId, todoId int, write func(tags repos.TagRepository) error) error {
+	return inAuditedTx(ctx, s.todos, userId, func(repo repos.TodoRepository) ([]todoChange, error) {
+		before, err := repo.LockTodos(ctx, userId, []int{todoId})
+		if err != nil {
+			return nil, err
+		}
+		return writeTouched(ctx, repo, userId, before, write)
+	})
+}
+
+// writeTouched runs write with the tags of the transaction of repo and returns the changes of the todos before
+func writeTouched(ctx context.Context, repo repos.TodoRepository, userId int, before []models.TodoModel, write func(tags repos.TagRepository) error) ([]todoChange, error) {
+	if err := write(repo.Tags()); err != nil {
+		return nil, err
+	}
+	after, err := repo.LockTodos(ctx, userId, todoIds(before))
+	if err != nil {
+		return nil, err
+	}
+	return touchedChanges(before, after), nil
+}
+
+func (s *TagServiceImpl) validateTagInput(tag models.TagModel) error {
+	if tag.Name == "" {
+		return &ValidationError{Message: "field name cannot be empty"}
+	}
+	if len(tag.Name) > 64 {
+		return &ValidationError{Message: "name cannot be longer than 64 characters"}
+	}
+	if tag.Color != "" && !colorPattern.MatchString(tag.Color) {
+		return &ValidationError{Message: "color must be a hex color like #1e90ff"}
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/cherrycutter/todo_app/internal/tracing"
+	"go.opentelemetry.io/otel/trace"
+	"time"
+)
+
+// tracedTodoService makes a span of every call of a TodoService
+type tracedTodoService struct {
+	service TodoService
+	tracer  trace.Tracer
+}
+
+// NewTracedTodoService wraps a service so that each of its calls is a span of tracer, the span reaches the
+// repository through ctx
+func NewTracedTodoService(service TodoService, tracer trace.Tracer) TodoService {
+	return &tracedTodoService{service: service, tracer: tracer}
+}
+
+func (s *tracedTodoService) start(ctx context.Context, method string) (context.Context, trace.Span) {
+	return s.tracer.Start(ctx, "TodoService."+method)
+}
+
+func (s *tracedTodoService) GetTodos(ctx context.Context, filter models.TodoFilter, params models.ListParams) (result models.TodoPage, err error) {
+	ctx, span := s.start(ctx, "GetTodos")
+	defer func() { tracing.End(span, err) }()
+	return s.service.GetTodos(ctx, filter, params)
+}
+
+func (s *tracedTodoService) GetTodo(ctx context.Context, id int) (result models.TodoModel, err error) {
+	ctx, span := s.start(ctx, "GetTodo")
+	defer func() { tracing.End(span, err) }()
+	return s.service.GetTodo(ctx, id)
+}
+
+func (s *tracedTodoService) CreateTodo(ctx context.Context, todo models.TodoModel) (result models.TodoModel, err error) {
+	ctx, span := s.start(ctx, "CreateTodo")
+	defer func() { tracing.End(span, err) }()
+	return s.service.CreateTodo(ctx, todo)
+}
+
+func (s *tracedTodoService) UpdateTodo(ctx context.Context, id int, todo models.TodoModel, opts models.TodoUpdateOptions) (result models.TodoModel, err error) {
+	ctx, span := s.start(ctx, "UpdateTodo")
+	defer func() { tracing.End(span, err) }()
+	return s.service.UpdateTodo(ctx, id, todo, opts)
+}
+
+func (s *tracedTodoService) PatchTodo(ctx context.Context, id int, patch models.TodoPatch, opts models.TodoUpdateOptions) (result models.TodoModel, err error) {
+	ctx, span := s.start(ctx, "PatchTodo")
+	defer func() { tracing.End(span, err) }()
+	return s.service.PatchTodo(ctx, id, patch, opts)
+}
+
+func (s *tracedTodoService) DeleteTodo(ctx context.Context, id int, ifMatch *int) (err error) {
+	ctx, span := s.start(ctx, "DeleteTodo")
+	defer func() { tracing.End(span, err) }()
+	return s.service.DeleteTodo(ctx, id, ifMatch)
+}
+
+func (s *tracedTodoService) BulkTodos(ctx context.Context, req models.TodoBulkRequest) (result models.TodoBulkResponse, err error) {
+	ctx, span := s.start(ctx, "BulkTodos")
+	defer func() { tracing.End(span, err) }()
+	return s.service.BulkTodos(ctx, req)
+}
+
+func (s *tracedTodoService) SearchTodos(ctx context.Context, search models.TodoSearch, limit int) (result models.TodoSearchResult, err error) {
+	ctx, span := s.start(ctx, "SearchTodos")
+	defer func() { tracing.End(span, err) }()
+	return s.service.SearchTodos(ctx, search, limit)
+}
+
+func (s *tracedTodoService) GetSubtasks(ctx context.Context, id int) (result []models.TodoModel, err error) {
+	ctx, span := s.start(ctx, "GetSubtasks")
+	defer func() { tracing.End(span, err) }()
+	return s.service.GetSubtasks(ctx, id)
+}
+
+func (s *tracedTodoService) CreateSubtask(ctx context.Context, id int, todo models.TodoModel) (result models.TodoModel, err error) {
+	ctx, span := s.start(ctx, "CreateSubtask")
+	defer func() { tracing.End(span, err) }()
+	return s.service.CreateSubtask(ctx, id, todo)
+}
+
+func (s *tracedTodoService) ReorderSubtasks(ctx context.Context, id int, ids []int) (result []models.TodoModel, err error) {
+	ctx, span := s.start(ctx, "ReorderSubtasks")
+	defer func() { tracing.End(span, err) }()
+	return s.service.ReorderSubtasks(ctx, id, ids)
+}
+
+func (s *tracedTodoService) ToggleSubtask(ctx context.Context, id, subtaskId int) (result models.TodoModel, err error) {
+	ctx, span := s.start(ctx, "ToggleSubtask")
+	defer func() { tracing.End(span, err) }()
+	return s.service.ToggleSubtask(ctx, id, subtaskId)
+}
+
+func (s *tracedTodoService) GetOccurrences(ctx context.Context, id, limit int) (result models.TodoOccurrences, err error) {
+	ctx, span := s.start(ctx, "GetOccurrences")
+	defer func() { tracing.End(span, err) }()
+	return s.service.GetOccurrences(ctx, id, limit)
+}
+
+func (s *tracedTodoService) RestoreTodo(ctx context.Context, id int) (result models.TodoModel, err error) {
+	ctx, span := s.start(ctx, "RestoreTodo")
+	defer func() { tracing.End(span, err) }()
+	return s.service.RestoreTodo(ctx, id)
+}
+
+func (s *tracedTodoService) DeleteTrashedTodo(ctx context.Context, id int) (err error) {
+	ctx, span := s.start(ctx, "DeleteTrashedTodo")
+	defer func() { tracing.End(span, err) }()
+	return s.service.DeleteTrashedTodo(ctx, id)
+}
+
+func (s *tracedTodoService) PurgeTrash(ctx context.Context, retention time.Duration) (result int64, err error) {
+	ctx, span := s.start(ctx, "PurgeTrash")
+	defer func() { tracing.End(span, err) }()
+	return s.service.PurgeTrash(ctx, retention)
+}
+
+func (s *tracedTodoService) GetTodoHistory(ctx context.Context, id int, params models.ListParams) (result models.AuditPage, err error) {
+	ctx, span := s.start(ctx, "GetTodoHistory")
+	defer func() { tracing.End(span, err) }()
+	return s.service.GetTodoHistory(ctx, id, params)
+}
+
+func (s *tracedTodoService) GetAuditLog(ctx context.Context, filter models.AuditFilter, params models.ListParams) (result models.AuditPage, err error) {
+	ctx, span := s.start(ctx, "GetAuditLog")
+	defer func() { tracing.End(span, err) }()
+	return s.service.GetAuditLog(ctx, filter, params)
+}
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"context"
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/cherrycutter/todo_app/internal/repos"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"testing"
+)
+
+// spanTodoService remembers the span of the context its calls receive
+type spanTodoService struct {
+	TodoService
+	span trace.SpanContext
+}
+
+func (s *spanTodoService) GetTodo(ctx context.Context, id int) (models.TodoModel, error) {
+	s.span = trace.SpanContextFromContext(ctx)
+	if id == 404 {
+		return models.TodoModel{}, repos.ErrTodoNotFound
+	}
+	return models.TodoModel{Id: id}, nil
+}
+
+func TestTracedTodoService(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	inner := &spanTodoService{}
+	s := NewTracedTodoService(inner, provider.Tracer("test"))
+
+	ctx, request := provider.Tracer("test").Start(context.Background(), "request")
+	todo, err := s.GetTodo(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, todo.Id)
+	_, err = s.GetTodo(ctx, 404)
+	assert.ErrorIs(t, err, repos.ErrTodoNotFound)
+	request.End()
+
+	spans := exporter.GetSpans()
+	if !assert.Len(t, spans, 3) {
+		return
+	}
+	found, notFound := spans[0], spans[1]
+	assert.Equal(t, "TodoService.GetTodo", found.Name)
+	assert.Equal(t, request.SpanContext().SpanID(), found.Parent.SpanID())
+	assert.Equal(t, codes.Unset, found.Status.Code)
+	assert.Equal(t, codes.Error, notFound.Status.Code)
+	// the service is called with its span, so that the spans of the repository are its children
+	assert.Equal(t, notFound.SpanContext.SpanID(), inner.span.SpanID())
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"context"
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/cherrycutter/todo_app/internal/repos"
+	"time"
+)
+
+// RestoreTodo takes a todo out of the trash together with the subtasks trashed along with it
+func (s *TodoServiceImpl) RestoreTodo(ctx context.Context, id int) (models.TodoModel, error) {
+	userId, err := currentUserId(ctx)
+	if err != nil {
+		return models.TodoModel{}, err
+	}
+	var restored models.TodoModel
+	err = s.auditedChanges(ctx, userId, func(repo repos.TodoRepository) ([]todoChange, error) {
+		trashed, err := repo.LockSubtree(ctx, userId, id)
+		if err != nil {
+			return nil, err
+		}
+		if err = repo.RestoreTodo(ctx, userId, id); err != nil {
+			return nil, err
+		}
+		subtree, err := repo.LockSubtree(ctx, userId, id)
+		if err != nil {
+			return nil, err
+		}
+		for _, todo := range subtree {
+			if todo.Id == id {
+				restored = todo
+			}
+		}
+		return writtenChanges(models.AuditRestore, trashed, subtree), nil
+	})
+	if err != nil {
+		return models.TodoModel{}, err
+	}
+	return restored, nil
+}
+
+// DeleteTrashedTodo permanently deletes a todo that is in the trash together with its subtasks
+func (s *TodoServiceImpl) DeleteTrashedTodo(ctx context.Context, id int) error {
+	userId, err := currentUserId(ctx)
+	if err != nil {
+		return err
+	}
+	return s.auditedChanges(ctx, userId, func(repo repos.TodoRepository) ([]todoChange, error) {
+		subtree, err := repo.LockSubtree(ctx, userId, id)
+		if err != nil {
+			return nil, err
+		}
+		if err = repo.DeleteTrashedTodo(ctx, userId, id); err != nil {
+			return nil, err
+		}
+		return writtenChanges(models.AuditPurge, subtree, nil), nil
+	})
+}
+
+// PurgeTrash permanently deletes the todos of every user that stayed in the trash longer than retention
+func (s *TodoServiceImpl) PurgeTrash(ctx context.Context, retention time.Duration) (int64, error) {
+	if retention <= 0 {
+		return 0, &ValidationError{Message: "trash retention must be positive"}
+	}
+	return s.repo.PurgeTrash(ctx, time.Now().Add(-retention))
+}
@@ -0,0 +1,15 @@
+package services
+
+import "regexp"
+
+// colorPattern is the format of the colors of tags and projects
+var colorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// ValidationError is returned when an input does not pass the service validation rules
+type ValidationError struct {
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
@@ -0,0 +1,351 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/cherrycutter/todo_app/internal/auth"
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/cherrycutter/todo_app/internal/repos"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	// webhookSecretPrefix marks the secrets generated for webhooks
+	webhookSecretPrefix = "whsec_"
+	// minWebhookSecretLength is the length a secret chosen by the user needs at least
+	minWebhookSecretLength = 16
+	// webhookBatchSize is how many deliveries are claimed and posted at once
+	webhookBatchSize = 20
+	// webhookLease is how long a claimed delivery is left to its dispatcher before another one retries it
+	webhookLease = 5 * time.Minute
+	// webhookMaxAttempts is how many times a delivery is posted before it fails
+	webhookMaxAttempts = 8
+	// webhookUnhealthyAfter is how many consecutive failed attempts turn a webhook unhealthy
+	webhookUnhealthyAfter = 5
+	// webhookBaseBackoff is the wait before the first retry, it doubles with every attempt up to webhookMaxBackoff
+	webhookBaseBackoff = 30 * time.Second
+	webhookMaxBackoff  = time.Hour
+	// maxAttemptErrorLength bounds the error stored with an attempt
+	maxAttemptErrorLength = 255
+)
+
+// Headers of a webhook post. The signature is the hex HMAC-SHA256 of "<timestamp>.<body>" keyed with
+// the secret of the webhook
+const (
+	WebhookIdHeader        = "X-Webhook-Id"
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookSignatureHeader = "X-Webhook-Signature"
+)
+
+// errWebhookAddress refuses a connection to an address of the server's own network
+var errWebhookAddress = errors.New("webhook address is not public")
+
+type WebhookServiceImpl struct {
+	repo   repos.WebhookRepository
+	client *http.Client
+}
+
+func NewWebhookService(repo repos.WebhookRepository, client *http.Client) WebhookService {
+	return &WebhookServiceImpl{repo: repo, client: client}
+}
+
+// NewWebhookClient returns the client deliveries are posted with. It only connects to public addresses, whatever
+// the url of a webhook resolves to, and does not follow redirects, so a webhook cannot reach the internal network
+func NewWebhookClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout, Control: checkWebhookAddress}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		// the redirect is answered to the dispatcher, it is not a 2xx so the attempt fails
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// checkWebhookAddress is the dial control of the webhook client, it runs with the resolved address of a connection
+func checkWebhookAddress(network, address string, c syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	if !publicAddr(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", errWebhookAddress, addrPort.Addr())
+	}
+	return nil
+}
+
+// nonPublicPrefixes are the special purpose IPv4 ranges the methods of netip.Addr do not report: "this network",
+// the shared address space of carrier-grade NAT (where some clouds serve their metadata), the IETF protocol
+// assignments and the benchmarking networks
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+}
+
+var (
+	// nat64Prefix is the well-known prefix of NAT64, the IPv4 address is in the last 4 bytes
+	nat64Prefix = netip.MustParsePrefix("64:ff9b::/96")
+	// sixToFourPrefix is the prefix of 6to4, the IPv4 address follows it
+	sixToFourPrefix = netip.MustParsePrefix("2002::/16")
+)
+
+// publicAddr reports whether an address is not a loopback, private, link-local, unspecified or other special
+// purpose one. IPv6 addresses embedding an IPv4 address are public when the IPv4 address is
+func publicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsUnspecified() {
+		return false
+	}
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	if embedded, ok := embeddedIPv4(addr); ok {
+		return publicAddr(embedded)
+	}
+	return true
+}
+
+// embeddedIPv4 returns the IPv4 address carried by a NAT64 or 6to4 address
+func embeddedIPv4(addr netip.Addr) (netip.Addr, bool) {
+	b := addr.As16()
+	switch {
+	case nat64Prefix.Contains(addr):
+		return netip.AddrFrom4([4]byte(b[12:16])), true
+	case sixToFourPrefix.Contains(addr):
+		return netip.AddrFrom4([4]byte(b[2:6])), true
+	}
+	return netip.Addr{}, false
+}
+
+func (s *WebhookServiceImpl) GetWebhooks(ctx context.Context) ([]models.WebhookModel, error) {
+	userId, err := currentUserId(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetAllWebhooks(ctx, userId)
+}
+
+func (s *WebhookServiceImpl) GetWebhook(ctx context.Context, id int) (models.WebhookModel, error) {
+	userId, err := currentUserId(ctx)
+	if err != nil {
+		return models.WebhookModel{}, err
+	}
+	return s.repo.GetWebhookById(ctx, userId, id)
+}
+
+// CreateWebhook creates a webhook, a secret is generated when none is given. The secret is only returned here
+func (s *WebhookServiceImpl) CreateWebhook(ctx context.Context, webhook models.WebhookModel) (models.WebhookModel, error) {
+	if err := validateWebhookInput(webhook); err != nil {
+		return models.WebhookModel{}, err
+	}
+	userId, err := currentUserId(ctx)
+	if err != nil {
+		return models.WebhookModel{}, err
+	}
+	if webhook.Secret == "" {
+		token, err := auth.NewRefreshToken()
+		if err != nil {
+			return models.WebhookModel{}, err
+		}
+		webhook.Secret = webhookSecretPrefix + token
+	}
+	createdWebhook, err := s.repo.CreateWebhook(ctx, userId, webhook)
+	if err != nil {
+		return models.WebhookModel{}, err
+	}
+	createdWebhook.Secret = webhook.Secret
+	return createdWebhook, nil
+}
+
+// UpdateWebhook replaces the url and event types of a webhook, and its secret when one is given. An unhealthy
+// webhook becomes healthy again
+func (s *WebhookServiceImpl) UpdateWebhook(ctx context.Context, id int, webhook models.WebhookModel) (models.WebhookModel, error) {
+	if err := validateWebhookInput(webhook); err != nil {
+		return models.WebhookModel{}, err
+	}
+	userId, err := currentUserId(ctx)
+	if err != nil {
+		return models.WebhookModel{}, err
+	}
+	return s.repo.UpdateWebhook(ctx, userId, id, webhook)
+}
+
+func (s *WebhookServiceImpl) DeleteWebhook(ctx context.Context, id int) error {
+	userId, err := currentUserId(ctx)
+	if err != nil {
+		return err
+	}
+	return s.repo.DeleteWebhookById(ctx, userId, id)
+}
+
+// GetWebhookDeliveries returns the latest deliveries of a webhook newest first
+func (s *WebhookServiceImpl) GetWebhookDeliveries(ctx context.Context, id, limit int) ([]models.WebhookDelivery, error) {
+	userId, err := currentUserId(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = s.repo.GetWebhookById(ctx, userId, id); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = models.DefaultPageLimit
+	}
+	return s.repo.GetWebhookDeliveries(ctx, userId, id, limit)
+}
+
+// DeliverWebhooks posts a batch of due deliveries and records their attempts, it returns how many were posted
+func (s *WebhookServiceImpl) DeliverWebhooks(ctx context.Context) (int, error) {
+	now := time.Now()
+	jobs, err := s.repo.ClaimWebhookDeliveries(ctx, now, now.Add(webhookLease), webhookBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	// the posts run side by side so that a slow endpoint does not hold up the others
+	attempts := make([]models.WebhookAttempt, len(jobs))
+	var wg sync.WaitGroup
+	for i, job := range jobs {
+		wg.Add(1)
+		go func(i int, job models.WebhookJob) {
+			defer wg.Done()
+			attempts[i] = s.post(ctx, job)
+		}(i, job)
+	}
+	wg.Wait()
+	for i, job := range jobs {
+		err = s.repo.RecordWebhookAttempt(ctx, job, attempts[i], nextWebhookAttempt(job, attempts[i]), webhookUnhealthyAfter)
+		if err != nil {
+			return i, err
+		}
+	}
+	return len(jobs), nil
+}
+
+// post sends a delivery to its webhook and describes the attempt, any response other than 2xx is a failure
+func (s *WebhookServiceImpl) post(ctx context.Context, job models.WebhookJob) models.WebhookAttempt {
+	start := time.Now()
+	statusCode, err := s.send(ctx, job, start)
+	attempt := models.WebhookAttempt{StatusCode: statusCode, DurationMs: time.Since(start).Milliseconds(), AttemptedAt: start}
+	if err != nil {
+		attempt.Error = err.Error()
+		if len(attempt.Error) > maxAttemptErrorLength {
+			attempt.Error = attempt.Error[:maxAttemptErrorLength]
+		}
+	}
+	return attempt
+}
+
+// send posts the signed payload of a delivery and returns the status code of the response, if any
+func (s *WebhookServiceImpl) send(ctx context.Context, job models.WebhookJob, now time.Time) (*int, error) {
+	body, err := json.Marshal(models.WebhookPayload{Id: job.DeliveryId, Type: job.EventType, CreatedAt: job.CreatedAt, Data: job.Payload})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, job.Url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookIdHeader, strconv.FormatInt(job.DeliveryId, 10))
+	req.Header.Set(WebhookEventHeader, job.EventType)
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(job.Secret, timestamp, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	// draining a little of the body lets the connection be reused
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return &resp.StatusCode, nil
+}
+
+// SignWebhookPayload returns the signature header of a webhook body sent at timestamp
+func SignWebhookPayload(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// nextWebhookAttempt returns when a failed delivery is retried, nil when it succeeded or ran out of attempts
+func nextWebhookAttempt(job models.WebhookJob, attempt models.WebhookAttempt) *time.Time {
+	attempts := job.Attempts + 1
+	if attempt.Error == "" || attempts >= webhookMaxAttempts {
+		return nil
+	}
+	retryAt := attempt.AttemptedAt.Add(webhookBackoff(attempts))
+	return &retryAt
+}
+
+// webhookBackoff is the wait after the given number of failed attempts
+func webhookBackoff(attempts int) time.Duration {
+	backoff := webhookBaseBackoff
+	for i := 1; i < attempts && backoff < webhookMaxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, webhookMaxBackoff)
+}
+
+// newWebhookDelivery describes a todo event for the webhooks subscribed to its type
+func newWebhookDelivery(event models.TodoEvent) (models.WebhookDelivery, error) {
+	payload, err := json.Marshal(models.WebhookTodoData{TodoId: event.TodoId, Todo: event.Todo})
+	if err != nil {
+		return models.WebhookDelivery{}, err
+	}
+	return models.WebhookDelivery{EventType: "todo." + string(event.Type), Payload: payload}, nil
+}
+
+func validateWebhookInput(webhook models.WebhookModel) error {
+	u, err := url.Parse(webhook.Url)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return &ValidationError{Message: "field url must be an absolute http or https url"}
+	}
+	// names are checked again when they are dialed, they may resolve to another address by then
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if addr, err := netip.ParseAddr(host); (err == nil && !publicAddr(addr)) || host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return &ValidationError{Message: "field url must point to a public address"}
+	}
+	if webhook.Secret != "" && len(webhook.Secret) < minWebhookSecretLength {
+		return &ValidationError{Message: fmt.Sprintf("secret must be at least %d characters long", minWebhookSecretLength)}
+	}
+	if len(webhook.EventTypes) == 0 {
+		return &ValidationError{Message: "field event_types cannot be empty"}
+	}
+	for _, eventType := range webhook.EventTypes {
+		if !slices.Contains(models.WebhookEventTypes, eventType) {
+			return &ValidationError{Message: "unknown event type " + eventType + ", expected one of " + strings.Join(models.WebhookEventTypes, ", ")}
+		}
+	}
+	return nil
+}
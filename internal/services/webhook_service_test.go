@@ -0,0 +1,197 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/cherrycutter/todo_app/internal/auth"
+	"github.com/cherrycutter/todo_app/internal/models"
+	"github.com/cherrycutter/todo_app/internal/repos"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// fakeWebhookRepo hands out its jobs once and keeps the attempts recorded for them
+type fakeWebhookRepo struct {
+	repos.WebhookRepository
+	jobs     []models.WebhookJob
+	attempts []recordedAttempt
+}
+
+type recordedAttempt struct {
+	job     models.WebhookJob
+	attempt models.WebhookAttempt
+	retryAt *time.Time
+}
+
+func (r *fakeWebhookRepo) ClaimWebhookDeliveries(ctx context.Context, now, leaseUntil time.Time, limit int) ([]models.WebhookJob, error) {
+	jobs := r.jobs
+	r.jobs = nil
+	return jobs, nil
+}
+
+func (r *fakeWebhookRepo) RecordWebhookAttempt(ctx context.Context, job models.WebhookJob, attempt models.WebhookAttempt, retryAt *time.Time, unhealthyAfter int) error {
+	r.attempts = append(r.attempts, recordedAttempt{job: job, attempt: attempt, retryAt: retryAt})
+	return nil
+}
+
+func TestDeliverWebhooks(t *testing.T) {
+	created := time.Date(2024, 6, 1, 8, 0, 0, 0, time.UTC)
+	data := json.RawMessage(`{"todo_id":1,"todo":null}`)
+
+	tests := []struct {
+		name       string
+		status     int
+		attempts   int
+		wantError  string
+		wantRetry  bool
+		wantStatus int
+	}{
+		{name: "Delivered", status: http.StatusNoContent, wantStatus: http.StatusNoContent},
+		{name: "Retried", status: http.StatusInternalServerError, attempts: 2, wantError: "unexpected status 500", wantRetry: true, wantStatus: http.StatusInternalServerError},
+		{name: "Failed", status: http.StatusBadGateway, attempts: webhookMaxAttempts - 1, wantError: "unexpected status 502", wantStatus: http.StatusBadGateway},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var received *http.Request
+			var body []byte
+			receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				received = r
+				body, _ = io.ReadAll(r.Body)
+				w.WriteHeader(tt.status)
+			}))
+			defer receiver.Close()
+
+			job := models.WebhookJob{
+				DeliveryId: 42, WebhookId: 1, Url: receiver.URL, Secret: "whsec_test-secret",
+				EventType: models.WebhookTodoUpdated, Payload: data, Attempts: tt.attempts, CreatedAt: created,
+			}
+			repo := &fakeWebhookRepo{jobs: []models.WebhookJob{job}}
+			s := NewWebhookService(repo, receiver.Client())
+
+			delivered, err := s.DeliverWebhooks(context.Background())
+			assert.NoError(t, err)
+			assert.Equal(t, 1, delivered)
+
+			var payload models.WebhookPayload
+			assert.NoError(t, json.Unmarshal(body, &payload))
+			assert.Equal(t, models.WebhookPayload{Id: 42, Type: models.WebhookTodoUpdated, CreatedAt: created, Data: data}, payload)
+			assert.Equal(t, "42", received.Header.Get(WebhookIdHeader))
+			assert.Equal(t, models.WebhookTodoUpdated, received.Header.Get(WebhookEventHeader))
+			timestamp := received.Header.Get(WebhookTimestampHeader)
+			assert.Equal(t, SignWebhookPayload(job.Secret, timestamp, body), received.Header.Get(WebhookSignatureHeader))
+
+			if assert.Len(t, repo.attempts, 1) {
+				recorded := repo.attempts[0]
+				assert.Equal(t, job, recorded.job)
+				assert.Equal(t, tt.wantError, recorded.attempt.Error)
+				if assert.NotNil(t, recorded.attempt.StatusCode) {
+					assert.Equal(t, tt.wantStatus, *recorded.attempt.StatusCode)
+				}
+				assert.Equal(t, tt.wantRetry, recorded.retryAt != nil)
+			}
+		})
+	}
+}
+
+func TestDeliverWebhooksUnreachable(t *testing.T) {
+	receiver := httptest.NewServer(http.NotFoundHandler())
+	url := receiver.URL
+	receiver.Close()
+
+	repo := &fakeWebhookRepo{jobs: []models.WebhookJob{{DeliveryId: 1, WebhookId: 1, Url: url, EventType: models.WebhookTodoCreated}}}
+	s := NewWebhookService(repo, http.DefaultClient)
+
+	_, err := s.DeliverWebhooks(context.Background())
+	assert.NoError(t, err)
+	if assert.Len(t, repo.attempts, 1) {
+		assert.Nil(t, repo.attempts[0].attempt.StatusCode)
+		assert.NotEmpty(t, repo.attempts[0].attempt.Error)
+		assert.NotNil(t, repo.attempts[0].retryAt)
+	}
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	assert.Equal(t, 30*time.Second, webhookBackoff(1))
+	assert.Equal(t, time.Minute, webhookBackoff(2))
+	assert.Equal(t, 4*time.Minute, webhookBackoff(4))
+	assert.Equal(t, webhookMaxBackoff, webhookBackoff(20))
+}
+
+func TestProjectAndTagDeliveries(t *testing.T) {
+	projectId := 3
+	backend := models.TagModel{Id: 5, Name: "backend"}
+	repo := newFakeTodoRepo(
+		models.TodoModel{Id: 1, Title: "Move out", ProjectId: &projectId, Version: 1},
+		models.TodoModel{Id: 2, Title: "Add the audit log", Tags: []models.TagModel{backend}, Version: 1},
+	)
+	ctx := auth.WithUserId(context.Background(), testUserId)
+
+	assert.NoError(t, NewProjectService(repo.Projects(), repo).DeleteProject(ctx, projectId, models.ProjectDeleteMoveToInbox))
+	assert.NoError(t, NewTagService(repo.Tags(), repo).DeleteTag(ctx, backend.Id))
+	if !assert.Len(t, repo.deliveries, 2) {
+		return
+	}
+	for i, todoId := range []int{1, 2} {
+		assert.Equal(t, models.WebhookTodoUpdated, repo.deliveries[i].EventType)
+		var data models.WebhookTodoData
+		assert.NoError(t, json.Unmarshal(repo.deliveries[i].Payload, &data))
+		assert.Equal(t, todoId, data.TodoId)
+	}
+}
+
+func TestValidateWebhookInput(t *testing.T) {
+	valid := models.WebhookModel{Url: "https://example.com/hook", EventTypes: []string{models.WebhookTodoCreated}}
+	assert.NoError(t, validateWebhookInput(valid))
+
+	for name, webhook := range map[string]models.WebhookModel{
+		"Relative url":       {Url: "/hook", EventTypes: valid.EventTypes},
+		"Unsupported scheme": {Url: "ftp://example.com/hook", EventTypes: valid.EventTypes},
+		"Short secret":       {Url: valid.Url, Secret: "short", EventTypes: valid.EventTypes},
+		"No event types":     {Url: valid.Url},
+		"Unknown event type": {Url: valid.Url, EventTypes: []string{"todo.archived"}},
+		"Loopback":           {Url: "http://127.0.0.1:8080/hook", EventTypes: valid.EventTypes},
+		"Loopback IPv6":      {Url: "http://[::1]/hook", EventTypes: valid.EventTypes},
+		"Localhost":          {Url: "http://localhost/hook", EventTypes: valid.EventTypes},
+		"Private":            {Url: "https://10.0.0.5/hook", EventTypes: valid.EventTypes},
+		"Mapped private":     {Url: "https://[::ffff:192.168.1.1]/hook", EventTypes: valid.EventTypes},
+		"Link-local":         {Url: "http://169.254.169.254/latest/meta-data", EventTypes: valid.EventTypes},
+		"Unspecified":        {Url: "http://0.0.0.0/hook", EventTypes: valid.EventTypes},
+		"This network":       {Url: "http://0.1.2.3/hook", EventTypes: valid.EventTypes},
+		"Shared address":     {Url: "http://100.100.100.200/latest/meta-data", EventTypes: valid.EventTypes},
+		"Protocol assigned":  {Url: "http://192.0.0.170/hook", EventTypes: valid.EventTypes},
+		"Benchmarking":       {Url: "http://198.18.0.1/hook", EventTypes: valid.EventTypes},
+		"NAT64 loopback":     {Url: "http://[64:ff9b::7f00:1]/hook", EventTypes: valid.EventTypes},
+		"6to4 private":       {Url: "http://[2002:a00:5::1]/hook", EventTypes: valid.EventTypes},
+	} {
+		var validationErr *ValidationError
+		assert.ErrorAs(t, validateWebhookInput(webhook), &validationErr, name)
+	}
+}
+
+func TestWebhookClient(t *testing.T) {
+	var received int
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received++
+	}))
+	defer receiver.Close()
+	client := NewWebhookClient(time.Second)
+
+	// the receiver listens on loopback, the connection is refused before anything is sent
+	_, err := client.Post(receiver.URL, "application/json", nil)
+	assert.ErrorIs(t, err, errWebhookAddress)
+	assert.Equal(t, 0, received)
+
+	assert.NoError(t, checkWebhookAddress("tcp", "93.184.216.34:443", nil))
+	assert.ErrorIs(t, checkWebhookAddress("tcp", "[fe80::1]:443", nil), errWebhookAddress)
+	assert.ErrorIs(t, checkWebhookAddress("tcp", "[64:ff9b::a9fe:a9fe]:80", nil), errWebhookAddress)
+	// NAT64 and 6to4 addresses of public hosts are public
+	assert.NoError(t, checkWebhookAddress("tcp", "[64:ff9b::5db8:d822]:443", nil))
+	assert.NoError(t, checkWebhookAddress("tcp", "[2002:5db8:d822::1]:443", nil))
+
+	req := httptest.NewRequest(http.MethodPost, "https://example.com/hook", nil)
+	assert.ErrorIs(t, client.CheckRedirect(req, []*http.Request{req}), http.ErrUseLastResponse)
+}
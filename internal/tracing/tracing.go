@@ -0,0 +1,83 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// TracerName is the instrumentation scope of the spans of the API
+	TracerName = "github.com/cherrycutter/todo_app"
+	// serviceName names the API in the exported spans, OTEL_SERVICE_NAME overrides it
+	serviceName = "todo_app"
+)
+
+// NewExporter returns the span exporter named otlp, stdout or memory, nil for none. The otlp exporter posts to
+// endpoint over HTTP, an empty endpoint falls back to OTEL_EXPORTER_OTLP_ENDPOINT and then to localhost:4318
+func NewExporter(ctx context.Context, name, endpoint string) (sdktrace.SpanExporter, error) {
+	switch name {
+	case "none":
+		return nil, nil
+	case "otlp":
+		var opts []otlptracehttp.Option
+		if endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpointURL(endpoint))
+		}
+		return otlptracehttp.New(ctx, opts...)
+	case "stdout":
+		return stdouttrace.New()
+	case "memory":
+		return tracetest.NewInMemoryExporter(), nil
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", name)
+	}
+}
+
+// NewProvider returns a tracer provider exporting its spans to exporter. A share of sampleRatio of the new traces
+// is sampled, traces continued from a traceparent keep the decision of their parent. Without exporter no span is
+// sampled, but trace contexts are still propagated
+func NewProvider(ctx context.Context, exporter sdktrace.SpanExporter, sampleRatio float64) (*sdktrace.TracerProvider, error) {
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(serviceName)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	opts := []sdktrace.TracerProviderOption{sdktrace.WithResource(res)}
+	_, inMemory := exporter.(*tracetest.InMemoryExporter)
+	if exporter == nil {
+		opts = append(opts, sdktrace.WithSampler(sdktrace.NeverSample()))
+	} else if inMemory {
+		// spans kept for tests are exported as they end, so that they can be read right after a request
+		opts = append(opts, sdktrace.WithSyncer(exporter), sdktrace.WithSampler(sdktrace.AlwaysSample()))
+	} else {
+		opts = append(opts, sdktrace.WithBatcher(exporter), sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio))))
+	}
+	return sdktrace.NewTracerProvider(opts...), nil
+}
+
+// NewPropagator returns the propagator of the trace context, the W3C traceparent and tracestate headers,
+// together with the W3C baggage header
+func NewPropagator() propagation.TextMapPropagator {
+	return propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+}
+
+// End ends a span, an error is recorded on the span and sets its status
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,48 @@
+package tracing
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"testing"
+)
+
+func TestNewProvider(t *testing.T) {
+	t.Run("Memory", func(t *testing.T) {
+		exporter, err := NewExporter(context.Background(), "memory", "")
+		if !assert.NoError(t, err) {
+			return
+		}
+		// the ratio is ignored, every span is kept and can be read as soon as it ends
+		provider, err := NewProvider(context.Background(), exporter, 0)
+		if !assert.NoError(t, err) {
+			return
+		}
+		_, span := provider.Tracer(TracerName).Start(context.Background(), "request")
+		span.End()
+		spans := exporter.(*tracetest.InMemoryExporter).GetSpans()
+		if assert.Len(t, spans, 1) {
+			assert.Equal(t, "request", spans[0].Name)
+		}
+	})
+
+	t.Run("None", func(t *testing.T) {
+		exporter, err := NewExporter(context.Background(), "none", "")
+		assert.NoError(t, err)
+		assert.Nil(t, exporter)
+		provider, err := NewProvider(context.Background(), exporter, 1)
+		if !assert.NoError(t, err) {
+			return
+		}
+		// nothing is sampled but the trace context is still created to be propagated
+		_, span := provider.Tracer(TracerName).Start(context.Background(), "request")
+		assert.False(t, span.SpanContext().IsSampled())
+		assert.True(t, span.SpanContext().IsValid())
+		span.End()
+	})
+
+	t.Run("Unknown", func(t *testing.T) {
+		_, err := NewExporter(context.Background(), "jaeger", "")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,47 @@
+package workers
+
+import (
+	"context"
+	"github.com/cherrycutter/todo_app/internal/services"
+	"github.com/cherrycutter/todo_app/pkg/logger"
+	"time"
+)
+
+// EventPurger periodically deletes the todo events older than the retention of the event log
+type EventPurger struct {
+	State
+	service  services.EventService
+	interval time.Duration
+}
+
+func NewEventPurger(service services.EventService, interval time.Duration) *EventPurger {
+	return &EventPurger{service: service, interval: interval}
+}
+
+// Run purges the expired events right away and then every interval until ctx is done
+func (p *EventPurger) Run(ctx context.Context) {
+	p.setRunning(true)
+	defer p.setRunning(false)
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		p.purge(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *EventPurger) purge(ctx context.Context) {
+	purged, err := p.service.PurgeExpiredEvents(ctx)
+	p.report(err)
+	if err != nil {
+		logger.Error.Printf("event purge failed: %s", err)
+		return
+	}
+	if purged > 0 {
+		logger.Info.Printf("purged %d expired events", purged)
+	}
+}
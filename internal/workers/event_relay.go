@@ -0,0 +1,42 @@
+package workers
+
+import (
+	"context"
+	"github.com/cherrycutter/todo_app/internal/services"
+	"github.com/cherrycutter/todo_app/pkg/logger"
+	"time"
+)
+
+// eventRelayRetryInterval is how long the relay waits before it listens again after its connection failed
+const eventRelayRetryInterval = 5 * time.Second
+
+// EventRelay passes the todo events notified by postgres to the subscribers connected to this instance
+type EventRelay struct {
+	State
+	service services.EventService
+}
+
+func NewEventRelay(service services.EventService) *EventRelay {
+	return &EventRelay{service: service}
+}
+
+// Run relays the events until ctx is done, listening again whenever the connection fails
+func (r *EventRelay) Run(ctx context.Context) {
+	r.setRunning(true)
+	defer r.setRunning(false)
+	for {
+		err := r.service.Relay(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		// the failure is reported until the relay listens again
+		r.report(err)
+		logger.Error.Printf("event relay failed: %s", err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(eventRelayRetryInterval):
+		}
+		r.report(nil)
+	}
+}
@@ -0,0 +1,47 @@
+package workers
+
+import (
+	"context"
+	"github.com/cherrycutter/todo_app/internal/services"
+	"github.com/cherrycutter/todo_app/pkg/logger"
+	"time"
+)
+
+// IdempotencyPurger periodically deletes the idempotency keys whose TTL elapsed
+type IdempotencyPurger struct {
+	State
+	service  services.IdempotencyService
+	interval time.Duration
+}
+
+func NewIdempotencyPurger(service services.IdempotencyService, interval time.Duration) *IdempotencyPurger {
+	return &IdempotencyPurger{service: service, interval: interval}
+}
+
+// Run purges the expired keys right away and then every interval until ctx is done
+func (p *IdempotencyPurger) Run(ctx context.Context) {
+	p.setRunning(true)
+	defer p.setRunning(false)
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		p.purge(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *IdempotencyPurger) purge(ctx context.Context) {
+	purged, err := p.service.PurgeExpiredKeys(ctx)
+	p.report(err)
+	if err != nil {
+		logger.Error.Printf("idempotency key purge failed: %s", err)
+		return
+	}
+	if purged > 0 {
+		logger.Info.Printf("purged %d expired idempotency keys", purged)
+	}
+}
@@ -0,0 +1,41 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+var (
+	ErrWorkerNotRunning = errors.New("worker is not running")
+)
+
+// State records whether a worker runs and how its last run went, workers embed it to report their health
+type State struct {
+	mu      sync.Mutex
+	running bool
+	err     error
+}
+
+// Check returns an error when the worker is not running or its last run failed
+func (s *State) Check(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.running {
+		return ErrWorkerNotRunning
+	}
+	return s.err
+}
+
+func (s *State) setRunning(running bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running = running
+}
+
+// report records the outcome of a run, nil for a successful one
+func (s *State) report(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
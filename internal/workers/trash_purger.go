@@ -0,0 +1,48 @@
+package workers
+
+import (
+	"context"
+	"github.com/cherrycutter/todo_app/internal/services"
+	"github.com/cherrycutter/todo_app/pkg/logger"
+	"time"
+)
+
+// TrashPurger periodically deletes the todos that stayed in the trash longer than the retention
+type TrashPurger struct {
+	State
+	service   services.TodoService
+	retention time.Duration
+	interval  time.Duration
+}
+
+func NewTrashPurger(service services.TodoService, retention, interval time.Duration) *TrashPurger {
+	return &TrashPurger{service: service, retention: retention, interval: interval}
+}
+
+// Run purges the trash right away and then every interval until ctx is done
+func (p *TrashPurger) Run(ctx context.Context) {
+	p.setRunning(true)
+	defer p.setRunning(false)
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		p.purge(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *TrashPurger) purge(ctx context.Context) {
+	purged, err := p.service.PurgeTrash(ctx, p.retention)
+	p.report(err)
+	if err != nil {
+		logger.Error.Printf("trash purge failed: %s", err)
+		return
+	}
+	if purged > 0 {
+		logger.Info.Printf("purged %d todos from the trash", purged)
+	}
+}
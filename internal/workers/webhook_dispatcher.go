@@ -0,0 +1,50 @@
+package workers
+
+import (
+	"context"
+	"github.com/cherrycutter/todo_app/internal/services"
+	"github.com/cherrycutter/todo_app/pkg/logger"
+	"time"
+)
+
+// WebhookDispatcher periodically posts the pending webhook deliveries that are due
+type WebhookDispatcher struct {
+	State
+	service  services.WebhookService
+	interval time.Duration
+}
+
+func NewWebhookDispatcher(service services.WebhookService, interval time.Duration) *WebhookDispatcher {
+	return &WebhookDispatcher{service: service, interval: interval}
+}
+
+// Run delivers the due webhooks right away and then every interval until ctx is done
+func (d *WebhookDispatcher) Run(ctx context.Context) {
+	d.setRunning(true)
+	defer d.setRunning(false)
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+	for {
+		d.dispatch(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// dispatch delivers batches until no delivery is due
+func (d *WebhookDispatcher) dispatch(ctx context.Context) {
+	for ctx.Err() == nil {
+		delivered, err := d.service.DeliverWebhooks(ctx)
+		d.report(err)
+		if err != nil {
+			logger.Error.Printf("webhook delivery failed: %s", err)
+			return
+		}
+		if delivered == 0 {
+			return
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/cherrycutter/todo_app/pkg/logger"
 	"github.com/spf13/viper"
 	"sync"
+	"time"
 )
 
 type Config struct {
@@ -13,8 +14,71 @@ type Config struct {
 	DBUser string
 	DBPass string
 	DBName string
+
+	// ServerAddr is the address the API listens on. Requests are read within ServerReadTimeout and answered within
+	// ServerWriteTimeout, idle keep-alive connections are closed after ServerIdleTimeout
+	ServerAddr         string
+	ServerReadTimeout  time.Duration
+	ServerWriteTimeout time.Duration
+	ServerIdleTimeout  time.Duration
+	// ShutdownDelay is how long the API reports it is not ready before it stops accepting connections, it then
+	// has until ShutdownTimeout to finish its requests
+	ShutdownDelay   time.Duration
+	ShutdownTimeout time.Duration
+	// ReadinessTimeout bounds every check of the readiness endpoint
+	ReadinessTimeout time.Duration
+	// MetricsAddr is the admin address /metrics and /stats/db are served on, they are not served when empty.
+	// MetricsTimeout bounds the queries counting the todos on every scrape
+	MetricsAddr    string
+	MetricsTimeout time.Duration
+	// TraceExporter sends the spans of the API to the OTLP collector at TraceEndpoint, writes them to stdout, or
+	// keeps them in memory for tests, none turns tracing off. TraceSampleRatio is the share of new traces sampled
+	TraceExporter    string
+	TraceEndpoint    string
+	TraceSampleRatio float64
+
+	// DBMaxConns and DBMinConns bound the connections of the pool. Connections are closed after being idle
+	// for DBMaxConnIdleTime or open for DBMaxConnLifetime, and checked every DBHealthCheckPeriod
+	DBMaxConns          int32
+	DBMinConns          int32
+	DBMaxConnIdleTime   time.Duration
+	DBMaxConnLifetime   time.Duration
+	DBHealthCheckPeriod time.Duration
+
+	// JWTSigningMethod is HS256 with JWTSecret as key or EdDSA with the ed25519 private key in the PEM file JWTKeyFile
+	JWTSigningMethod string
+	JWTSecret        string
+	JWTKeyFile       string
+	AccessTokenTTL   time.Duration
+	RefreshTokenTTL  time.Duration
+
+	// MaxTodoDepth is the number of levels todos can be nested, 1 disables subtasks
+	MaxTodoDepth int
+
+	// TrashRetention is how long deleted todos stay in the trash, the trash is purged every TrashPurgeInterval
+	TrashRetention     time.Duration
+	TrashPurgeInterval time.Duration
+
+	// IdempotencyStore keeps the Idempotency-Key responses in postgres or in memory, they are replayed
+	// for IdempotencyKeyTTL and the expired keys are purged every IdempotencyPurgeInterval
+	IdempotencyStore         string
+	IdempotencyKeyTTL        time.Duration
+	IdempotencyPurgeInterval time.Duration
+
+	// EventRetention is how long the events of the change feed can be replayed to resuming clients, expired
+	// events are purged every EventPurgeInterval
+	EventRetention     time.Duration
+	EventPurgeInterval time.Duration
+
+	// WebhookPollInterval is how often pending webhook deliveries are looked up, each post times out
+	// after WebhookTimeout
+	WebhookPollInterval time.Duration
+	WebhookTimeout      time.Duration
 }
 
+// minJWTSecretLength is the length of an HS256 key in bytes, shorter keys are easier to brute force
+const minJWTSecretLength = 32
+
 var (
 	config Config
 	once   sync.Once
@@ -28,6 +92,36 @@ func LoadConfig() Config {
 		viper.AddConfigPath(".")
 		viper.AddConfigPath("./configs")
 		viper.AutomaticEnv()
+		viper.SetDefault("ServerAddr", ":8080")
+		viper.SetDefault("ServerReadTimeout", "15s")
+		viper.SetDefault("ServerWriteTimeout", "30s")
+		viper.SetDefault("ServerIdleTimeout", "60s")
+		viper.SetDefault("ShutdownDelay", "5s")
+		viper.SetDefault("ShutdownTimeout", "30s")
+		viper.SetDefault("ReadinessTimeout", "2s")
+		viper.SetDefault("MetricsAddr", "")
+		viper.SetDefault("MetricsTimeout", "5s")
+		viper.SetDefault("TraceExporter", "none")
+		viper.SetDefault("TraceEndpoint", "")
+		viper.SetDefault("TraceSampleRatio", 1.0)
+		viper.SetDefault("DBMaxConns", 10)
+		viper.SetDefault("DBMinConns", 2)
+		viper.SetDefault("DBMaxConnIdleTime", "30m")
+		viper.SetDefault("DBMaxConnLifetime", "1h")
+		viper.SetDefault("DBHealthCheckPeriod", "1m")
+		viper.SetDefault("JWTSigningMethod", "HS256")
+		viper.SetDefault("AccessTokenTTL", "15m")
+		viper.SetDefault("RefreshTokenTTL", "720h")
+		viper.SetDefault("MaxTodoDepth", 3)
+		viper.SetDefault("TrashRetention", "720h")
+		viper.SetDefault("TrashPurgeInterval", "1h")
+		viper.SetDefault("IdempotencyStore", "postgres")
+		viper.SetDefault("IdempotencyKeyTTL", "24h")
+		viper.SetDefault("IdempotencyPurgeInterval", "1h")
+		viper.SetDefault("EventRetention", "24h")
+		viper.SetDefault("EventPurgeInterval", "1h")
+		viper.SetDefault("WebhookPollInterval", "5s")
+		viper.SetDefault("WebhookTimeout", "10s")
 
 		if err := viper.ReadInConfig(); err != nil {
 			logger.Error.Fatalf("error reading config file, %s", err)
@@ -39,6 +133,46 @@ func LoadConfig() Config {
 			DBUser: viper.GetString("DBUser"),
 			DBPass: viper.GetString("DBPass"),
 			DBName: viper.GetString("DBName"),
+
+			ServerAddr:         viper.GetString("ServerAddr"),
+			ServerReadTimeout:  viper.GetDuration("ServerReadTimeout"),
+			ServerWriteTimeout: viper.GetDuration("ServerWriteTimeout"),
+			ServerIdleTimeout:  viper.GetDuration("ServerIdleTimeout"),
+			ShutdownDelay:      viper.GetDuration("ShutdownDelay"),
+			ShutdownTimeout:    viper.GetDuration("ShutdownTimeout"),
+			ReadinessTimeout:   viper.GetDuration("ReadinessTimeout"),
+			MetricsAddr:        viper.GetString("MetricsAddr"),
+			MetricsTimeout:     viper.GetDuration("MetricsTimeout"),
+			TraceExporter:      viper.GetString("TraceExporter"),
+			TraceEndpoint:      viper.GetString("TraceEndpoint"),
+			TraceSampleRatio:   viper.GetFloat64("TraceSampleRatio"),
+
+			DBMaxConns:          viper.GetInt32("DBMaxConns"),
+			DBMinConns:          viper.GetInt32("DBMinConns"),
+			DBMaxConnIdleTime:   viper.GetDuration("DBMaxConnIdleTime"),
+			DBMaxConnLifetime:   viper.GetDuration("DBMaxConnLifetime"),
+			DBHealthCheckPeriod: viper.GetDuration("DBHealthCheckPeriod"),
+
+			JWTSigningMethod: viper.GetString("JWTSigningMethod"),
+			JWTSecret:        viper.GetString("JWTSecret"),
+			JWTKeyFile:       viper.GetString("JWTKeyFile"),
+			AccessTokenTTL:   viper.GetDuration("AccessTokenTTL"),
+			RefreshTokenTTL:  viper.GetDuration("RefreshTokenTTL"),
+
+			MaxTodoDepth: viper.GetInt("MaxTodoDepth"),
+
+			TrashRetention:     viper.GetDuration("TrashRetention"),
+			TrashPurgeInterval: viper.GetDuration("TrashPurgeInterval"),
+
+			IdempotencyStore:         viper.GetString("IdempotencyStore"),
+			IdempotencyKeyTTL:        viper.GetDuration("IdempotencyKeyTTL"),
+			IdempotencyPurgeInterval: viper.GetDuration("IdempotencyPurgeInterval"),
+
+			EventRetention:     viper.GetDuration("EventRetention"),
+			EventPurgeInterval: viper.GetDuration("EventPurgeInterval"),
+
+			WebhookPollInterval: viper.GetDuration("WebhookPollInterval"),
+			WebhookTimeout:      viper.GetDuration("WebhookTimeout"),
 		}
 
 		if err := validateConfig(config); err != nil {
@@ -54,5 +188,67 @@ func validateConfig(config Config) error {
 	if config.DBHost == "" || config.DBPort == "" || config.DBUser == "" || config.DBPass == "" || config.DBName == "" {
 		return errors.New("missing field in config file")
 	}
+	if config.ServerAddr == "" {
+		return errors.New("ServerAddr cannot be empty")
+	}
+	if config.ServerReadTimeout <= 0 || config.ServerWriteTimeout <= 0 || config.ServerIdleTimeout <= 0 {
+		return errors.New("ServerReadTimeout, ServerWriteTimeout and ServerIdleTimeout must be positive durations")
+	}
+	if config.ShutdownDelay < 0 || config.ShutdownTimeout <= config.ShutdownDelay {
+		return errors.New("ShutdownDelay cannot be negative and ShutdownTimeout must be longer than ShutdownDelay")
+	}
+	if config.ReadinessTimeout <= 0 || config.MetricsTimeout <= 0 {
+		return errors.New("ReadinessTimeout and MetricsTimeout must be positive durations")
+	}
+	if config.MetricsAddr != "" && config.MetricsAddr == config.ServerAddr {
+		return errors.New("MetricsAddr must differ from ServerAddr")
+	}
+	switch config.TraceExporter {
+	case "none", "otlp", "stdout", "memory":
+	default:
+		return errors.New("TraceExporter must be none, otlp, stdout or memory")
+	}
+	if config.TraceSampleRatio < 0 || config.TraceSampleRatio > 1 {
+		return errors.New("TraceSampleRatio must be between 0 and 1")
+	}
+	if config.DBMaxConns < 1 || config.DBMinConns < 0 || config.DBMinConns > config.DBMaxConns {
+		return errors.New("DBMaxConns must be at least 1 and DBMinConns between 0 and DBMaxConns")
+	}
+	if config.DBMaxConnIdleTime <= 0 || config.DBMaxConnLifetime <= 0 || config.DBHealthCheckPeriod <= 0 {
+		return errors.New("DBMaxConnIdleTime, DBMaxConnLifetime and DBHealthCheckPeriod must be positive durations")
+	}
+	switch config.JWTSigningMethod {
+	case "HS256":
+		if len(config.JWTSecret) < minJWTSecretLength {
+			return errors.New("JWTSecret of at least 32 bytes is required for the HS256 signing method")
+		}
+	case "EdDSA":
+		if config.JWTKeyFile == "" {
+			return errors.New("JWTKeyFile is required for the EdDSA signing method")
+		}
+	default:
+		return errors.New("JWTSigningMethod must be HS256 or EdDSA")
+	}
+	if config.AccessTokenTTL <= 0 || config.RefreshTokenTTL <= 0 {
+		return errors.New("AccessTokenTTL and RefreshTokenTTL must be positive durations")
+	}
+	if config.MaxTodoDepth < 1 {
+		return errors.New("MaxTodoDepth must be at least 1")
+	}
+	if config.TrashRetention <= 0 || config.TrashPurgeInterval <= 0 {
+		return errors.New("TrashRetention and TrashPurgeInterval must be positive durations")
+	}
+	if config.IdempotencyStore != "postgres" && config.IdempotencyStore != "memory" {
+		return errors.New("IdempotencyStore must be postgres or memory")
+	}
+	if config.IdempotencyKeyTTL <= 0 || config.IdempotencyPurgeInterval <= 0 {
+		return errors.New("IdempotencyKeyTTL and IdempotencyPurgeInterval must be positive durations")
+	}
+	if config.EventRetention <= 0 || config.EventPurgeInterval <= 0 {
+		return errors.New("EventRetention and EventPurgeInterval must be positive durations")
+	}
+	if config.WebhookPollInterval <= 0 || config.WebhookTimeout <= 0 {
+		return errors.New("WebhookPollInterval and WebhookTimeout must be positive durations")
+	}
 	return nil
 }
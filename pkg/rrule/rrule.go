@@ -0,0 +1,282 @@
+// Package rrule parses and expands the subset of RFC 5545 recurrence rules supported by recurring todos:
+// FREQ (DAILY, WEEKLY or MONTHLY), INTERVAL, BYDAY, COUNT and UNTIL
+package rrule
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+)
+
+// maxPeriods bounds the number of periods scanned for occurrences, a rule like FREQ=MONTHLY;BYDAY=5FR
+// can skip several periods in a row but never that many
+const maxPeriods = 1000
+
+// untilLayouts are the UNTIL formats accepted, a date or a UTC date-time
+var untilLayouts = []string{"20060102T150405Z", "20060102"}
+
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// Weekday is a BYDAY entry, N picks the nth weekday of the month (negative counts from the end)
+// and is 0 for every such weekday
+type Weekday struct {
+	Day time.Weekday
+	N   int
+}
+
+func (w Weekday) String() string {
+	day := strings.ToUpper(w.Day.String()[:2])
+	if w.N == 0 {
+		return day
+	}
+	return strconv.Itoa(w.N) + day
+}
+
+// Rule is a parsed recurrence rule, the first occurrence of a series is always its start
+type Rule struct {
+	Freq     Frequency
+	Interval int
+	ByDay    []Weekday
+	// Count is the number of occurrences including the start, 0 for no limit
+	Count int
+	// Until is the last instant an occurrence can fall on, nil for no limit
+	Until *time.Time
+}
+
+// Parse parses a rule such as FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH;COUNT=10, an RRULE: prefix is allowed
+func Parse(s string) (Rule, error) {
+	rule := Rule{Interval: 1}
+	s = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(s)), "RRULE:")
+	if s == "" {
+		return Rule{}, errors.New("empty rule")
+	}
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(s, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" {
+			return Rule{}, fmt.Errorf("invalid rule part %q", part)
+		}
+		if seen[name] {
+			return Rule{}, fmt.Errorf("duplicate rule part %s", name)
+		}
+		seen[name] = true
+
+		var err error
+		switch name {
+		case "FREQ":
+			switch Frequency(value) {
+			case Daily, Weekly, Monthly:
+				rule.Freq = Frequency(value)
+			default:
+				return Rule{}, fmt.Errorf("unsupported FREQ %s: must be DAILY, WEEKLY or MONTHLY", value)
+			}
+		case "INTERVAL":
+			if rule.Interval, err = parsePositive(value); err != nil {
+				return Rule{}, fmt.Errorf("invalid INTERVAL %s", value)
+			}
+		case "COUNT":
+			if rule.Count, err = parsePositive(value); err != nil {
+				return Rule{}, fmt.Errorf("invalid COUNT %s", value)
+			}
+		case "UNTIL":
+			until, err := parseUntil(value)
+			if err != nil {
+				return Rule{}, err
+			}
+			rule.Until = &until
+		case "BYDAY":
+			for _, day := range strings.Split(value, ",") {
+				weekday, err := parseWeekday(day)
+				if err != nil {
+					return Rule{}, err
+				}
+				rule.ByDay = append(rule.ByDay, weekday)
+			}
+		default:
+			return Rule{}, fmt.Errorf("unsupported rule part %s", name)
+		}
+	}
+	if rule.Freq == "" {
+		return Rule{}, errors.New("FREQ is required")
+	}
+	if rule.Count > 0 && rule.Until != nil {
+		return Rule{}, errors.New("COUNT and UNTIL cannot be combined")
+	}
+	if rule.Freq != Monthly {
+		for _, day := range rule.ByDay {
+			if day.N != 0 {
+				return Rule{}, fmt.Errorf("BYDAY %s can only be numbered with FREQ=MONTHLY", day)
+			}
+		}
+	}
+	return rule, nil
+}
+
+func parsePositive(value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return 0, errors.New("not a positive number")
+	}
+	return n, nil
+}
+
+func parseUntil(value string) (time.Time, error) {
+	for _, layout := range untilLayouts {
+		if until, err := time.Parse(layout, value); err == nil {
+			if layout == "20060102" {
+				// a date includes the whole day
+				until = until.Add(24*time.Hour - time.Second)
+			}
+			return until, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid UNTIL %s: must be a date or a UTC date-time like 20240601T170000Z", value)
+}
+
+func parseWeekday(value string) (Weekday, error) {
+	if len(value) < 2 {
+		return Weekday{}, fmt.Errorf("invalid BYDAY %s", value)
+	}
+	day, ok := weekdays[value[len(value)-2:]]
+	if !ok {
+		return Weekday{}, fmt.Errorf("invalid BYDAY %s", value)
+	}
+	weekday := Weekday{Day: day}
+	if prefix := value[:len(value)-2]; prefix != "" {
+		n, err := strconv.Atoi(prefix)
+		if err != nil || n == 0 || n < -5 || n > 5 {
+			return Weekday{}, fmt.Errorf("invalid BYDAY %s", value)
+		}
+		weekday.N = n
+	}
+	return weekday, nil
+}
+
+// String formats the rule in its canonical form
+func (r Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, 0, len(r.ByDay))
+		for _, day := range r.ByDay {
+			days = append(days, day.String())
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format(untilLayouts[0]))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Next returns up to n occurrences following start, the first occurrence of the series. Occurrences keep
+// the clock time and the location of start
+func (r Rule) Next(start time.Time, n int) []time.Time {
+	interval := r.Interval
+	if interval < 1 {
+		interval = 1
+	}
+	var occurrences []time.Time
+	// the start counts as the first occurrence
+	emitted := 1
+	for period := 0; period < maxPeriods && len(occurrences) < n; period++ {
+		for _, t := range r.candidates(start, period*interval) {
+			if !t.After(start) {
+				continue
+			}
+			if (r.Count > 0 && emitted >= r.Count) || (r.Until != nil && t.After(*r.Until)) {
+				return occurrences
+			}
+			occurrences = append(occurrences, t)
+			emitted++
+			if len(occurrences) == n {
+				break
+			}
+		}
+	}
+	return occurrences
+}
+
+// candidates returns the sorted occurrences of the period offset periods after the one containing start,
+// without applying COUNT and UNTIL
+func (r Rule) candidates(start time.Time, offset int) []time.Time {
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, start.Hour(), start.Minute(), start.Second(), start.Nanosecond(), start.Location())
+	}
+	var days []time.Time
+	switch r.Freq {
+	case Daily:
+		day := at(start.Year(), start.Month(), start.Day()+offset)
+		if len(r.ByDay) == 0 || r.hasWeekday(day.Weekday()) {
+			days = append(days, day)
+		}
+	case Weekly:
+		// weeks start on Monday
+		monday := at(start.Year(), start.Month(), start.Day()-(int(start.Weekday())+6)%7+7*offset)
+		if len(r.ByDay) == 0 {
+			days = append(days, monday.AddDate(0, 0, (int(start.Weekday())+6)%7))
+		}
+		for i := 0; i < 7; i++ {
+			if day := monday.AddDate(0, 0, i); r.hasWeekday(day.Weekday()) {
+				days = append(days, day)
+			}
+		}
+	case Monthly:
+		first := at(start.Year(), start.Month()+time.Month(offset), 1)
+		length := first.AddDate(0, 1, -1).Day()
+		if len(r.ByDay) == 0 {
+			// months too short for the day of the start are skipped
+			if start.Day() <= length {
+				days = append(days, at(first.Year(), first.Month(), start.Day()))
+			}
+		}
+		for d := 1; d <= length; d++ {
+			day := at(first.Year(), first.Month(), d)
+			for _, weekday := range r.ByDay {
+				if day.Weekday() != weekday.Day {
+					continue
+				}
+				nth, nthFromEnd := (d-1)/7+1, -((length-d)/7 + 1)
+				if weekday.N == 0 || weekday.N == nth || weekday.N == nthFromEnd {
+					days = append(days, day)
+					break
+				}
+			}
+		}
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+	return days
+}
+
+func (r Rule) hasWeekday(day time.Weekday) bool {
+	for _, weekday := range r.ByDay {
+		if weekday.Day == day {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,163 @@
+package rrule
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 9, 30, 0, 0, time.UTC)
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    string
+		want    string
+		wantErr bool
+	}{
+		{name: "Canonical", rule: "rrule:freq=weekly;interval=2;byday=mo,th;count=10", want: "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH;COUNT=10"},
+		{name: "Until Date-Time", rule: "FREQ=DAILY;UNTIL=20240601T170000Z", want: "FREQ=DAILY;UNTIL=20240601T170000Z"},
+		{name: "Until Date", rule: "FREQ=DAILY;UNTIL=20240601", want: "FREQ=DAILY;UNTIL=20240601T235959Z"},
+		{name: "Numbered Weekday", rule: "FREQ=MONTHLY;BYDAY=-1FR,2MO", want: "FREQ=MONTHLY;BYDAY=-1FR,2MO"},
+		{name: "Empty", rule: " ", wantErr: true},
+		{name: "Missing Freq", rule: "INTERVAL=2", wantErr: true},
+		{name: "Unsupported Freq", rule: "FREQ=YEARLY", wantErr: true},
+		{name: "Missing Value", rule: "FREQ", wantErr: true},
+		{name: "Duplicate Part", rule: "FREQ=DAILY;FREQ=WEEKLY", wantErr: true},
+		{name: "Unsupported Part", rule: "FREQ=WEEKLY;WKST=MO", wantErr: true},
+		{name: "Zero Interval", rule: "FREQ=DAILY;INTERVAL=0", wantErr: true},
+		{name: "Negative Count", rule: "FREQ=DAILY;COUNT=-1", wantErr: true},
+		{name: "Invalid Until", rule: "FREQ=DAILY;UNTIL=tomorrow", wantErr: true},
+		{name: "Count And Until", rule: "FREQ=DAILY;COUNT=2;UNTIL=20240601", wantErr: true},
+		{name: "Invalid Weekday", rule: "FREQ=WEEKLY;BYDAY=XX", wantErr: true},
+		{name: "Weekday Out Of Range", rule: "FREQ=MONTHLY;BYDAY=6MO", wantErr: true},
+		{name: "Numbered Weekly Weekday", rule: "FREQ=WEEKLY;BYDAY=1MO", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := Parse(tt.rule)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, rule.String())
+		})
+	}
+}
+
+func TestNext(t *testing.T) {
+	tests := []struct {
+		name  string
+		rule  string
+		start time.Time
+		n     int
+		want  []time.Time
+	}{
+		{
+			name:  "Daily",
+			rule:  "FREQ=DAILY;INTERVAL=2",
+			start: date(2024, time.June, 1),
+			n:     3,
+			want:  []time.Time{date(2024, time.June, 3), date(2024, time.June, 5), date(2024, time.June, 7)},
+		},
+		{
+			name:  "Daily On Weekdays",
+			rule:  "FREQ=DAILY;BYDAY=MO,FR",
+			start: date(2024, time.June, 3),
+			n:     3,
+			want:  []time.Time{date(2024, time.June, 7), date(2024, time.June, 10), date(2024, time.June, 14)},
+		},
+		{
+			name:  "Weekly By Day",
+			rule:  "FREQ=WEEKLY;BYDAY=MO,TH",
+			start: date(2024, time.June, 3),
+			n:     4,
+			want:  []time.Time{date(2024, time.June, 6), date(2024, time.June, 10), date(2024, time.June, 13), date(2024, time.June, 17)},
+		},
+		{
+			name:  "Weekly Every Other Week",
+			rule:  "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU",
+			start: date(2024, time.June, 3),
+			n:     3,
+			want:  []time.Time{date(2024, time.June, 4), date(2024, time.June, 18), date(2024, time.July, 2)},
+		},
+		{
+			name:  "Weekly On The Start Weekday",
+			rule:  "FREQ=WEEKLY",
+			start: date(2024, time.June, 5),
+			n:     2,
+			want:  []time.Time{date(2024, time.June, 12), date(2024, time.June, 19)},
+		},
+		{
+			name:  "Monthly On The 31st",
+			rule:  "FREQ=MONTHLY",
+			start: date(2024, time.January, 31),
+			n:     3,
+			want:  []time.Time{date(2024, time.March, 31), date(2024, time.May, 31), date(2024, time.July, 31)},
+		},
+		{
+			name:  "Monthly Last Friday",
+			rule:  "FREQ=MONTHLY;BYDAY=-1FR",
+			start: date(2024, time.June, 28),
+			n:     3,
+			want:  []time.Time{date(2024, time.July, 26), date(2024, time.August, 30), date(2024, time.September, 27)},
+		},
+		{
+			name:  "Monthly Second Monday",
+			rule:  "FREQ=MONTHLY;BYDAY=2MO",
+			start: date(2024, time.June, 10),
+			n:     2,
+			want:  []time.Time{date(2024, time.July, 8), date(2024, time.August, 12)},
+		},
+		{
+			name:  "Monthly Fifth Friday",
+			rule:  "FREQ=MONTHLY;BYDAY=5FR",
+			start: date(2024, time.May, 31),
+			n:     2,
+			want:  []time.Time{date(2024, time.August, 30), date(2024, time.November, 29)},
+		},
+		{
+			name:  "Until",
+			rule:  "FREQ=DAILY;UNTIL=20240603",
+			start: date(2024, time.June, 1),
+			n:     10,
+			want:  []time.Time{date(2024, time.June, 2), date(2024, time.June, 3)},
+		},
+		{
+			name:  "Count Includes The Start",
+			rule:  "FREQ=DAILY;COUNT=3",
+			start: date(2024, time.June, 1),
+			n:     10,
+			want:  []time.Time{date(2024, time.June, 2), date(2024, time.June, 3)},
+		},
+		{
+			name:  "Count Of One",
+			rule:  "FREQ=WEEKLY;COUNT=1",
+			start: date(2024, time.June, 1),
+			n:     10,
+			want:  nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := Parse(tt.rule)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, tt.want, rule.Next(tt.start, tt.n))
+		})
+	}
+}
+
+func TestNextKeepsLocation(t *testing.T) {
+	cet := time.FixedZone("CET", 3600)
+	rule, err := Parse("FREQ=DAILY")
+	if !assert.NoError(t, err) {
+		return
+	}
+	start := time.Date(2024, time.June, 1, 8, 15, 0, 0, cet)
+	assert.Equal(t, []time.Time{time.Date(2024, time.June, 2, 8, 15, 0, 0, cet)}, rule.Next(start, 1))
+}
@@ -0,0 +1,7 @@
+-- File: 000002_todo_pagination.down.sql
+
+DROP INDEX IF EXISTS todo_created_at_id_idx;
+
+ALTER TABLE todo
+    ALTER COLUMN created_at DROP NOT NULL,
+    ALTER COLUMN created_at DROP DEFAULT;
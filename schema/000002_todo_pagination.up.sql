@@ -0,0 +1,11 @@
+-- File: 000002_todo_pagination.up.sql
+
+-- Backfilling creation time so every row takes part in keyset pagination
+UPDATE todo SET created_at = NOW() WHERE created_at IS NULL;
+
+ALTER TABLE todo
+    ALTER COLUMN created_at SET DEFAULT NOW(),
+    ALTER COLUMN created_at SET NOT NULL;
+
+-- Index backing the (created_at, id) ordering of list queries
+CREATE INDEX IF NOT EXISTS todo_created_at_id_idx ON todo (created_at, id);
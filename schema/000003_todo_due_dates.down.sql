@@ -0,0 +1,7 @@
+-- File: 000003_todo_due_dates.down.sql
+
+DROP INDEX IF EXISTS todo_due_at_idx;
+
+ALTER TABLE todo
+    DROP COLUMN IF EXISTS remind_at,
+    DROP COLUMN IF EXISTS due_at;
@@ -0,0 +1,9 @@
+-- File: 000003_todo_due_dates.up.sql
+
+-- Adding due date and reminder to todos
+ALTER TABLE todo
+    ADD COLUMN IF NOT EXISTS due_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS remind_at TIMESTAMPTZ;
+
+-- Index backing the overdue and due range queries
+CREATE INDEX IF NOT EXISTS todo_due_at_idx ON todo (due_at) WHERE due_at IS NOT NULL;
@@ -0,0 +1,5 @@
+-- File: 000004_tags.down.sql
+
+-- Dropping tag tables
+DROP TABLE IF EXISTS todo_tag;
+DROP TABLE IF EXISTS tag;
@@ -0,0 +1,19 @@
+-- File: 000004_tags.up.sql
+
+-- Creating tag table
+CREATE TABLE IF NOT EXISTS tag (
+                                   id SERIAL PRIMARY KEY,
+                                   name TEXT NOT NULL UNIQUE CHECK (LENGTH(name) <= 64),
+                                   color TEXT,
+                                   description TEXT,
+                                   created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Creating join table between todos and tags
+CREATE TABLE IF NOT EXISTS todo_tag (
+                                        todo_id INTEGER NOT NULL REFERENCES todo (id) ON DELETE CASCADE,
+                                        tag_id INTEGER NOT NULL REFERENCES tag (id) ON DELETE CASCADE,
+                                        PRIMARY KEY (todo_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS todo_tag_tag_id_idx ON todo_tag (tag_id);
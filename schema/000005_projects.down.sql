@@ -0,0 +1,7 @@
+-- File: 000005_projects.down.sql
+
+DROP INDEX IF EXISTS todo_project_id_idx;
+ALTER TABLE todo DROP COLUMN IF EXISTS project_id;
+
+-- Dropping project table
+DROP TABLE IF EXISTS project;
@@ -0,0 +1,20 @@
+-- File: 000005_projects.up.sql
+
+-- Creating project table
+CREATE TABLE IF NOT EXISTS project (
+                                       id SERIAL PRIMARY KEY,
+                                       name TEXT NOT NULL CHECK (LENGTH(name) <= 255),
+                                       description TEXT,
+                                       color TEXT,
+                                       archived BOOLEAN NOT NULL DEFAULT FALSE,
+                                       inbox BOOLEAN NOT NULL DEFAULT FALSE,
+                                       created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- There is exactly one inbox, it receives the todos of deleted projects
+CREATE UNIQUE INDEX IF NOT EXISTS project_inbox_idx ON project (inbox) WHERE inbox;
+INSERT INTO project (name, description, inbox) VALUES ('Inbox', 'Todos of deleted projects', TRUE);
+
+-- Linking todos to projects
+ALTER TABLE todo ADD COLUMN IF NOT EXISTS project_id INTEGER REFERENCES project (id);
+CREATE INDEX IF NOT EXISTS todo_project_id_idx ON todo (project_id);
@@ -0,0 +1,18 @@
+-- File: 000006_users.down.sql
+
+ALTER TABLE tag DROP CONSTRAINT IF EXISTS tag_owner_id_name_key;
+ALTER TABLE tag ADD CONSTRAINT tag_name_key UNIQUE (name);
+
+ALTER TABLE todo DROP CONSTRAINT IF EXISTS todo_project_id_owner_id_fkey;
+ALTER TABLE todo ADD CONSTRAINT todo_project_id_fkey FOREIGN KEY (project_id) REFERENCES project (id);
+ALTER TABLE project DROP CONSTRAINT IF EXISTS project_id_owner_id_key;
+
+DROP INDEX IF EXISTS project_owner_inbox_idx;
+DROP INDEX IF EXISTS todo_owner_id_created_at_id_idx;
+
+ALTER TABLE tag DROP COLUMN IF EXISTS owner_id;
+ALTER TABLE project DROP COLUMN IF EXISTS owner_id;
+ALTER TABLE todo DROP COLUMN IF EXISTS owner_id;
+
+-- Dropping users table
+DROP TABLE IF EXISTS users;
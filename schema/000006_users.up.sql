@@ -0,0 +1,45 @@
+-- File: 000006_users.up.sql
+
+-- Creating users table
+CREATE TABLE IF NOT EXISTS users (
+                                     id SERIAL PRIMARY KEY,
+                                     email TEXT NOT NULL UNIQUE,
+                                     password_hash TEXT NOT NULL,
+                                     created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Every todo, project and tag belongs to a user
+ALTER TABLE todo ADD COLUMN IF NOT EXISTS owner_id INTEGER REFERENCES users (id) ON DELETE CASCADE;
+ALTER TABLE project ADD COLUMN IF NOT EXISTS owner_id INTEGER REFERENCES users (id) ON DELETE CASCADE;
+ALTER TABLE tag ADD COLUMN IF NOT EXISTS owner_id INTEGER REFERENCES users (id) ON DELETE CASCADE;
+
+-- Rows created before this migration are given to the legacy user, which is only created when there are any.
+-- Its password hash is empty so nobody can log in as it until its password is set or its rows are reassigned
+INSERT INTO users (email, password_hash)
+SELECT 'legacy@localhost', ''
+WHERE EXISTS (SELECT 1 FROM todo) OR EXISTS (SELECT 1 FROM tag) OR EXISTS (SELECT 1 FROM project WHERE NOT inbox);
+-- the shared inbox becomes the inbox of the legacy user, without one it is not needed anymore
+DELETE FROM project WHERE owner_id IS NULL AND inbox AND NOT EXISTS (SELECT 1 FROM users WHERE email = 'legacy@localhost');
+UPDATE todo SET owner_id = (SELECT id FROM users WHERE email = 'legacy@localhost') WHERE owner_id IS NULL;
+UPDATE project SET owner_id = (SELECT id FROM users WHERE email = 'legacy@localhost') WHERE owner_id IS NULL;
+UPDATE tag SET owner_id = (SELECT id FROM users WHERE email = 'legacy@localhost') WHERE owner_id IS NULL;
+
+ALTER TABLE todo ALTER COLUMN owner_id SET NOT NULL;
+ALTER TABLE project ALTER COLUMN owner_id SET NOT NULL;
+ALTER TABLE tag ALTER COLUMN owner_id SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS todo_owner_id_created_at_id_idx ON todo (owner_id, created_at, id);
+
+-- Each user has an own inbox
+DROP INDEX IF EXISTS project_inbox_idx;
+CREATE UNIQUE INDEX IF NOT EXISTS project_owner_inbox_idx ON project (owner_id) WHERE inbox;
+
+-- Todos can only be put into projects of their owner
+ALTER TABLE project ADD CONSTRAINT project_id_owner_id_key UNIQUE (id, owner_id);
+ALTER TABLE todo DROP CONSTRAINT IF EXISTS todo_project_id_fkey;
+ALTER TABLE todo ADD CONSTRAINT todo_project_id_owner_id_fkey
+    FOREIGN KEY (project_id, owner_id) REFERENCES project (id, owner_id);
+
+-- Tag names are unique per user
+ALTER TABLE tag DROP CONSTRAINT IF EXISTS tag_name_key;
+ALTER TABLE tag ADD CONSTRAINT tag_owner_id_name_key UNIQUE (owner_id, name);
@@ -0,0 +1,4 @@
+-- File: 000007_refresh_tokens.down.sql
+
+-- Dropping refresh_token table
+DROP TABLE IF EXISTS refresh_token;
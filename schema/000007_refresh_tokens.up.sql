@@ -0,0 +1,13 @@
+-- File: 000007_refresh_tokens.up.sql
+
+-- Creating refresh_token table, only the SHA-256 hash of a token is stored
+CREATE TABLE IF NOT EXISTS refresh_token (
+                                             id SERIAL PRIMARY KEY,
+                                             user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+                                             token_hash TEXT NOT NULL UNIQUE,
+                                             expires_at TIMESTAMP NOT NULL,
+                                             revoked_at TIMESTAMP,
+                                             created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS refresh_token_user_id_idx ON refresh_token (user_id);
@@ -0,0 +1,4 @@
+-- File: 000008_api_keys.down.sql
+
+-- Dropping api_key table
+DROP TABLE IF EXISTS api_key;
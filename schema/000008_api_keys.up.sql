@@ -0,0 +1,16 @@
+-- File: 000008_api_keys.up.sql
+
+-- Creating api_key table, only the SHA-256 hash of a key is stored
+CREATE TABLE IF NOT EXISTS api_key (
+                                       id SERIAL PRIMARY KEY,
+                                       user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+                                       name VARCHAR(64) NOT NULL,
+                                       prefix TEXT NOT NULL,
+                                       key_hash TEXT NOT NULL UNIQUE,
+                                       scopes TEXT[] NOT NULL,
+                                       last_used_at TIMESTAMP,
+                                       revoked_at TIMESTAMP,
+                                       created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS api_key_user_id_idx ON api_key (user_id);
@@ -0,0 +1,4 @@
+-- File: 000009_todo_search.down.sql
+
+DROP INDEX IF EXISTS todo_search_idx;
+ALTER TABLE todo DROP COLUMN IF EXISTS search;
@@ -0,0 +1,11 @@
+-- File: 000009_todo_search.up.sql
+
+-- Full-text search document of a todo, matches in the title weigh more than matches in the description
+ALTER TABLE todo
+    ADD COLUMN IF NOT EXISTS search TSVECTOR GENERATED ALWAYS AS (
+        setweight(to_tsvector('english', COALESCE(title, '')), 'A') ||
+        setweight(to_tsvector('english', COALESCE(description, '')), 'B')
+    ) STORED;
+
+-- Index backing the search queries
+CREATE INDEX IF NOT EXISTS todo_search_idx ON todo USING GIN (search);
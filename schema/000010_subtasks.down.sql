@@ -0,0 +1,7 @@
+-- File: 000010_subtasks.down.sql
+
+DROP INDEX IF EXISTS todo_parent_id_position_idx;
+ALTER TABLE todo
+    DROP CONSTRAINT IF EXISTS todo_parent_id_check,
+    DROP COLUMN IF EXISTS position,
+    DROP COLUMN IF EXISTS parent_id;
@@ -0,0 +1,11 @@
+-- File: 000010_subtasks.up.sql
+
+-- Todos can be nested under a parent todo, subtasks are deleted with their parent
+-- and ordered by position among their siblings
+ALTER TABLE todo
+    ADD COLUMN IF NOT EXISTS parent_id INTEGER REFERENCES todo (id) ON DELETE CASCADE,
+    ADD COLUMN IF NOT EXISTS position INTEGER NOT NULL DEFAULT 0,
+    ADD CONSTRAINT todo_parent_id_check CHECK (parent_id <> id);
+
+-- Index backing the subtask lists and the progress counts
+CREATE INDEX IF NOT EXISTS todo_parent_id_position_idx ON todo (parent_id, position) WHERE parent_id IS NOT NULL;
@@ -0,0 +1,6 @@
+-- File: 000011_recurring_todos.down.sql
+
+DROP INDEX IF EXISTS todo_series_id_idx;
+ALTER TABLE todo
+    DROP COLUMN IF EXISTS series_id,
+    DROP COLUMN IF EXISTS recurrence;
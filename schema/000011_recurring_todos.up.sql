@@ -0,0 +1,10 @@
+-- File: 000011_recurring_todos.up.sql
+
+-- A recurring todo carries an RFC 5545 recurrence rule, completing it creates the next instance.
+-- The instances of a series share the id of its first todo, the link survives deleting that todo
+ALTER TABLE todo
+    ADD COLUMN IF NOT EXISTS recurrence TEXT,
+    ADD COLUMN IF NOT EXISTS series_id INTEGER;
+
+-- Index backing the series lookups
+CREATE INDEX IF NOT EXISTS todo_series_id_idx ON todo (series_id) WHERE series_id IS NOT NULL;
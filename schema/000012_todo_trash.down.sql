@@ -0,0 +1,5 @@
+-- File: 000012_todo_trash.down.sql
+
+DROP INDEX IF EXISTS todo_deleted_at_idx;
+ALTER TABLE todo
+    DROP COLUMN IF EXISTS deleted_at;
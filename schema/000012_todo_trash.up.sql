@@ -0,0 +1,8 @@
+-- File: 000012_todo_trash.up.sql
+
+-- Deleted todos go to the trash first, they are purged after the configured retention
+ALTER TABLE todo
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+-- Index backing the trash listing and the purge
+CREATE INDEX IF NOT EXISTS todo_deleted_at_idx ON todo (deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,4 @@
+-- File: 000013_todo_version.down.sql
+
+ALTER TABLE todo
+    DROP COLUMN IF EXISTS version;
@@ -0,0 +1,5 @@
+-- File: 000013_todo_version.up.sql
+
+-- Every write to a todo increments its version, it backs the ETag of the todo and the If-Match checks
+ALTER TABLE todo
+    ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
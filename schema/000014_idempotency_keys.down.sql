@@ -0,0 +1,4 @@
+-- File: 000014_idempotency_keys.down.sql
+
+-- Dropping idempotency_key table
+DROP TABLE IF EXISTS idempotency_key;
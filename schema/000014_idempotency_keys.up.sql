@@ -0,0 +1,17 @@
+-- File: 000014_idempotency_keys.up.sql
+
+-- Creating idempotency_key table, it keeps the fingerprint of a request sent with an Idempotency-Key header
+-- and, once the request completed, its response to replay it to retries until the key expires
+CREATE TABLE IF NOT EXISTS idempotency_key (
+                                               user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+                                               key TEXT NOT NULL,
+                                               fingerprint TEXT NOT NULL,
+                                               status_code INTEGER,
+                                               headers JSONB,
+                                               body BYTEA,
+                                               created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+                                               expires_at TIMESTAMPTZ NOT NULL,
+                                               PRIMARY KEY (user_id, key)
+);
+
+CREATE INDEX IF NOT EXISTS idempotency_key_expires_at_idx ON idempotency_key (expires_at);
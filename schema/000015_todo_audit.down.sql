@@ -0,0 +1,4 @@
+-- File: 000015_todo_audit.down.sql
+
+-- Dropping todo_audit table
+DROP TABLE IF EXISTS todo_audit;
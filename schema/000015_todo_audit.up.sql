@@ -0,0 +1,16 @@
+-- File: 000015_todo_audit.up.sql
+
+-- Creating todo_audit table, every change of a todo is recorded in the transaction making it. Entries outlive
+-- the todo they describe and list the changed fields as {"field": {"old": ..., "new": ...}}
+CREATE TABLE IF NOT EXISTS todo_audit (
+                                          id BIGSERIAL PRIMARY KEY,
+                                          owner_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+                                          todo_id INTEGER NOT NULL,
+                                          actor_id INTEGER NOT NULL,
+                                          operation TEXT NOT NULL,
+                                          changes JSONB NOT NULL,
+                                          created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS todo_audit_owner_id_id_idx ON todo_audit (owner_id, id);
+CREATE INDEX IF NOT EXISTS todo_audit_todo_id_id_idx ON todo_audit (todo_id, id);
@@ -0,0 +1,4 @@
+-- File: 000016_todo_events.down.sql
+
+-- Dropping todo_event table
+DROP TABLE IF EXISTS todo_event;
@@ -0,0 +1,16 @@
+-- File: 000016_todo_events.up.sql
+
+-- Creating todo_event table, the log of the changes streamed to the subscribers of the event feed. Events are
+-- written in the transaction of the change, which notifies the todo_events channel on commit, and are purged
+-- after the retention so that clients can only resume a recent stream
+CREATE TABLE IF NOT EXISTS todo_event (
+                                          id BIGSERIAL PRIMARY KEY,
+                                          owner_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+                                          todo_id INTEGER NOT NULL,
+                                          type TEXT NOT NULL,
+                                          todo JSONB,
+                                          created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS todo_event_owner_id_id_idx ON todo_event (owner_id, id);
+CREATE INDEX IF NOT EXISTS todo_event_created_at_idx ON todo_event (created_at);
@@ -0,0 +1,6 @@
+-- File: 000017_webhooks.down.sql
+
+-- Dropping webhook_attempt, webhook_delivery and webhook tables
+DROP TABLE IF EXISTS webhook_attempt;
+DROP TABLE IF EXISTS webhook_delivery;
+DROP TABLE IF EXISTS webhook;
@@ -0,0 +1,45 @@
+-- File: 000017_webhooks.up.sql
+
+-- Creating webhook table, the endpoints notified of changes of todos. An endpoint is marked unhealthy after
+-- repeated failed attempts and gets no deliveries until it is updated
+CREATE TABLE IF NOT EXISTS webhook (
+                                       id SERIAL PRIMARY KEY,
+                                       owner_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+                                       url TEXT NOT NULL,
+                                       secret TEXT NOT NULL,
+                                       event_types TEXT[] NOT NULL,
+                                       healthy BOOLEAN NOT NULL DEFAULT TRUE,
+                                       failure_count INTEGER NOT NULL DEFAULT 0,
+                                       created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS webhook_owner_id_idx ON webhook (owner_id);
+
+-- Creating webhook_delivery table, the outbox written in the transaction of the change of a todo. Pending
+-- deliveries are claimed by the dispatcher by pushing next_attempt_at past the attempt
+CREATE TABLE IF NOT EXISTS webhook_delivery (
+                                                id BIGSERIAL PRIMARY KEY,
+                                                webhook_id INTEGER NOT NULL REFERENCES webhook (id) ON DELETE CASCADE,
+                                                event_type TEXT NOT NULL,
+                                                payload JSONB NOT NULL,
+                                                status TEXT NOT NULL DEFAULT 'pending',
+                                                attempts INTEGER NOT NULL DEFAULT 0,
+                                                next_attempt_at TIMESTAMPTZ DEFAULT NOW(),
+                                                created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+                                                delivered_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS webhook_delivery_webhook_id_id_idx ON webhook_delivery (webhook_id, id);
+CREATE INDEX IF NOT EXISTS webhook_delivery_pending_idx ON webhook_delivery (next_attempt_at) WHERE status = 'pending';
+
+-- Creating webhook_attempt table, every attempt to deliver a payload with its outcome
+CREATE TABLE IF NOT EXISTS webhook_attempt (
+                                               id BIGSERIAL PRIMARY KEY,
+                                               delivery_id BIGINT NOT NULL REFERENCES webhook_delivery (id) ON DELETE CASCADE,
+                                               status_code INTEGER,
+                                               error TEXT NOT NULL DEFAULT '',
+                                               duration_ms BIGINT NOT NULL,
+                                               attempted_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS webhook_attempt_delivery_id_idx ON webhook_attempt (delivery_id);
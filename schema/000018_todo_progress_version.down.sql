@@ -0,0 +1,6 @@
+-- File: 000018_todo_progress_version.down.sql
+
+DROP TRIGGER IF EXISTS todo_delete_touch_parent ON todo;
+DROP TRIGGER IF EXISTS todo_update_touch_parent ON todo;
+DROP TRIGGER IF EXISTS todo_insert_touch_parent ON todo;
+DROP FUNCTION IF EXISTS todo_touch_parent();
@@ -0,0 +1,33 @@
+-- File: 000018_todo_progress_version.up.sql
+
+-- The progress of a todo counts its done and open subtasks outside the trash and is part of its representation,
+-- so the version of the parent moves whenever a subtask is created, completed, reopened, moved, trashed or restored.
+-- The parent only gets a new version, which fires no trigger again
+CREATE OR REPLACE FUNCTION todo_touch_parent() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP <> 'INSERT' AND OLD.parent_id IS NOT NULL THEN
+        UPDATE todo SET version = version + 1 WHERE id = OLD.parent_id;
+    END IF;
+    IF TG_OP <> 'DELETE' AND NEW.parent_id IS NOT NULL AND (TG_OP = 'INSERT' OR NEW.parent_id IS DISTINCT FROM OLD.parent_id) THEN
+        UPDATE todo SET version = version + 1 WHERE id = NEW.parent_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER todo_insert_touch_parent
+    AFTER INSERT ON todo
+    FOR EACH ROW WHEN (NEW.parent_id IS NOT NULL AND NEW.deleted_at IS NULL)
+    EXECUTE FUNCTION todo_touch_parent();
+
+CREATE TRIGGER todo_update_touch_parent
+    AFTER UPDATE OF completed, deleted_at, parent_id ON todo
+    FOR EACH ROW WHEN (OLD.completed IS DISTINCT FROM NEW.completed OR OLD.deleted_at IS DISTINCT FROM NEW.deleted_at
+        OR OLD.parent_id IS DISTINCT FROM NEW.parent_id)
+    EXECUTE FUNCTION todo_touch_parent();
+
+-- a subtask in the trash is not counted, removing it for good leaves the progress as it is
+CREATE TRIGGER todo_delete_touch_parent
+    AFTER DELETE ON todo
+    FOR EACH ROW WHEN (OLD.parent_id IS NOT NULL AND OLD.deleted_at IS NULL)
+    EXECUTE FUNCTION todo_touch_parent();